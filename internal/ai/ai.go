@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"html"
 	"strings"
+	"sync"
 
 	"github.com/coding-hui/common/util/slices"
 	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
@@ -24,12 +25,27 @@ type Engine struct {
 	running bool
 	channel chan StreamCompletionOutput
 
-	convoStore convo.Store
-	model      Model
+	convoStore  convo.Store
+	model       Model
+	modelCfg    options.Model
+	apiCfg      options.API
+	clients     *modelClients
+	compactions *failedCompactions
 
 	Config *options.Config
 }
 
+// modelClients caches the model clients created by an engine and the engines derived from it,
+// so every model is only connected once per session.
+type modelClients struct {
+	mu      sync.Mutex
+	clients map[string]Model
+}
+
+func clientKey(mod options.Model, api options.API) string {
+	return api.Name + "/" + mod.Name
+}
+
 func New(ops ...Option) (*Engine, error) {
 	return applyOptions(ops...)
 }
@@ -50,6 +66,107 @@ func (e *Engine) GetConvoStore() convo.Store {
 	return e.convoStore
 }
 
+// GetModel returns the settings of the model this engine talks to.
+func (e *Engine) GetModel() options.Model {
+	return e.modelCfg
+}
+
+// ForModel returns an engine that talks to the named model and shares the channel,
+// convo store and config of e. Model clients are cached, so several engines can be
+// used side by side without reconnecting. An empty name, or the current model name
+// through the current API, returns e itself.
+func (e *Engine) ForModel(name string) (*Engine, error) {
+	if name == "" {
+		return e, nil
+	}
+
+	mod, err := e.Config.GetModel(name)
+	if err != nil {
+		return nil, err
+	}
+	if mod.Name == "" {
+		mod.Name = name
+	}
+
+	api, err := e.resolveAPI(mod)
+	if err != nil {
+		return nil, err
+	}
+	if mod.Name == e.modelCfg.Name && api.Name == e.apiCfg.Name {
+		return e, nil
+	}
+
+	client, err := e.clientFor(mod, api)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Engine{
+		mode:        e.mode,
+		channel:     e.channel,
+		convoStore:  e.convoStore,
+		model:       client,
+		modelCfg:    mod,
+		apiCfg:      api,
+		clients:     e.clients,
+		compactions: e.compactions,
+		Config:      e.Config,
+	}, nil
+}
+
+// resolveAPI returns the API used to talk to the model. Like the config, the selected
+// API (--api, or /chat-model in the coder) wins over the API of the model, and the
+// current API is the fallback.
+func (e *Engine) resolveAPI(mod options.Model) (options.API, error) {
+	name := e.Config.API
+	if name == "" {
+		name = mod.API
+	}
+	if name == "" {
+		name = e.Config.CurrentAPI.Name
+	}
+	return e.Config.GetAPI(name)
+}
+
+// Copy returns an engine for the same model with its own channel and state, sharing the
+// model clients, failed compactions, convo store and config of e. Sessions that run at
+// the same time each use their own copy.
+func (e *Engine) Copy() *Engine {
+	return &Engine{
+		mode:        e.mode,
+		channel:     make(chan StreamCompletionOutput),
+		convoStore:  e.convoStore,
+		model:       e.model,
+		modelCfg:    e.modelCfg,
+		apiCfg:      e.apiCfg,
+		clients:     e.clients,
+		compactions: e.compactions,
+		Config:      e.Config,
+	}
+}
+
+func (e *Engine) clientFor(mod options.Model, api options.API) (Model, error) {
+	if e.clients == nil {
+		e.clients = &modelClients{clients: map[string]Model{}}
+	}
+
+	e.clients.mu.Lock()
+	defer e.clients.mu.Unlock()
+
+	key := clientKey(mod, api)
+	if client, ok := e.clients.clients[key]; ok {
+		return client, nil
+	}
+
+	client, err := newModelClient(mod, api)
+	if err != nil {
+		return nil, errbook.Wrap(fmt.Sprintf("Failed to create client for model %s.", mod.Name), err)
+	}
+	e.clients.clients[key] = client
+
+	return client, nil
+}
+
 func (e *Engine) Interrupt() {
 	e.channel <- StreamCompletionOutput{
 		Content:    "[Interrupt]",
@@ -64,7 +181,13 @@ func (e *Engine) Interrupt() {
 func (e *Engine) CreateCompletion(ctx context.Context, messages []llms.ChatMessage) (*CompletionOutput, error) {
 	e.running = true
 
-	if err := e.setupChatContext(ctx, &messages); err != nil {
+	// the status is rendered as a step, no chat is listening to the channel
+	status := func(s string) {
+		if !e.Config.Quiet && s != "" {
+			console.RenderStep("%s", s)
+		}
+	}
+	if err := e.setupChatContext(ctx, &messages, status); err != nil {
 		return nil, err
 	}
 
@@ -76,7 +199,7 @@ func (e *Engine) CreateCompletion(ctx context.Context, messages []llms.ChatMessa
 	content := rsp.Choices[0].Content
 	content = html.UnescapeString(content)
 
-	e.appendAssistantMessage(content)
+	e.appendAssistantMessage(content, rsp.Usage)
 
 	e.running = false
 
@@ -88,6 +211,24 @@ func (e *Engine) CreateCompletion(ctx context.Context, messages []llms.ChatMessa
 	}, nil
 }
 
+// Generate creates a one-off completion for the given messages. Unlike CreateCompletion
+// it neither reads nor writes the conversation history, which makes it suitable for
+// background tasks such as summaries.
+func (e *Engine) Generate(ctx context.Context, messages []llms.ChatMessage) (*CompletionOutput, error) {
+	rsp, err := e.model.GenerateContent(ctx, slices.Map(messages, convert), e.callOptions()...)
+	if err != nil {
+		return nil, errbook.Wrap("Failed to create completion.", err)
+	}
+	if len(rsp.Choices) == 0 {
+		return nil, errbook.New("The model returned no choices.")
+	}
+
+	return &CompletionOutput{
+		Explanation: html.UnescapeString(rsp.Choices[0].Content),
+		Usage:       rsp.Usage,
+	}, nil
+}
+
 func (e *Engine) CreateStreamCompletion(ctx context.Context, messages []llms.ChatMessage) (*StreamCompletionOutput, error) {
 	e.running = true
 
@@ -101,7 +242,13 @@ func (e *Engine) CreateStreamCompletion(ctx context.Context, messages []llms.Cha
 		return nil
 	}
 
-	if err := e.setupChatContext(ctx, &messages); err != nil {
+	// the chat shows the status until the reply streams in
+	status := func(s string) {
+		if !e.Config.Quiet {
+			e.channel <- StreamCompletionOutput{Status: s}
+		}
+	}
+	if err := e.setupChatContext(ctx, &messages, status); err != nil {
 		return nil, err
 	}
 
@@ -140,7 +287,7 @@ func (e *Engine) CreateStreamCompletion(ctx context.Context, messages []llms.Cha
 	}
 	e.running = false
 
-	e.appendAssistantMessage(output)
+	e.appendAssistantMessage(output, rsp.Usage)
 
 	return &StreamCompletionOutput{
 		Content:    output,
@@ -158,8 +305,8 @@ func (e *Engine) callOptions(streamingFunc ...func(ctx context.Context, chunk []
 	if len(streamingFunc) > 0 && streamingFunc[0] != nil {
 		opts = append(opts, llms.WithStreamingFunc(streamingFunc[0]))
 	}
-	opts = append(opts, llms.WithModel(e.Config.CurrentModel.Name))
-	opts = append(opts, llms.WithMaxLength(e.Config.CurrentModel.MaxChars))
+	opts = append(opts, llms.WithModel(e.modelCfg.Name))
+	opts = append(opts, llms.WithMaxLength(e.modelCfg.MaxChars))
 	opts = append(opts, llms.WithTemperature(e.Config.Temperature))
 	opts = append(opts, llms.WithTopP(e.Config.TopP))
 	opts = append(opts, llms.WithTopK(e.Config.TopK))
@@ -168,13 +315,15 @@ func (e *Engine) callOptions(streamingFunc ...func(ctx context.Context, chunk []
 	return opts
 }
 
-func (e *Engine) setupChatContext(ctx context.Context, messages *[]llms.ChatMessage) error {
+func (e *Engine) setupChatContext(ctx context.Context, messages *[]llms.ChatMessage, status func(string)) error {
 	store := e.convoStore
 	if store == nil {
 		return errbook.New("no chat convo store found")
 	}
 
 	if !e.Config.NoCache && e.Config.CacheReadFromID != "" {
+		e.compactHistory(ctx, e.Config.CacheReadFromID, status)
+
 		history, err := store.Messages(ctx, e.Config.CacheReadFromID)
 		if err != nil {
 			return errbook.Wrap(fmt.Sprintf(
@@ -189,9 +338,9 @@ func (e *Engine) setupChatContext(ctx context.Context, messages *[]llms.ChatMess
 	return nil
 }
 
-func (e *Engine) appendAssistantMessage(content string) {
+func (e *Engine) appendAssistantMessage(content string, usage llms.Usage) {
 	if e.convoStore != nil && e.Config.CacheWriteToID != "" {
-		if err := e.convoStore.AddAIMessage(context.Background(), e.Config.CacheWriteToID, content); err != nil {
+		if err := e.convoStore.AddAIMessageWithUsage(context.Background(), e.Config.CacheWriteToID, content, e.modelCfg.Name, usage); err != nil {
 			errbook.HandleError(errbook.Wrap("failed to add assistant chat output message to convo", err))
 		}
 	}
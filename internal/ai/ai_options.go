@@ -63,29 +63,36 @@ func applyOptions(engineOpts ...Option) (engine *Engine, err error) {
 		return nil, err
 	}
 
-	mod, api := cfg.CurrentModel, cfg.CurrentAPI
+	engine.modelCfg = cfg.CurrentModel
+	engine.apiCfg = cfg.CurrentAPI
+	engine.model, err = newModelClient(cfg.CurrentModel, cfg.CurrentAPI)
+	if err != nil {
+		return nil, err
+	}
+	engine.clients = &modelClients{
+		clients: map[string]Model{clientKey(cfg.CurrentModel, cfg.CurrentAPI): engine.model},
+	}
+	engine.compactions = &failedCompactions{chars: map[string]int{}}
+
+	return engine, nil
+}
+
+// newModelClient creates the client used to talk to the given model through the given API.
+func newModelClient(mod options.Model, api options.API) (Model, error) {
 	switch api.Name {
 	case ModelTypeARK:
-		engine.model, err = volcengine.NewClientWithApiKey(
-			cfg.CurrentAPI.APIKey,
+		return volcengine.NewClientWithApiKey(
+			api.APIKey,
 			arkruntime.WithBaseUrl(api.BaseURL),
 			arkruntime.WithRegion(api.Region),
 			arkruntime.WithTimeout(api.Timeout),
 			arkruntime.WithRetryTimes(api.RetryTimes),
 		)
-		if err != nil {
-			return nil, err
-		}
 	default:
-		engine.model, err = openai.New(
+		return openai.New(
 			openai.WithModel(mod.Name),
 			openai.WithBaseURL(api.BaseURL),
 			openai.WithToken(api.APIKey),
 		)
-		if err != nil {
-			return nil, err
-		}
 	}
-
-	return engine, nil
 }
@@ -0,0 +1,118 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coding-hui/ai-terminal/internal/options"
+)
+
+func TestEngine(t *testing.T) {
+	t.Run("for model", testForModel)
+	t.Run("for unknown model", testForUnknownModel)
+	t.Run("for model with selected api", testForModelSelectedAPI)
+	t.Run("copy", testCopy)
+}
+
+func newTestEngine(t *testing.T) *Engine {
+	t.Helper()
+
+	cfg := &options.Config{
+		Model: "gpt-4o",
+		Models: map[string]options.Model{
+			"gpt-4o":       {Name: "gpt-4o", API: "openai", MaxChars: 1000},
+			"o1-mini":      {Name: "o1-mini", API: "openai", MaxChars: 2000},
+			"doubao-coder": {Name: "doubao-coder", API: ModelTypeARK, MaxChars: 3000},
+		},
+		APIs: options.APIs{
+			{Name: "openai", APIKey: "test", BaseURL: "http://localhost"},
+			{Name: ModelTypeARK, APIKey: "test", BaseURL: "http://localhost"},
+		},
+	}
+	cfg.CurrentModel = cfg.Models["gpt-4o"]
+	cfg.CurrentAPI = cfg.APIs[0]
+
+	client, err := newModelClient(cfg.CurrentModel, cfg.CurrentAPI)
+	require.NoError(t, err)
+
+	return &Engine{
+		channel:  make(chan StreamCompletionOutput),
+		model:    client,
+		modelCfg: cfg.CurrentModel,
+		apiCfg:   cfg.CurrentAPI,
+		clients:  &modelClients{clients: map[string]Model{clientKey(cfg.CurrentModel, cfg.CurrentAPI): client}},
+		Config:   cfg,
+	}
+}
+
+func testForModel(t *testing.T) {
+	engine := newTestEngine(t)
+
+	same, err := engine.ForModel("")
+	require.NoError(t, err)
+	require.Same(t, engine, same)
+
+	same, err = engine.ForModel("gpt-4o")
+	require.NoError(t, err)
+	require.Same(t, engine, same)
+
+	design, err := engine.ForModel("o1-mini")
+	require.NoError(t, err)
+	require.NotSame(t, engine, design)
+	require.Equal(t, "o1-mini", design.GetModel().Name)
+	require.Equal(t, engine.GetChannel(), design.GetChannel())
+	require.Equal(t, "gpt-4o", engine.GetModel().Name)
+
+	coding, err := engine.ForModel("doubao-coder")
+	require.NoError(t, err)
+	require.Equal(t, 3000, coding.GetModel().MaxChars)
+	require.Len(t, engine.clients.clients, 3)
+
+	again, err := design.ForModel("o1-mini")
+	require.NoError(t, err)
+	require.Same(t, design, again)
+
+	fromCoding, err := coding.ForModel("o1-mini")
+	require.NoError(t, err)
+	require.Same(t, design.model, fromCoding.model)
+	require.Len(t, engine.clients.clients, 3)
+}
+
+func testForUnknownModel(t *testing.T) {
+	engine := newTestEngine(t)
+
+	_, err := engine.ForModel("unknown")
+	require.Error(t, err)
+}
+
+func testForModelSelectedAPI(t *testing.T) {
+	engine := newTestEngine(t)
+
+	// switching the API like /chat-model does must not reuse the clients of the old API
+	engine.Config.API = ModelTypeARK
+
+	switched, err := engine.ForModel("gpt-4o")
+	require.NoError(t, err)
+	require.NotSame(t, engine, switched)
+	require.Equal(t, ModelTypeARK, switched.apiCfg.Name)
+
+	design, err := engine.ForModel("o1-mini")
+	require.NoError(t, err)
+	require.Equal(t, ModelTypeARK, design.apiCfg.Name)
+	require.Contains(t, engine.clients.clients, clientKey(design.GetModel(), design.apiCfg))
+
+	again, err := switched.ForModel("gpt-4o")
+	require.NoError(t, err)
+	require.Same(t, switched, again)
+}
+
+func testCopy(t *testing.T) {
+	engine := newTestEngine(t)
+
+	copied := engine.Copy()
+	require.NotSame(t, engine, copied)
+	require.NotEqual(t, engine.channel, copied.channel)
+	require.Same(t, engine.clients, copied.clients)
+	require.Equal(t, engine.GetModel(), copied.GetModel())
+}
@@ -0,0 +1,201 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
+	"k8s.io/klog/v2"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+)
+
+const (
+	// CompactSummaryPrefix starts the message that replaces the compacted turns of a conversation.
+	CompactSummaryPrefix = "Summary of the earlier conversation:\n"
+
+	// compactExcerptLen limits how much of every message is sent to be summarized.
+	compactExcerptLen = 2000
+
+	// compactTimeout limits how long the model may take to summarize a conversation before it is sent.
+	compactTimeout = time.Minute
+)
+
+// CompactResult tells how a conversation was compacted.
+type CompactResult struct {
+	// Revision is the new revision of the conversation messages
+	Revision int
+	// Summarized is the number of turns replaced by the summary
+	Summarized int
+	// Kept is the number of recent turns kept as they were
+	Kept int
+}
+
+// CompactConversation summarizes the older turns of the stored conversation messages into
+// one summary message, followed by the recent turns that fit into half of the compaction
+// budget. The result is stored as a new revision, the original messages are kept.
+// Unless force is set, it only compacts when the messages exceed the compaction threshold
+// of the model input budget. It returns nil when there was nothing to compact.
+func (e *Engine) CompactConversation(ctx context.Context, convoID string, force bool) (*CompactResult, error) {
+	if e.convoStore == nil {
+		return nil, errbook.New("no chat convo store found")
+	}
+
+	budget := e.compactBudget()
+	if !force && budget <= 0 {
+		return nil, nil
+	}
+
+	messages, err := e.convoStore.ConversationMessages(ctx, convoID)
+	if err != nil {
+		return nil, err
+	}
+	if !force && messagesChars(messages) <= budget {
+		return nil, nil
+	}
+
+	turns := (&convo.Transcript{Messages: messages}).Turns()
+	kept := recentTurns(turns, budget/2)
+	older := turns[:len(turns)-kept]
+	if len(older) == 0 {
+		return nil, nil
+	}
+
+	summary, err := e.summarizeTurns(ctx, older)
+	if err != nil {
+		return nil, err
+	}
+
+	compacted := []convo.Message{{
+		Role:      string(llms.ChatMessageTypeSystem),
+		Content:   CompactSummaryPrefix + summary,
+		Model:     e.modelCfg.Name,
+		CreatedAt: time.Now().UTC(),
+	}}
+	for _, turn := range turns[len(older):] {
+		compacted = append(compacted, turn...)
+	}
+
+	revision, err := e.convoStore.CompactMessages(ctx, convoID, compacted)
+	if err != nil {
+		return nil, err
+	}
+	return &CompactResult{Revision: revision, Summarized: len(older), Kept: kept}, nil
+}
+
+// compactHistory compacts the conversation before it is sent when it exceeds the compaction
+// threshold, reporting its progress through status. A failed or timed out compaction is not
+// fatal, the whole history is sent instead, and it is not tried again before the history grew.
+func (e *Engine) compactHistory(ctx context.Context, convoID string, status func(string)) {
+	budget := e.compactBudget()
+	if budget <= 0 {
+		return
+	}
+	messages, err := e.convoStore.ConversationMessages(ctx, convoID)
+	if err != nil {
+		return
+	}
+	chars := messagesChars(messages)
+	if chars <= budget || e.compactions.failedAt(convoID) >= chars {
+		return
+	}
+
+	status(fmt.Sprintf("Compacting the conversation history with %s", e.modelCfg.Name))
+	ctx, cancel := context.WithTimeout(ctx, compactTimeout)
+	defer cancel()
+	if _, err := e.CompactConversation(ctx, convoID, false); err != nil {
+		klog.V(1).Infof("Couldn't compact conversation %s: %v", convoID, err)
+		e.compactions.fail(convoID, chars)
+		status("Couldn't compact the conversation history, sending all of it")
+		return
+	}
+	status(e.Config.LoadingText)
+}
+
+// failedCompactions remembers the size of the conversations whose compaction failed,
+// shared by an engine and the engines derived from it.
+type failedCompactions struct {
+	mu    sync.Mutex
+	chars map[string]int
+}
+
+// failedAt returns the number of characters of the conversation when its compaction
+// last failed, 0 when it didn't.
+func (f *failedCompactions) failedAt(convoID string) int {
+	if f == nil {
+		return 0
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.chars[convoID]
+}
+
+func (f *failedCompactions) fail(convoID string, chars int) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.chars[convoID] = chars
+}
+
+// compactBudget returns the number of characters the conversation may use before it is compacted.
+func (e *Engine) compactBudget() int {
+	return int(e.Config.GetCompactThreshold() * float64(e.modelCfg.MaxChars))
+}
+
+// summarizeTurns asks the model for a summary of the turns.
+func (e *Engine) summarizeTurns(ctx context.Context, turns []convo.Turn) (string, error) {
+	var sb strings.Builder
+	for _, turn := range turns {
+		for _, m := range turn {
+			content := strings.TrimSpace(m.Content)
+			if content == "" {
+				continue
+			}
+			if runes := []rune(content); len(runes) > compactExcerptLen {
+				content = string(runes[:compactExcerptLen]) + "..."
+			}
+			fmt.Fprintf(&sb, "%s: %s\n\n", m.Heading(), content)
+		}
+	}
+
+	out, err := e.Generate(ctx, []llms.ChatMessage{
+		llms.SystemChatMessage{Content: compactPrompt},
+		llms.HumanChatMessage{Content: sb.String()},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	summary := strings.TrimSpace(out.Explanation)
+	if summary == "" {
+		return "", errbook.New("The model returned an empty summary.")
+	}
+	return summary, nil
+}
+
+// recentTurns counts the most recent turns that fit into budget characters,
+// the last turn is always kept.
+func recentTurns(turns []convo.Turn, budget int) int {
+	used := 0
+	for kept := 0; kept < len(turns); kept++ {
+		used += messagesChars(turns[len(turns)-1-kept])
+		if kept > 0 && used > budget {
+			return kept
+		}
+	}
+	return len(turns)
+}
+
+func messagesChars(messages []convo.Message) int {
+	total := 0
+	for _, m := range messages {
+		total += len(m.Content)
+	}
+	return total
+}
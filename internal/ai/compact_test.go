@@ -0,0 +1,150 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/convo/sqlite3"
+)
+
+func TestCompactConversation(t *testing.T) {
+	var summarized string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &request)
+		summarized = request.Messages[len(request.Messages)-1].Content
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"- the user fixes the parser"},"finish_reason":"stop"}]}`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	store, err := sqlite3.NewSqliteStore(
+		sqlite3.WithDBAddress(filepath.Join(dir, "convo.db")),
+		sqlite3.WithDataPath(dir),
+	)
+	require.NoError(t, err)
+	defer store.Close() //nolint:errcheck
+
+	engine := newTestEngine(t)
+	engine.Config.APIs[0].BaseURL = server.URL
+	engine.model, err = newModelClient(engine.modelCfg, engine.Config.APIs[0])
+	require.NoError(t, err)
+	engine.convoStore = store
+
+	ctx := context.Background()
+	convoID := convo.NewConversationID()
+	turn := strings.Repeat("x", 150)
+	require.NoError(t, store.SetMessages(ctx, convoID, []llms.ChatMessage{
+		llms.HumanChatMessage{Content: "first question " + turn},
+		llms.AIChatMessage{Content: "first answer " + turn},
+		llms.HumanChatMessage{Content: "second question"},
+		llms.AIChatMessage{Content: "second answer"},
+	}))
+
+	// 1000 max chars and the default threshold allow 800 chars
+	result, err := engine.CompactConversation(ctx, convoID, false)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	require.NoError(t, store.AddUserMessage(ctx, convoID, "third question "+turn+turn))
+	require.NoError(t, store.AddAIMessage(ctx, convoID, "third answer "+turn))
+	require.NoError(t, store.PersistentMessages(ctx, convoID))
+
+	result, err = engine.CompactConversation(ctx, convoID, false)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, CompactResult{Revision: 1, Summarized: 2, Kept: 1}, *result)
+	assert.Contains(t, summarized, "first question")
+	assert.NotContains(t, summarized, "third question")
+
+	messages, err := store.Messages(ctx, convoID)
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	assert.Equal(t, llms.SystemChatMessage{Content: CompactSummaryPrefix + "- the user fixes the parser"}, messages[0])
+	assert.Equal(t, llms.ChatMessageTypeHuman, messages[1].GetType())
+
+	// the summary and the last turn are all that is left
+	result, err = engine.CompactConversation(ctx, convoID, true)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func TestCompactHistoryFailure(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	store, err := sqlite3.NewSqliteStore(
+		sqlite3.WithDBAddress(filepath.Join(dir, "convo.db")),
+		sqlite3.WithDataPath(dir),
+	)
+	require.NoError(t, err)
+	defer store.Close() //nolint:errcheck
+
+	engine := newTestEngine(t)
+	engine.Config.APIs[0].BaseURL = server.URL
+	engine.model, err = newModelClient(engine.modelCfg, engine.Config.APIs[0])
+	require.NoError(t, err)
+	engine.convoStore = store
+	engine.compactions = &failedCompactions{chars: map[string]int{}}
+
+	ctx := context.Background()
+	convoID := convo.NewConversationID()
+	history := []llms.ChatMessage{
+		llms.HumanChatMessage{Content: "first question " + strings.Repeat("x", 500)},
+		llms.AIChatMessage{Content: "first answer " + strings.Repeat("x", 500)},
+		llms.HumanChatMessage{Content: "second question"},
+		llms.AIChatMessage{Content: "second answer"},
+	}
+	require.NoError(t, store.SetMessages(ctx, convoID, history))
+	engine.Config.CacheReadFromID = convoID
+
+	var statuses []string
+	status := func(s string) { statuses = append(statuses, s) }
+
+	// the whole history is sent when the summary fails
+	var messages []llms.ChatMessage
+	require.NoError(t, engine.setupChatContext(ctx, &messages, status))
+	assert.Equal(t, history, messages)
+	require.Len(t, statuses, 2)
+	assert.Contains(t, statuses[0], "Compacting the conversation history")
+	assert.Contains(t, statuses[1], "Couldn't compact the conversation history")
+	failed := requests.Load()
+
+	// the failed compaction isn't retried until the history grew
+	messages, statuses = nil, nil
+	require.NoError(t, engine.setupChatContext(ctx, &messages, status))
+	assert.Equal(t, history, messages)
+	assert.Empty(t, statuses)
+	assert.Equal(t, failed, requests.Load())
+
+	require.NoError(t, store.AddUserMessage(ctx, convoID, "third question"))
+	require.NoError(t, store.PersistentMessages(ctx, convoID))
+	messages, statuses = nil, nil
+	require.NoError(t, engine.setupChatContext(ctx, &messages, status))
+	assert.Len(t, statuses, 2)
+	assert.Greater(t, requests.Load(), failed)
+}
@@ -1 +1,12 @@
 package ai
+
+const (
+	// titlePrompt asks the title model to name a conversation.
+	titlePrompt = `You name chat conversations. Reply with a title for the conversation below, at most 60 characters.
+Use the language of the conversation. Reply with the title only: no quotes, no trailing punctuation, no markdown.`
+
+	// compactPrompt asks the model to summarize the older turns of a long conversation.
+	compactPrompt = `Summarize the conversation between a user and an AI assistant the user sends, it replaces the conversation in the assistant's memory.
+Keep the user's goals, decisions, constraints, facts, names, code identifiers and open questions. Drop greetings and repetition.
+If the conversation starts with an earlier summary, merge it into the new summary. Reply with the summary only, as short markdown bullet points.`
+)
@@ -0,0 +1,88 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
+
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+)
+
+const (
+	// MaxTitleLen is the maximum length of a generated title, in characters.
+	MaxTitleLen = 60
+
+	// titleExcerptLen limits how much of every message is sent to the title model.
+	titleExcerptLen = 1000
+)
+
+// GenerateTitle asks the configured title model for a short title of the conversation.
+// It fails when no title model is configured, so the caller can fall back to another title.
+func (e *Engine) GenerateTitle(ctx context.Context, messages []llms.ChatMessage) (string, error) {
+	if e.Config.TitleModel == "" {
+		return "", errbook.New("No title model configured.")
+	}
+
+	engine, err := e.ForModel(e.Config.TitleModel)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, msg := range messages {
+		content := strings.TrimSpace(msg.GetContent())
+		if content == "" || msg.GetType() == llms.ChatMessageTypeSystem {
+			continue
+		}
+		if runes := []rune(content); len(runes) > titleExcerptLen {
+			content = string(runes[:titleExcerptLen]) + "..."
+		}
+		fmt.Fprintf(&sb, "%s: %s\n\n", msg.GetType(), content)
+	}
+	if sb.Len() == 0 {
+		return "", errbook.New("The conversation has no messages to name it after.")
+	}
+
+	out, err := engine.Generate(ctx, []llms.ChatMessage{
+		llms.SystemChatMessage{Content: titlePrompt},
+		llms.HumanChatMessage{Content: sb.String()},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	title := CleanTitle(out.Explanation)
+	if title == "" {
+		return "", errbook.New("The title model returned an empty title.")
+	}
+	return title, nil
+}
+
+// CleanTitle makes a one-line title of at most MaxTitleLen characters from a model reply,
+// dropping quotes, markdown emphasis and trailing punctuation.
+func CleanTitle(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "#*_ ")
+		line = strings.TrimPrefix(line, "Title:")
+		line = strings.Trim(line, "\"'`*_“”‘’ ")
+		if line == "" {
+			continue
+		}
+
+		title := strings.Join(strings.Fields(line), " ")
+		if runes := []rune(title); len(runes) > MaxTitleLen {
+			title = string(runes[:MaxTitleLen])
+			if i := strings.LastIndex(title, " "); i > MaxTitleLen/2 {
+				title = title[:i]
+			}
+		}
+		return strings.TrimRightFunc(title, func(r rune) bool {
+			return unicode.IsSpace(r) || r == '.' || r == ',' || r == ':' || r == ';'
+		})
+	}
+	return ""
+}
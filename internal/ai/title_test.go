@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
+
+	"github.com/coding-hui/ai-terminal/internal/options"
+)
+
+func TestCleanTitle(t *testing.T) {
+	for in, want := range map[string]string{
+		"Fix parser panic on empty input":           "Fix parser panic on empty input",
+		`"Fix parser panic."`:                       "Fix parser panic",
+		"Title: **Redis cache setup**\n\nMore text": "Redis cache setup",
+		"\n\n# Debugging   goroutine leaks\n":       "Debugging goroutine leaks",
+		"“修复解析器崩溃”":                                 "修复解析器崩溃",
+		"":                                          "",
+		strings.Repeat("word ", 20):                 strings.TrimSpace(strings.Repeat("word ", 12)),
+		strings.Repeat("a", 80):                     strings.Repeat("a", MaxTitleLen),
+	} {
+		assert.Equal(t, want, CleanTitle(in), in)
+	}
+}
+
+func TestGenerateTitle(t *testing.T) {
+	var request struct {
+		Model    string `json:"model"`
+		Messages []struct {
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &request)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"1","object":"chat.completion","model":"gpt-4o-mini","choices":[{"index":0,"message":{"role":"assistant","content":"\"Parser panic on empty input.\""},"finish_reason":"stop"}]}`)
+	}))
+	defer server.Close()
+
+	engine := newTestEngine(t)
+	engine.Config.Models["gpt-4o-mini"] = options.Model{Name: "gpt-4o-mini", API: "openai", MaxChars: 1000}
+	engine.Config.APIs[0].BaseURL = server.URL
+
+	messages := []llms.ChatMessage{
+		llms.SystemChatMessage{Content: "you are helpful"},
+		llms.HumanChatMessage{Content: "why does the parser panic on empty input?"},
+		llms.AIChatMessage{Content: "the lexer returns a nil token"},
+	}
+
+	_, err := engine.GenerateTitle(context.Background(), messages)
+	require.Error(t, err, "no title model configured")
+
+	engine.Config.TitleModel = "gpt-4o-mini"
+	title, err := engine.GenerateTitle(context.Background(), messages)
+	require.NoError(t, err)
+	assert.Equal(t, "Parser panic on empty input", title)
+	assert.Equal(t, "gpt-4o-mini", request.Model)
+	require.Len(t, request.Messages, 2)
+	assert.Contains(t, request.Messages[1].Content, "why does the parser panic")
+	assert.NotContains(t, request.Messages[1].Content, "you are helpful")
+
+	server.Close()
+	_, err = engine.GenerateTitle(context.Background(), messages)
+	assert.Error(t, err)
+}
@@ -48,6 +48,8 @@ type StreamCompletionOutput struct {
 	Last       bool
 	Interrupt  bool
 	Executable bool
+	// Status describes the work done before the reply, such as compacting the history
+	Status string
 
 	Usage llms.Usage `json:"usage"`
 }
@@ -56,6 +58,10 @@ func (c StreamCompletionOutput) GetContent() string {
 	return c.Content
 }
 
+func (c StreamCompletionOutput) GetStatus() string {
+	return c.Status
+}
+
 func (c StreamCompletionOutput) IsLast() bool {
 	return c.Last
 }
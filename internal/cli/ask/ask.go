@@ -103,6 +103,7 @@ func (o *Options) Run() error {
 		chat.WithContent(o.pipe+"\n\n"+strings.Join(o.prompts, "\n\n")),
 		chat.WithRunMode(runMode),
 		chat.WithEngine(engine),
+		chat.WithWaitForTitle(true),
 	)
 
 	return chatModel.Run()
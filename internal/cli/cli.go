@@ -98,7 +98,7 @@ func NewAICommand(in io.Reader, out, errOut io.Writer) *cobra.Command {
 		templates.CommandGroup{
 			Message: "AI Commands:",
 			Commands: []*cobra.Command{
-				coder.NewCmdCoder(&cfg),
+				coder.NewCmdCoder(ioStreams, &cfg),
 				ask.NewCmdASK(ioStreams, &cfg),
 				convo.NewCmdConversation(ioStreams, &cfg),
 				commit.NewCmdCommit(ioStreams, &cfg),
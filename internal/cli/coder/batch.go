@@ -0,0 +1,169 @@
+package coder
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/spf13/cobra"
+
+	"github.com/coding-hui/ai-terminal/internal/ai"
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/git"
+	"github.com/coding-hui/ai-terminal/internal/options"
+	"github.com/coding-hui/ai-terminal/internal/ui/coders"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+	"github.com/coding-hui/ai-terminal/internal/util/genericclioptions"
+)
+
+type batchOptions struct {
+	genericclioptions.IOStreams
+	cfg      *options.Config
+	parallel int
+	worktree bool
+}
+
+func newCmdBatch(ioStreams genericclioptions.IOStreams, cfg *options.Config) *cobra.Command {
+	o := &batchOptions{IOStreams: ioStreams, cfg: cfg}
+	cmd := &cobra.Command{
+		Use:   "batch <tasks.yaml>",
+		Short: "Apply the tasks of a task file, each one in an independent coder session.",
+		Example: `# tasks.yaml
+parallel: 2
+worktree: true
+tasks:
+  - name: structured logging
+    globs: ["internal/cli/*/*.go"]
+    instruction: Replace fmt.Printf debug output with the console package.
+  - name: context timeouts
+    files: [internal/ai/ai.go]
+    instruction: Pass a context with a timeout to every completion.
+
+ai coder batch tasks.yaml`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd, args[0])
+		},
+	}
+
+	cmd.Flags().IntVar(&o.parallel, "parallel", 0, "Number of tasks run at once, implies --worktree when greater than 1.")
+	cmd.Flags().BoolVar(&o.worktree, "worktree", false, "Run every task in its own git worktree and commit it onto its own branch.")
+
+	return cmd
+}
+
+func (o *batchOptions) run(cmd *cobra.Command, path string) error {
+	batch, err := coders.LoadBatchFile(path)
+	if err != nil {
+		return err
+	}
+	if cmd.Flags().Changed("parallel") {
+		batch.Parallel = o.parallel
+	}
+	if cmd.Flags().Changed("worktree") {
+		batch.Worktree = o.worktree
+	}
+	if batch.Parallel > 1 {
+		batch.Worktree = true
+	}
+
+	repo := git.New()
+	gitDir, err := repo.GitDir()
+	if err != nil {
+		return errbook.Wrap("Could not get git root", err)
+	}
+	root, err := filepath.Abs(filepath.Dir(gitDir))
+	if err != nil {
+		return errbook.Wrap("Could not get git root", err)
+	}
+
+	engine, err := ai.New(ai.WithConfig(o.cfg))
+	if err != nil {
+		return errbook.Wrap("Could not initialized ai engine", err)
+	}
+
+	autoCoder := coders.NewAutoCoder(
+		coders.WithConfig(o.cfg),
+		coders.WithEngine(engine),
+		coders.WithRepo(repo),
+		coders.WithCodeBasePath(root),
+		coders.WithIOStreams(o.IOStreams),
+	)
+
+	results := autoCoder.RunBatch(context.Background(), batch)
+	_, _ = fmt.Fprintln(o.Out, renderBatchSummary(results))
+
+	failed := 0
+	for _, r := range results {
+		if !r.Succeeded() {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errbook.New("%d of %d tasks failed", failed, len(results))
+	}
+
+	return nil
+}
+
+// renderBatchSummary renders the outcome, the edits, the tokens and the cost of every task.
+// The cost is only known for models with a configured pricing.
+func renderBatchSummary(results []coders.BatchResult) string {
+	styles := console.StdoutStyles()
+	var prompt, completion int
+	var cost float64
+	var total time.Duration
+
+	t := table.New().
+		Border(lipgloss.NormalBorder()).
+		Headers("#", "TASK", "STATUS", "EDITS", "COMMIT", "TOKENS", "COST", "TIME")
+	for i, r := range results {
+		status := styles.CommitSuccess.Render("ok")
+		if !r.Succeeded() {
+			status = styles.ErrorDetails.Render("failed")
+			if r.Err != nil {
+				status += " " + styles.Comment.Render(r.Err.Error())
+			}
+		}
+
+		commit := "-"
+		if r.Commit != "" {
+			commit = r.Commit[:min(len(r.Commit), 7)]
+			if r.Branch != "" {
+				commit += " " + styles.Comment.Render(r.Branch)
+			}
+		}
+
+		t.Row(
+			strconv.Itoa(i+1),
+			r.Task.Name,
+			status,
+			fmt.Sprintf("%d applied, %d failed", r.Applied, r.Failed),
+			commit,
+			fmt.Sprintf("%d in, %d out", r.Usage.PromptTokens, r.Usage.CompletionTokens),
+			formatCost(r.Cost),
+			r.Duration.Round(time.Second).String(),
+		)
+
+		prompt += r.Usage.PromptTokens
+		completion += r.Usage.CompletionTokens
+		cost += r.Cost
+		total += r.Duration
+	}
+
+	return t.String() + "\n" + styles.Comment.Render(
+		fmt.Sprintf("Total: %d prompt tokens, %d completion tokens, %s, %s", prompt, completion, formatCost(cost), total.Round(time.Second)),
+	)
+}
+
+// formatCost renders a cost in USD, a dash when it is unknown.
+func formatCost(cost float64) string {
+	if cost == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("$%.4f", cost)
+}
@@ -1,7 +1,9 @@
 package coder
 
 import (
+	"fmt"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -12,15 +14,20 @@ import (
 	"github.com/coding-hui/ai-terminal/internal/git"
 	"github.com/coding-hui/ai-terminal/internal/options"
 	"github.com/coding-hui/ai-terminal/internal/ui/coders"
+	"github.com/coding-hui/ai-terminal/internal/util/genericclioptions"
 )
 
 type Options struct {
-	cfg    *options.Config
-	prompt string
+	genericclioptions.IOStreams
+	cfg       *options.Config
+	prompt    string
+	assumeYes bool
+	output    string
+	sandbox   bool
 }
 
-func NewCmdCoder(cfg *options.Config) *cobra.Command {
-	ops := &Options{cfg: cfg}
+func NewCmdCoder(ioStreams genericclioptions.IOStreams, cfg *options.Config) *cobra.Command {
+	ops := &Options{IOStreams: ioStreams, cfg: cfg}
 	cmd := &cobra.Command{
 		Use:   "coder",
 		Short: "Automatically generate code based on prompts.",
@@ -28,6 +35,13 @@ func NewCmdCoder(cfg *options.Config) *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&ops.prompt, "prompt", "p", "", "Prompt to generate code.")
+	cmd.Flags().BoolVarP(&ops.assumeYes, "yes", "y", false, "Answer yes to every confirmation, so the coder runs without a TTY.")
+	cmd.Flags().StringVarP(&ops.output, "output", "o", coders.OutputText,
+		fmt.Sprintf("Format of the report printed after a prompt run. One of: %s.", strings.Join(coders.SupportedOutputFormats(), ", ")))
+
+	cmd.Flags().BoolVar(&ops.sandbox, "sandbox", false, "Edit and commit in a git worktree on a new ai/<session> branch, finish with /merge or /discard.")
+
+	cmd.AddCommand(newCmdBatch(ioStreams, cfg))
 
 	return cmd
 }
@@ -37,6 +51,13 @@ func (o *Options) run(_ *cobra.Command, args []string) error {
 		o.prompt = strings.Join(args, " ") + "\n" + o.prompt
 	}
 
+	if !slices.Contains(coders.SupportedOutputFormats(), o.output) {
+		return errbook.New("Invalid output format %s. Please use one of: %s.", o.output, strings.Join(coders.SupportedOutputFormats(), ", "))
+	}
+	if o.output == coders.OutputJSON && strings.TrimSpace(o.prompt) == "" {
+		return errbook.New("--output json requires a prompt, use -p to pass one")
+	}
+
 	repo := git.New()
 	root, err := repo.GitDir()
 	if err != nil {
@@ -60,6 +81,10 @@ func (o *Options) run(_ *cobra.Command, args []string) error {
 		coders.WithCodeBasePath(filepath.Dir(root)),
 		coders.WithStore(store),
 		coders.WithPrompt(o.prompt),
+		coders.WithAssumeYes(o.assumeYes),
+		coders.WithOutputFormat(o.output),
+		coders.WithIOStreams(o.IOStreams),
+		coders.WithSandbox(o.sandbox),
 	)
 
 	return autoCoder.Run()
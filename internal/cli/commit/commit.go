@@ -37,6 +37,7 @@ type Options struct {
 	commitLang     string
 	userPrompt     string
 	commitPrefix   string
+	workDir        string
 
 	cfg *options.Config
 	genericclioptions.IOStreams
@@ -96,6 +97,13 @@ func WithCommitLang(lang string) Option {
 	}
 }
 
+// WithWorkDir runs git in the given working tree instead of the current directory
+func WithWorkDir(dir string) Option {
+	return func(o *Options) {
+		o.workDir = dir
+	}
+}
+
 // New creates a new Options instance with optional configurations
 func New(opts ...Option) *Options {
 	o := &Options{}
@@ -156,6 +164,7 @@ func (o *Options) AutoCommit(_ *cobra.Command, args []string) error {
 		git.WithDiffUnified(o.diffUnified),
 		git.WithExcludeList(o.excludeList),
 		git.WithEnableAmend(o.commitAmend),
+		git.WithDir(o.workDir),
 	)
 
 	// Add files specified by the user
@@ -208,7 +217,11 @@ func (o *Options) AutoCommit(_ *cobra.Command, args []string) error {
 		if err != nil {
 			return errbook.Wrap("Could not get git dir.", err)
 		}
-		o.commitMsgFile = path.Join(strings.TrimSpace(out), "COMMIT_EDITMSG")
+		gitDir := strings.TrimSpace(out)
+		if !path.IsAbs(gitDir) {
+			gitDir = path.Join(o.workDir, gitDir)
+		}
+		o.commitMsgFile = path.Join(gitDir, "COMMIT_EDITMSG")
 	}
 	console.RenderStep("Writing commit message to %s", o.commitMsgFile)
 	err = os.WriteFile(o.commitMsgFile, []byte(commitMessage), 0o600)
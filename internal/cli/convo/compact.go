@@ -0,0 +1,79 @@
+package convo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/coding-hui/ai-terminal/internal/ai"
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/options"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+	"github.com/coding-hui/ai-terminal/internal/util/genericclioptions"
+)
+
+type compact struct {
+	genericclioptions.IOStreams
+	cfg *options.Config
+}
+
+func newCmdCompactConversation(ioStreams genericclioptions.IOStreams, cfg *options.Config) *cobra.Command {
+	o := &compact{IOStreams: ioStreams, cfg: cfg}
+	cmd := &cobra.Command{
+		Use:          "compact <id or title>",
+		Short:        "Summarize the older turns of a chat conversation, keeping the original messages.",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		Example: `# Summarize a long conversation before continuing it:
+          ai convo compact 6a8e1d2
+          ai ask --continue 6a8e1d2 what was left to do?`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.Run(args[0], cmd.Flags().Changed("model"))
+		},
+	}
+
+	return cmd
+}
+
+// Run executes the compact command. The conversation is summarized by its own model,
+// unless another model was chosen with --model.
+func (c *compact) Run(id string, modelChanged bool) error {
+	store, err := convo.GetConversationStore(c.cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	conversation, err := store.GetConversation(ctx, id)
+	if err != nil {
+		return errbook.Wrap("Couldn't find conversation to compact.", err)
+	}
+	if conversation.Model != nil && *conversation.Model != "" && !modelChanged {
+		c.cfg.Model = *conversation.Model
+	}
+
+	engine, err := ai.New(ai.WithConfig(c.cfg), ai.WithStore(store))
+	if err != nil {
+		return err
+	}
+
+	if !c.cfg.Quiet {
+		console.RenderStep("Summarizing %s with %s", conversation.ID[:convo.Sha1short], engine.GetModel().Name)
+	}
+	result, err := engine.CompactConversation(ctx, conversation.ID, true)
+	if err != nil {
+		return errbook.Wrap("Couldn't compact conversation.", err)
+	}
+
+	if result == nil {
+		_, _ = fmt.Fprintln(c.ErrOut, "Nothing to compact, the conversation has a single turn.")
+		return nil
+	}
+	if !c.cfg.Quiet {
+		_, _ = fmt.Fprintf(c.ErrOut, "Conversation compacted: %s, %d turns summarized, %d kept, revision %d.\n",
+			conversation.ID[:convo.Sha1short], result.Summarized, result.Kept, result.Revision)
+	}
+	return nil
+}
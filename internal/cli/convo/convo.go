@@ -20,6 +20,15 @@ func NewCmdConversation(ioStreams genericclioptions.IOStreams, cfg *options.Conf
 	cmd.AddCommand(newCmdLsConversation(ioStreams, cfg))
 	cmd.AddCommand(newCmdRemoveConversation(ioStreams, cfg))
 	cmd.AddCommand(newCmdShowConversation(ioStreams, cfg))
+	cmd.AddCommand(newCmdTreeConversation(ioStreams, cfg))
+	cmd.AddCommand(newCmdRenameConversation(ioStreams, cfg))
+	cmd.AddCommand(newCmdTagConversation(ioStreams, cfg))
+	cmd.AddCommand(newCmdPinConversation(ioStreams, cfg))
+	cmd.AddCommand(newCmdCompactConversation(ioStreams, cfg))
+	cmd.AddCommand(newCmdSearchConversation(ioStreams, cfg))
+	cmd.AddCommand(newCmdExportConversation(ioStreams, cfg))
+	cmd.AddCommand(newCmdImportConversation(ioStreams, cfg))
+	cmd.AddCommand(newCmdDB(ioStreams, cfg))
 
 	return cmd
 }
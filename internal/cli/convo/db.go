@@ -0,0 +1,107 @@
+package convo
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/spf13/cobra"
+
+	"github.com/coding-hui/ai-terminal/internal/convo/sqlite3"
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/options"
+	"github.com/coding-hui/ai-terminal/internal/util/genericclioptions"
+)
+
+type db struct {
+	genericclioptions.IOStreams
+	cfg *options.Config
+}
+
+func newCmdDB(ioStreams genericclioptions.IOStreams, cfg *options.Config) *cobra.Command {
+	o := &db{IOStreams: ioStreams, cfg: cfg}
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Manage the schema of the conversation database.",
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:          "migrate",
+		Short:        "Apply the pending schema migrations.",
+		SilenceUsage: true,
+		Example: `# Upgrade the conversation database to the latest schema:
+          ai convo db migrate`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return o.migrate()
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:          "status",
+		Short:        "Show the applied and pending schema migrations.",
+		SilenceUsage: true,
+		Example: `# Show the schema version of the conversation database:
+          ai convo db status`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return o.status()
+		},
+	})
+
+	return cmd
+}
+
+// open connects to the conversation database without migrating it.
+func (d *db) open() (*sqlx.DB, error) {
+	if d.cfg.DataStore.Type != "db" {
+		return nil, errbook.New("The datastore %s has no schema to migrate.", d.cfg.DataStore.Type)
+	}
+	return sqlite3.Open(sqlite3.DBAddress(d.cfg))
+}
+
+func (d *db) migrate() error {
+	conn, err := d.open()
+	if err != nil {
+		return err
+	}
+	defer conn.Close() //nolint:errcheck
+
+	applied, err := sqlite3.Migrate(context.Background(), conn)
+	for _, m := range applied {
+		_, _ = fmt.Fprintf(d.Out, "Applied %04d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		return errbook.Wrap("Couldn't migrate the conversation database.", err)
+	}
+
+	if len(applied) == 0 {
+		_, _ = fmt.Fprintln(d.ErrOut, "The conversation database is up to date.")
+	}
+	return nil
+}
+
+func (d *db) status() error {
+	conn, err := d.open()
+	if err != nil {
+		return err
+	}
+	defer conn.Close() //nolint:errcheck
+
+	statuses, err := sqlite3.Status(context.Background(), conn)
+	if err != nil {
+		return errbook.Wrap("Couldn't read the migrations of the conversation database.", err)
+	}
+
+	w := tabwriter.NewWriter(d.Out, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+	for _, s := range statuses {
+		applied := "pending"
+		if s.Applied {
+			applied = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		_, _ = fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+	}
+	return w.Flush()
+}
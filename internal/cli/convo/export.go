@@ -0,0 +1,100 @@
+package convo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/options"
+	"github.com/coding-hui/ai-terminal/internal/util/genericclioptions"
+)
+
+type export struct {
+	genericclioptions.IOStreams
+	cfg    *options.Config
+	format string
+	output string
+}
+
+func newCmdExportConversation(ioStreams genericclioptions.IOStreams, cfg *options.Config) *cobra.Command {
+	o := &export{IOStreams: ioStreams, cfg: cfg}
+	cmd := &cobra.Command{
+		Use:          "export <id or title>",
+		Short:        "Export the transcript of a chat conversation.",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		Example: `# Print a conversation as Markdown:
+          ai convo export 6a8e1d2
+
+          # Archive a conversation as JSON, to import it again later:
+          ai convo export 6a8e1d2 -o docs/sessions/parser-fix.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.Run(args[0])
+		},
+	}
+
+	cmd.Flags().StringVar(&o.format, "format", "", fmt.Sprintf("format of the transcript, one of %s, taken from the output file extension by default.", strings.Join(convo.TranscriptFormats, ", ")))
+	cmd.Flags().StringVarP(&o.output, "output", "o", "", "file to write the transcript to instead of stdout.")
+
+	return cmd
+}
+
+// Run executes the export command.
+func (e *export) Run(id string) error {
+	format := e.format
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(e.output), ".")
+		if format == "markdown" {
+			format = convo.FormatMarkdown
+		}
+		if !slices.Contains(convo.TranscriptFormats, format) {
+			format = convo.FormatMarkdown
+		}
+	}
+	if !slices.Contains(convo.TranscriptFormats, format) {
+		return errbook.New("Unknown format %s, use one of %s.", format, strings.Join(convo.TranscriptFormats, ", "))
+	}
+
+	store, err := convo.GetConversationStore(e.cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	conversation, err := store.GetConversation(ctx, id)
+	if err != nil {
+		return errbook.Wrap("Couldn't find conversation to export.", err)
+	}
+	messages, err := store.ConversationMessages(ctx, conversation.ID)
+	if err != nil {
+		return errbook.Wrap("Couldn't read the conversation messages.", err)
+	}
+	transcript := &convo.Transcript{Conversation: *conversation, Messages: messages}
+
+	var out io.Writer = e.Out
+	if e.output != "" {
+		f, err := os.Create(e.output)
+		if err != nil {
+			return errbook.Wrap("Couldn't create the export file.", err)
+		}
+		defer f.Close() //nolint:errcheck
+		out = f
+	}
+
+	if err := transcript.Write(out, format); err != nil {
+		return errbook.Wrap("Couldn't export the conversation.", err)
+	}
+
+	if e.output != "" && !e.cfg.Quiet {
+		_, _ = fmt.Fprintf(e.ErrOut, "Conversation %s exported to %s\n", conversation.ID[:convo.Sha1short], e.output)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package convo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/options"
+	"github.com/coding-hui/ai-terminal/internal/util/genericclioptions"
+)
+
+type importer struct {
+	genericclioptions.IOStreams
+	cfg     *options.Config
+	replace bool
+	newID   bool
+}
+
+func newCmdImportConversation(ioStreams genericclioptions.IOStreams, cfg *options.Config) *cobra.Command {
+	o := &importer{IOStreams: ioStreams, cfg: cfg}
+	cmd := &cobra.Command{
+		Use:          "import <file.json>",
+		Short:        "Import a chat conversation from a JSON transcript.",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		Example: `# Import a conversation exported with ai convo export, - reads stdin:
+          ai convo import docs/sessions/parser-fix.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.Run(args[0])
+		},
+	}
+
+	cmd.Flags().BoolVar(&o.replace, "replace", false, "replace a conversation with the same ID.")
+	cmd.Flags().BoolVar(&o.newID, "new-id", false, "import the conversation under a new ID, keeping a conversation with the same ID.")
+
+	return cmd
+}
+
+// Run executes the import command.
+func (i *importer) Run(path string) error {
+	var in io.Reader = i.In
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return errbook.Wrap("Couldn't open the transcript.", err)
+		}
+		defer f.Close() //nolint:errcheck
+		in = f
+	}
+
+	transcript, err := convo.ReadTranscript(in)
+	if err != nil {
+		return errbook.Wrap("Couldn't read the transcript.", err)
+	}
+
+	if i.newID || !convo.MatchSha1(transcript.ID) {
+		transcript.ID = convo.NewConversationID()
+	}
+	if strings.TrimSpace(transcript.Title) == "" {
+		transcript.Title = transcriptTitle(transcript)
+	}
+
+	store, err := convo.GetConversationStore(i.cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := store.ImportConversation(context.Background(), transcript, i.replace); err != nil {
+		return errbook.Wrap("Couldn't import the conversation, use --replace or --new-id if it already exists.", err)
+	}
+
+	if !i.cfg.Quiet {
+		_, _ = fmt.Fprintf(i.ErrOut, "Conversation imported: %s %s\n", transcript.ID[:convo.Sha1short], transcript.Title)
+	}
+	return nil
+}
+
+// transcriptTitle names a transcript without a title after its first prompt.
+func transcriptTitle(t *convo.Transcript) string {
+	for _, m := range t.Messages {
+		if m.Role != "human" {
+			continue
+		}
+		if first, _, _ := strings.Cut(strings.TrimSpace(m.Content), "\n"); first != "" {
+			return first
+		}
+	}
+	return t.ID[:convo.Sha1short]
+}
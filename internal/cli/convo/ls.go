@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/atotto/clipboard"
 	timeago "github.com/caarlos0/timea.go"
@@ -19,7 +21,10 @@ import (
 	"github.com/coding-hui/ai-terminal/internal/util/term"
 )
 
-type ls struct{}
+type ls struct {
+	tags   []string
+	pinned bool
+}
 
 func newCmdLsConversation(ioStreams genericclioptions.IOStreams, cfg *options.Config) *cobra.Command {
 	o := &ls{}
@@ -27,12 +32,18 @@ func newCmdLsConversation(ioStreams genericclioptions.IOStreams, cfg *options.Co
 		Use:   "ls",
 		Short: "Show chat conversations.",
 		Example: `# Managing conversations:
-          ai convo ls`,
+          ai convo ls
+
+          # Show the pinned conversations tagged parser:
+          ai convo ls --tag parser --pinned`,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			return o.Run(ioStreams, cfg)
 		},
 	}
 
+	cmd.Flags().StringArrayVar(&o.tags, "tag", nil, "show only conversations with the tag, can be repeated.")
+	cmd.Flags().BoolVar(&o.pinned, "pinned", false, "show only pinned conversations.")
+
 	return cmd
 }
 
@@ -47,6 +58,7 @@ func (o *ls) Run(ioStreams genericclioptions.IOStreams, cfg *options.Config) err
 	if err != nil {
 		return err
 	}
+	conversations = o.filter(conversations)
 
 	if len(conversations) == 0 {
 		_, _ = fmt.Fprintln(ioStreams.ErrOut, "No conversations found.")
@@ -54,8 +66,7 @@ func (o *ls) Run(ioStreams genericclioptions.IOStreams, cfg *options.Config) err
 	}
 
 	if term.IsInputTTY() && term.IsOutputTTY() {
-		selectFromList(conversations)
-		return nil
+		return selectFromList(ioStreams, cfg, conversations)
 	}
 
 	printList(conversations)
@@ -63,6 +74,33 @@ func (o *ls) Run(ioStreams genericclioptions.IOStreams, cfg *options.Config) err
 	return nil
 }
 
+// filter keeps the conversations with all the tags, and only the pinned ones with pinned set.
+func (o *ls) filter(conversations []convo.Conversation) []convo.Conversation {
+	tags := normalizeTags(o.tags)
+	var filtered []convo.Conversation
+	for _, c := range conversations {
+		if o.pinned && !c.Pinned {
+			continue
+		}
+		if !slices.ContainsFunc(tags, func(tag string) bool { return !c.HasTag(tag) }) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// labels renders the pin and the tags of a conversation.
+func labels(c convo.Conversation) string {
+	var labels []string
+	if c.Pinned {
+		labels = append(labels, "pinned")
+	}
+	for _, tag := range c.Tags {
+		labels = append(labels, "#"+tag)
+	}
+	return strings.Join(labels, " ")
+}
+
 func makeOptions(conversations []convo.Conversation) []huh.Option[string] {
 	opts := make([]huh.Option[string], 0, len(conversations))
 	for _, c := range conversations {
@@ -72,12 +110,15 @@ func makeOptions(conversations []convo.Conversation) []huh.Option[string] {
 		if c.Model != nil {
 			right += console.StdoutStyles().Comment.Render(*c.Model)
 		}
+		if l := labels(c); l != "" {
+			right += " " + console.StdoutStyles().Quote.Render(l)
+		}
 		opts = append(opts, huh.NewOption(left+" "+right, c.ID))
 	}
 	return opts
 }
 
-func selectFromList(conversations []convo.Conversation) {
+func selectFromList(ioStreams genericclioptions.IOStreams, cfg *options.Config, conversations []convo.Conversation) error {
 	var selected string
 	if err := huh.NewForm(
 		huh.NewGroup(
@@ -90,7 +131,12 @@ func selectFromList(conversations []convo.Conversation) {
 		if !errors.Is(err, huh.ErrUserAborted) {
 			fmt.Fprintln(os.Stderr, err.Error())
 		}
-		return
+		return nil
+	}
+
+	cfg.Show = selected
+	if err := showConversation(ioStreams, cfg); err != nil {
+		return err
 	}
 
 	_ = clipboard.WriteAll(selected)
@@ -127,16 +173,20 @@ func selectFromList(conversations []convo.Conversation) {
 			console.StdoutStyles().FlagDesc.Render(options.Help[flag.cmd]),
 		)
 	}
+	return nil
 }
 
 func printList(conversations []convo.Conversation) {
 	for _, conversation := range conversations {
-		_, _ = fmt.Fprintf(
-			os.Stdout,
-			"%s\t%s\t%s\n",
+		line := fmt.Sprintf(
+			"%s\t%s\t%s",
 			console.StdoutStyles().SHA1.Render(conversation.ID[:convo.Sha1short]),
 			conversation.Title,
 			console.StdoutStyles().Timeago.Render(timeago.Of(conversation.UpdatedAt)),
 		)
+		if l := labels(conversation); l != "" {
+			line += "\t" + l
+		}
+		_, _ = fmt.Fprintln(os.Stdout, line)
 	}
 }
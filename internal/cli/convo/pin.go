@@ -0,0 +1,67 @@
+package convo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/options"
+	"github.com/coding-hui/ai-terminal/internal/util/genericclioptions"
+)
+
+type pin struct {
+	genericclioptions.IOStreams
+	cfg   *options.Config
+	unpin bool
+}
+
+func newCmdPinConversation(ioStreams genericclioptions.IOStreams, cfg *options.Config) *cobra.Command {
+	o := &pin{IOStreams: ioStreams, cfg: cfg}
+	cmd := &cobra.Command{
+		Use:          "pin <id or title>",
+		Short:        "Pin a chat conversation, pinned conversations are kept by rm --all and --delete-older-than.",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		Example: `# Keep a conversation when cleaning up old ones:
+          ai convo pin 6a8e1d2
+
+          # Unpin it again:
+          ai convo pin --unpin 6a8e1d2`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.Run(args[0])
+		},
+	}
+
+	cmd.Flags().BoolVar(&o.unpin, "unpin", false, "unpin the conversation.")
+
+	return cmd
+}
+
+// Run executes the pin command.
+func (p *pin) Run(id string) error {
+	store, err := convo.GetConversationStore(p.cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	conversation, err := store.GetConversation(ctx, id)
+	if err != nil {
+		return errbook.Wrap("Couldn't find conversation to pin.", err)
+	}
+	if err := store.PinConversation(ctx, conversation.ID, !p.unpin); err != nil {
+		return errbook.Wrap("Couldn't pin conversation.", err)
+	}
+
+	if !p.cfg.Quiet {
+		action := "pinned"
+		if p.unpin {
+			action = "unpinned"
+		}
+		_, _ = fmt.Fprintf(p.ErrOut, "Conversation %s: %s %s\n", action, conversation.ID[:convo.Sha1short], conversation.Title)
+	}
+	return nil
+}
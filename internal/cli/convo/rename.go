@@ -0,0 +1,63 @@
+package convo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/options"
+	"github.com/coding-hui/ai-terminal/internal/util/genericclioptions"
+)
+
+type rename struct {
+	genericclioptions.IOStreams
+	cfg *options.Config
+}
+
+func newCmdRenameConversation(ioStreams genericclioptions.IOStreams, cfg *options.Config) *cobra.Command {
+	o := &rename{IOStreams: ioStreams, cfg: cfg}
+	cmd := &cobra.Command{
+		Use:          "rename <id or title> <new title>",
+		Short:        "Rename a chat conversation.",
+		SilenceUsage: true,
+		Args:         cobra.MinimumNArgs(2),
+		Example: `# Give a conversation a title to find it again:
+          ai convo rename 6a8e1d2 parser panic on empty input`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.Run(args[0], strings.Join(args[1:], " "))
+		},
+	}
+
+	return cmd
+}
+
+// Run executes the rename command.
+func (r *rename) Run(id, title string) error {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return errbook.New("Please provide a new title.")
+	}
+
+	store, err := convo.GetConversationStore(r.cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	conversation, err := store.GetConversation(ctx, id)
+	if err != nil {
+		return errbook.Wrap("Couldn't find conversation to rename.", err)
+	}
+	if err := store.RenameConversation(ctx, conversation.ID, title); err != nil {
+		return errbook.Wrap("Couldn't rename conversation.", err)
+	}
+
+	if !r.cfg.Quiet {
+		_, _ = fmt.Fprintf(r.ErrOut, "Conversation renamed: %s %s\n", conversation.ID[:convo.Sha1short], title)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -67,6 +68,11 @@ func (r *rm) deleteConversation(store convo.Store, conversationID string) error
 		return errbook.Wrap("Couldn't find conversation to delete.", err)
 	}
 
+	forks, err := countForks(store, conversation.ID)
+	if err != nil {
+		return errbook.Wrap("Couldn't list conversation forks.", err)
+	}
+
 	err = store.DeleteConversation(context.Background(), conversation.ID)
 	if err != nil {
 		return errbook.Wrap("Couldn't delete conversation.", err)
@@ -84,11 +90,29 @@ func (r *rm) deleteConversation(store convo.Store, conversationID string) error
 
 	if !r.cfg.Quiet {
 		fmt.Fprintln(os.Stderr, "Conversation deleted:", conversation.ID[:convo.Sha1minLen])
+		if forks > 0 {
+			fmt.Fprintln(os.Stderr, "Forks kept and linked to its parent:", forks)
+		}
 	}
 
 	return nil
 }
 
+// countForks counts the conversations forked from the conversation.
+func countForks(store convo.Store, conversationID string) (int, error) {
+	conversations, err := store.ListConversations(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	forks := 0
+	for _, c := range conversations {
+		if c.ParentID != nil && *c.ParentID == conversationID {
+			forks++
+		}
+	}
+	return forks, nil
+}
+
 func (r *rm) deleteConversationOlderThan(store convo.Store, deleteAll bool) error {
 	var err error
 	var conversations []convo.Conversation
@@ -105,6 +129,9 @@ func (r *rm) deleteConversationOlderThan(store convo.Store, deleteAll bool) erro
 		}
 	}
 
+	// pinned conversations are only removed one by one
+	conversations = slices.DeleteFunc(conversations, func(c convo.Conversation) bool { return c.Pinned })
+
 	if len(conversations) == 0 {
 		if !r.cfg.Quiet {
 			fmt.Fprintln(os.Stderr, "No conversations found.")
@@ -0,0 +1,185 @@
+package convo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	timeago "github.com/caarlos0/timea.go"
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
+
+	"github.com/coding-hui/ai-terminal/internal/cli/ask"
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/options"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+	"github.com/coding-hui/ai-terminal/internal/util/flag"
+	"github.com/coding-hui/ai-terminal/internal/util/genericclioptions"
+	"github.com/coding-hui/ai-terminal/internal/util/term"
+)
+
+const defaultSearchLimit = 20
+
+// searchRoles are the message roles a search can be limited to.
+var searchRoles = []string{
+	string(llms.ChatMessageTypeHuman),
+	string(llms.ChatMessageTypeAI),
+	string(llms.ChatMessageTypeSystem),
+}
+
+type search struct {
+	genericclioptions.IOStreams
+	cfg   *options.Config
+	model string
+	since time.Duration
+	role  string
+	limit int
+}
+
+func newCmdSearchConversation(ioStreams genericclioptions.IOStreams, cfg *options.Config) *cobra.Command {
+	o := &search{IOStreams: ioStreams, cfg: cfg}
+	cmd := &cobra.Command{
+		Use:          "search <query>",
+		Short:        "Search the titles and messages of chat conversations.",
+		SilenceUsage: true,
+		Args:         cobra.MinimumNArgs(1),
+		Example: `# Find the conversations mentioning the parser:
+          ai convo search parser
+
+          # Find the answers of the last week about a flaky test:
+          ai convo search "flaky test" --role ai --since 7d`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.Run(strings.Join(args, " "))
+		},
+	}
+
+	cmd.Flags().StringVar(&o.model, "model", "", "only search conversations held with the model.")
+	cmd.Flags().Var(flag.NewDurationFlag(o.since, &o.since), "since", "only search conversations updated within the duration, e.g. 7d.")
+	cmd.Flags().StringVar(&o.role, "role", "", fmt.Sprintf("only search messages of the role, one of %s.", strings.Join(searchRoles, ", ")))
+	cmd.Flags().IntVar(&o.limit, "limit", defaultSearchLimit, "maximum number of conversations to show.")
+
+	return cmd
+}
+
+// Run executes the search command.
+func (s *search) Run(query string) error {
+	if s.role != "" && !slices.Contains(searchRoles, s.role) {
+		return errbook.New("Unknown role %s, use one of %s.", s.role, strings.Join(searchRoles, ", "))
+	}
+
+	store, err := convo.GetConversationStore(s.cfg)
+	if err != nil {
+		return err
+	}
+
+	opts := convo.SearchOptions{Model: s.model, Role: s.role, Limit: s.limit}
+	if s.since > 0 {
+		opts.Since = time.Now().Add(-s.since)
+	}
+	results, err := store.SearchConversations(context.Background(), query, opts)
+	if err != nil {
+		return errbook.Wrap("Couldn't search conversations.", err)
+	}
+
+	if len(results) == 0 {
+		_, _ = fmt.Fprintln(s.ErrOut, "No conversations found.")
+		return nil
+	}
+
+	if term.IsInputTTY() && term.IsOutputTTY() {
+		return s.selectResult(results)
+	}
+
+	for _, r := range results {
+		_, _ = fmt.Fprintf(
+			s.Out,
+			"%s\t%s\t%s\t%s\n",
+			console.StdoutStyles().SHA1.Render(r.ID[:convo.Sha1short]),
+			r.Title,
+			console.StdoutStyles().Timeago.Render(resultSource(r)),
+			renderSnippet(r.Snippet),
+		)
+	}
+	return nil
+}
+
+// selectResult lets the user pick a hit and show or continue its conversation.
+func (s *search) selectResult(results []convo.SearchResult) error {
+	opts := make([]huh.Option[string], 0, len(results))
+	for _, r := range results {
+		left := console.StdoutStyles().SHA1.Render(r.ID[:convo.Sha1short])
+		timea := console.StdoutStyles().Timeago.Render(timeago.Of(r.UpdatedAt))
+		right := console.StdoutStyles().ConversationList.Render(r.Title, timea)
+		snippet := console.StdoutStyles().Comment.Render(resultSource(r)+": ") + renderSnippet(r.Snippet)
+		opts = append(opts, huh.NewOption(left+" "+right+"\n    "+snippet, r.ID))
+	}
+
+	var selected, action string
+	if err := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Conversations").
+				Value(&selected).
+				Options(opts...),
+		),
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Open the conversation").
+				Value(&action).
+				Options(
+					huh.NewOption("Show the conversation", "show"),
+					huh.NewOption("Continue the conversation", "continue"),
+				),
+		),
+	).Run(); err != nil {
+		if errors.Is(err, huh.ErrUserAborted) {
+			return nil
+		}
+		return err
+	}
+
+	if action == "continue" {
+		s.cfg.Continue = selected
+		s.cfg.Interactive = true
+		return ask.NewOptions(s.IOStreams, s.cfg).Run()
+	}
+	s.cfg.Show = selected
+	return showConversation(s.IOStreams, s.cfg)
+}
+
+// resultSource tells where the search matched, the title or a message of a role.
+func resultSource(r convo.SearchResult) string {
+	if r.Role == "" {
+		return "title"
+	}
+	return r.Role
+}
+
+// renderSnippet puts the snippet on a single line and highlights the matched terms.
+func renderSnippet(snippet string) string {
+	snippet = strings.Join(strings.Fields(snippet), " ")
+
+	var b strings.Builder
+	for {
+		start := strings.Index(snippet, convo.SnippetMatchStart)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(snippet[start:], convo.SnippetMatchEnd)
+		if end < 0 {
+			break
+		}
+		end += start
+		b.WriteString(snippet[:start])
+		b.WriteString(console.StdoutStyles().SearchMatch.Render(snippet[start+len(convo.SnippetMatchStart) : end]))
+		snippet = snippet[end+len(convo.SnippetMatchEnd):]
+	}
+	b.WriteString(snippet)
+	return b.String()
+}
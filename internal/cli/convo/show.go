@@ -1,10 +1,13 @@
 package convo
 
 import (
+	"context"
+
 	"github.com/spf13/cobra"
 
-	"github.com/coding-hui/ai-terminal/internal/cli/ask"
+	"github.com/coding-hui/ai-terminal/internal/convo"
 	"github.com/coding-hui/ai-terminal/internal/options"
+	"github.com/coding-hui/ai-terminal/internal/ui/chat"
 	"github.com/coding-hui/ai-terminal/internal/util/genericclioptions"
 )
 
@@ -16,9 +19,14 @@ type show struct {
 func newCmdShowConversation(ioStreams genericclioptions.IOStreams, cfg *options.Config) *cobra.Command {
 	o := &show{IOStreams: ioStreams}
 	cmd := &cobra.Command{
-		Use:          "show",
+		Use:          "show [id or title]",
 		Short:        "Show chat conversation.",
 		SilenceUsage: true,
+		Example: `# Show every turn of a conversation:
+          ai convo show 6a8e1d2
+
+          # Show only the last two turns of the last conversation:
+          ai convo show --last --tail 2`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return o.Run(args, cfg)
 		},
@@ -30,13 +38,23 @@ func newCmdShowConversation(ioStreams genericclioptions.IOStreams, cfg *options.
 }
 
 func (s *show) Run(args []string, cfg *options.Config) error {
-	cfg.ShowLast = s.last
+	cfg.ShowLast = s.last || len(args) == 0
 	if len(args) > 0 {
 		cfg.Show = args[0]
 	}
-	if err := ask.NewOptions(s.IOStreams, cfg).Run(); err != nil {
+	return showConversation(s.IOStreams, cfg)
+}
+
+// showConversation shows the transcript of the conversation set with cfg.Show or cfg.ShowLast.
+func showConversation(ioStreams genericclioptions.IOStreams, cfg *options.Config) error {
+	store, err := convo.GetConversationStore(cfg)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	transcript, err := convo.GetShowTranscript(context.Background(), cfg, store)
+	if err != nil {
+		return err
+	}
+	return chat.ShowTranscript(cfg, ioStreams.Out, transcript)
 }
@@ -0,0 +1,104 @@
+package convo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/options"
+	"github.com/coding-hui/ai-terminal/internal/util/genericclioptions"
+)
+
+type tag struct {
+	genericclioptions.IOStreams
+	cfg *options.Config
+}
+
+func newCmdTagConversation(ioStreams genericclioptions.IOStreams, cfg *options.Config) *cobra.Command {
+	o := &tag{IOStreams: ioStreams, cfg: cfg}
+	cmd := &cobra.Command{
+		Use:   "tag",
+		Short: "Label chat conversations with tags.",
+		Example: `# Tag a conversation and list the conversations with the tag:
+          ai convo tag add 6a8e1d2 parser bug
+          ai convo ls --tag parser`,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:          "add <id or title> <tag>...",
+		Short:        "Add tags to a chat conversation.",
+		SilenceUsage: true,
+		Args:         cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.Run(args[0], args[1:], false)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:          "rm <id or title> <tag>...",
+		Short:        "Remove tags from a chat conversation.",
+		SilenceUsage: true,
+		Args:         cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.Run(args[0], args[1:], true)
+		},
+	})
+
+	return cmd
+}
+
+// Run adds the tags to the conversation, or removes them with remove set.
+func (t *tag) Run(id string, tags []string, remove bool) error {
+	tags = normalizeTags(tags)
+	if len(tags) == 0 {
+		return errbook.New("Please provide at least one tag.")
+	}
+
+	store, err := convo.GetConversationStore(t.cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	conversation, err := store.GetConversation(ctx, id)
+	if err != nil {
+		return errbook.Wrap("Couldn't find conversation to tag.", err)
+	}
+
+	if remove {
+		err = store.RemoveTags(ctx, conversation.ID, tags)
+	} else {
+		err = store.AddTags(ctx, conversation.ID, tags)
+	}
+	if err != nil {
+		return errbook.Wrap("Couldn't update the conversation tags.", err)
+	}
+
+	if !t.cfg.Quiet {
+		updated, err := store.GetConversation(ctx, conversation.ID)
+		if err != nil {
+			return errbook.Wrap("Couldn't find conversation.", err)
+		}
+		_, _ = fmt.Fprintf(t.ErrOut, "Conversation %s tags: %s\n", conversation.ID[:convo.Sha1short], strings.Join(updated.Tags, ", "))
+	}
+	return nil
+}
+
+// normalizeTags lowercases the tags and drops blank and repeated ones.
+func normalizeTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,109 @@
+package convo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	timeago "github.com/caarlos0/timea.go"
+	"github.com/spf13/cobra"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/options"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+	"github.com/coding-hui/ai-terminal/internal/util/genericclioptions"
+)
+
+type tree struct {
+	genericclioptions.IOStreams
+	cfg *options.Config
+}
+
+func newCmdTreeConversation(ioStreams genericclioptions.IOStreams, cfg *options.Config) *cobra.Command {
+	o := &tree{IOStreams: ioStreams, cfg: cfg}
+	cmd := &cobra.Command{
+		Use:          "tree [id or title]",
+		Short:        "Show chat conversations with the conversations forked from them.",
+		SilenceUsage: true,
+		Args:         cobra.MaximumNArgs(1),
+		Example: `# Fork a conversation at its second turn and show the branches:
+          ai ask --fork 6a8e1d2@2 try a different approach
+          ai convo tree 6a8e1d2`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.Run(args)
+		},
+	}
+
+	return cmd
+}
+
+// Run executes the tree command.
+func (t *tree) Run(args []string) error {
+	store, err := convo.GetConversationStore(t.cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	conversations, err := store.ListConversations(ctx)
+	if err != nil {
+		return errbook.Wrap("Couldn't list conversations.", err)
+	}
+	if len(conversations) == 0 {
+		_, _ = fmt.Fprintln(t.ErrOut, "No conversations found.")
+		return nil
+	}
+
+	roots := convo.ConversationTree(conversations)
+	if len(args) > 0 {
+		found, err := store.GetConversation(ctx, args[0])
+		if err != nil {
+			return errbook.Wrap("Couldn't find conversation.", err)
+		}
+		roots = []*convo.ConversationNode{findNode(roots, found.ID)}
+	}
+
+	for _, root := range roots {
+		printNode(t.Out, root, "", "")
+	}
+	return nil
+}
+
+// findNode finds the node of the conversation in the trees.
+func findNode(nodes []*convo.ConversationNode, id string) *convo.ConversationNode {
+	for _, node := range nodes {
+		if node.ID == id {
+			return node
+		}
+		if found := findNode(node.Children, id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// printNode prints the conversation and its forks below it, each line starts with the prefix
+// and the forks are indented with the childPrefix.
+func printNode(w io.Writer, node *convo.ConversationNode, prefix, childPrefix string) {
+	if node == nil {
+		return
+	}
+
+	styles := console.StdoutStyles()
+	line := []string{styles.SHA1.Render(node.ID[:convo.Sha1short]), node.Title}
+	if node.ParentTurn != nil && *node.ParentTurn > 0 {
+		line = append(line, styles.Comment.Render(fmt.Sprintf("@%d", *node.ParentTurn)))
+	}
+	line = append(line, styles.Timeago.Render(timeago.Of(node.UpdatedAt)))
+	_, _ = fmt.Fprintln(w, prefix+strings.Join(line, " "))
+
+	for i, child := range node.Children {
+		if i == len(node.Children)-1 {
+			printNode(w, child, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			printNode(w, child, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
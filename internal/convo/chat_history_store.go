@@ -36,6 +36,12 @@ func (h *SimpleChatHistoryStore) AddAIMessage(ctx context.Context, convoID, mess
 	return h.AddMessage(ctx, convoID, llms.AIChatMessage{Content: message})
 }
 
+// AddAIMessageWithUsage adds an AIMessage to the chat message convo, the gob files
+// keep no model or token usage.
+func (h *SimpleChatHistoryStore) AddAIMessageWithUsage(ctx context.Context, convoID, message, _ string, _ llms.Usage) error {
+	return h.AddAIMessage(ctx, convoID, message)
+}
+
 // AddUserMessage adds a user to the chat message convo.
 func (h *SimpleChatHistoryStore) AddUserMessage(ctx context.Context, convoID, message string) error {
 	return h.AddMessage(ctx, convoID, llms.HumanChatMessage{Content: message})
@@ -2,6 +2,9 @@ package convo
 
 import (
 	"context"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/x/exp/ordered"
@@ -27,6 +30,17 @@ const (
 	ContentTypeText ContentType = "text"
 )
 
+// ContextMode defines whether the model may edit a loaded content.
+type ContextMode string
+
+const (
+	// ContextModeEditable marks content the model may edit
+	ContextModeEditable ContextMode = "editable"
+
+	// ContextModeReadOnly marks content that is only added for reference
+	ContextModeReadOnly ContextMode = "read-only"
+)
+
 // LoadContext contains metadata and content information for loaded resources.
 // It tracks the source, type, convo association, and additional metadata
 // about the content being used in conversations.
@@ -49,6 +63,9 @@ type LoadContext struct {
 	// Name is a human-readable identifier for the content
 	Name string `db:"name" json:"name"`
 
+	// Mode tells whether the model may edit the content, empty means editable
+	Mode ContextMode `db:"mode" json:"mode"`
+
 	// ConversationID associates the content with a specific convo
 	ConversationID string `db:"conversation_id" json:"conversationId"`
 
@@ -56,6 +73,11 @@ type LoadContext struct {
 	UpdatedAt time.Time `db:"updated_at" json:"updatedAt"`
 }
 
+// IsReadOnly reports whether the content is only added for reference.
+func (lc *LoadContext) IsReadOnly() bool {
+	return lc.Mode == ContextModeReadOnly
+}
+
 // Conversation represents a chat convo with metadata and convo.
 // It tracks the convo ID, title, last update time, and optional model info.
 type Conversation struct {
@@ -70,6 +92,120 @@ type Conversation struct {
 
 	// Model optionally specifies the AI model used in the convo
 	Model *string `db:"model" json:"model"`
+
+	// ParentID is the convo this convo was forked from
+	ParentID *string `db:"parent_id" json:"parentId,omitempty"`
+
+	// ParentTurn is the last turn of the parent convo copied into the fork, 0 for all of them
+	ParentTurn *int `db:"parent_turn" json:"parentTurn,omitempty"`
+
+	// Pinned convos are kept when old or all convos are removed
+	Pinned bool `db:"pinned" json:"pinned,omitempty"`
+
+	// Tags label the convo, sorted by name
+	Tags []string `db:"-" json:"tags,omitempty"`
+}
+
+// HasTag tells whether the convo is labeled with the tag.
+func (c Conversation) HasTag(tag string) bool {
+	return slices.Contains(c.Tags, tag)
+}
+
+// Message is a stored message of a convo with the model that wrote it and its token usage.
+type Message struct {
+	// ConversationID associates the message with a specific convo
+	ConversationID string `db:"conversation_id" json:"-"`
+
+	// Position orders the messages of the convo, starting at 0
+	Position int `db:"position" json:"-"`
+
+	// Revision of the convo messages the message belongs to, compacting a convo adds a revision
+	Revision int `db:"revision" json:"-"`
+
+	// Role is the type of the message, such as human, ai or system
+	Role string `db:"role" json:"role"`
+
+	// Content is the text of the message
+	Content string `db:"content" json:"content"`
+
+	// ReasoningContent is the reasoning of the model before its reply
+	ReasoningContent string `db:"reasoning_content" json:"reasoningContent,omitempty"`
+
+	// Model optionally specifies the AI model that wrote the message
+	Model string `db:"model" json:"model,omitempty"`
+
+	// PromptTokens is the number of input tokens of the request answered by the message
+	PromptTokens int `db:"prompt_tokens" json:"promptTokens,omitempty"`
+
+	// CompletionTokens is the number of tokens of the message
+	CompletionTokens int `db:"completion_tokens" json:"completionTokens,omitempty"`
+
+	// TotalTokens is the number of tokens of the request and the message
+	TotalTokens int `db:"total_tokens" json:"totalTokens,omitempty"`
+
+	// CreatedAt tracks when the message was stored
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+}
+
+// NewMessage returns the stored form of a chat message.
+func NewMessage(m llms.ChatMessage) Message {
+	msg := Message{
+		Role:    string(m.GetType()),
+		Content: m.GetContent(),
+	}
+	if ai, ok := m.(llms.AIChatMessage); ok {
+		msg.ReasoningContent = ai.ReasoningContent
+	}
+	return msg
+}
+
+// ChatMessage returns the chat message, nil for an unknown role.
+func (m Message) ChatMessage() llms.ChatMessage {
+	return llms.ChatMessageModel{
+		Type: m.Role,
+		Data: llms.ChatMessageModelData{
+			Type:             m.Role,
+			Content:          m.Content,
+			ReasoningContent: m.ReasoningContent,
+		},
+	}.ToChatMessage()
+}
+
+// Markers around the matched terms in the snippet of a SearchResult.
+const (
+	SnippetMatchStart = "\x02"
+	SnippetMatchEnd   = "\x03"
+)
+
+// SearchOptions filters the conversations found by a search.
+type SearchOptions struct {
+	// Model keeps the conversations held with the model
+	Model string
+
+	// Since keeps the conversations updated after the time
+	Since time.Time
+
+	// Role keeps the hits in messages of the role, such as human or ai,
+	// titles only match without a role
+	Role string
+
+	// Limit bounds the number of results, zero means no limit
+	Limit int
+}
+
+// SearchResult is a conversation found by a search with its best matching hit.
+type SearchResult struct {
+	Conversation
+
+	// Role of the matching message, empty when the title matched
+	Role string `db:"role" json:"role"`
+
+	// Snippet is the text around the match, the matched terms are enclosed
+	// in SnippetMatchStart and SnippetMatchEnd
+	Snippet string `db:"snippet" json:"snippet"`
+
+	// Rank orders the results, lower is better
+	Rank float64 `db:"rank" json:"rank"`
 }
 
 // CacheDetailsMsg contains details about a cached conversation
@@ -84,6 +220,9 @@ type ChatMessageHistory interface {
 	// AddAIMessage is a convenience method for adding an AI message string to
 	// the store.
 	AddAIMessage(ctx context.Context, convoID, message string) error
+	// AddAIMessageWithUsage adds an AI message string together with the model
+	// that wrote it and the token usage of the request.
+	AddAIMessageWithUsage(ctx context.Context, convoID, message, model string, usage llms.Usage) error
 	// AddUserMessage is a convenience method for adding a human message string
 	// to the store.
 	AddUserMessage(ctx context.Context, convoID, message string) error
@@ -114,12 +253,34 @@ type Store interface {
 	ListConversationsOlderThan(ctx context.Context, t time.Duration) ([]Conversation, error)
 	// SaveConversation saves a convo to the store
 	SaveConversation(ctx context.Context, id, title, model string) error
-	// DeleteConversation removes a convo from the store
+	// RenameConversation changes the title of a convo
+	RenameConversation(ctx context.Context, convoID, title string) error
+	// PinConversation pins or unpins a convo
+	PinConversation(ctx context.Context, convoID string, pinned bool) error
+	// AddTags labels a convo with the tags
+	AddTags(ctx context.Context, convoID string, tags []string) error
+	// RemoveTags removes the tags from a convo
+	RemoveTags(ctx context.Context, convoID string, tags []string) error
+	// ForkConversation copies the parent convo with its messages up to the turn, all of them
+	// when turn is 0, into a new convo with the fork ID linked to the parent.
+	ForkConversation(ctx context.Context, parentID, forkID string, turn int) (*Conversation, error)
+	// DeleteConversation removes a convo from the store, its forks are linked to its parent
 	DeleteConversation(ctx context.Context, convoID string) error
 	// ClearConversations removes all convo from the store.
 	ClearConversations(ctx context.Context) error
 	// ConversationExists checks if the given chat convo exists.
 	ConversationExists(ctx context.Context, sessionID string) (bool, error)
+	// SearchConversations finds the conversations whose titles or messages match the query,
+	// best matches first.
+	SearchConversations(ctx context.Context, query string, opts SearchOptions) ([]SearchResult, error)
+	// ConversationMessages retrieves the stored messages of a convo with their metadata.
+	ConversationMessages(ctx context.Context, convoID string) ([]Message, error)
+	// CompactMessages stores the messages as a new revision of the convo messages, which
+	// replaces the current one while the previous revisions are kept. It returns the new revision.
+	CompactMessages(ctx context.Context, convoID string, messages []Message) (int, error)
+	// ImportConversation saves the convo of the transcript with its messages, replacing
+	// a convo with the same ID when replace is set.
+	ImportConversation(ctx context.Context, transcript *Transcript, replace bool) error
 }
 
 // LoadContextStore manages loaded content contexts
@@ -139,6 +300,10 @@ type LoadContextStore interface {
 // GetCurrentConversationID handles the logic for determining the current conversation ID
 // based on config parameters and existing conversations
 func GetCurrentConversationID(ctx context.Context, cfg *options.Config, store Store) (CacheDetailsMsg, error) {
+	if cfg.Fork != "" {
+		return forkConversation(ctx, cfg, store)
+	}
+
 	continueLast := cfg.ContinueLast || (cfg.Continue != "" && cfg.Title == "")
 	readID := ordered.First(cfg.Continue, cfg.Show)
 	writeID := ordered.First(cfg.Title, cfg.Continue)
@@ -192,3 +357,75 @@ func GetCurrentConversationID(ctx context.Context, cfg *options.Config, store St
 		ReadID:  readID,
 	}, nil
 }
+
+// forkConversation copies the conversation set with cfg.Fork, as <id or title>[@turn],
+// into a new conversation which is read from and written to.
+func forkConversation(ctx context.Context, cfg *options.Config, store Store) (CacheDetailsMsg, error) {
+	ref, turn := ParseForkRef(cfg.Fork)
+	parent, err := store.GetConversation(ctx, ref)
+	if err != nil {
+		return CacheDetailsMsg{}, errbook.Wrap("Couldn't find conversation to fork.", err)
+	}
+
+	fork, err := store.ForkConversation(ctx, parent.ID, NewConversationID(), turn)
+	if err != nil {
+		return CacheDetailsMsg{}, errbook.Wrap("Couldn't fork conversation.", err)
+	}
+
+	model := cfg.Model
+	if fork.Model != nil && *fork.Model != "" {
+		model = *fork.Model
+	}
+	return CacheDetailsMsg{
+		Title:   cfg.Title,
+		Model:   model,
+		WriteID: fork.ID,
+		ReadID:  fork.ID,
+	}, nil
+}
+
+// ParseForkRef splits <id or title>@<turn> into the conversation and the turn,
+// the turn is 0 when it isn't given.
+func ParseForkRef(ref string) (string, int) {
+	i := strings.LastIndex(ref, "@")
+	if i < 0 {
+		return ref, 0
+	}
+	turn, err := strconv.Atoi(ref[i+1:])
+	if err != nil || turn < 0 {
+		return ref, 0
+	}
+	return ref[:i], turn
+}
+
+// GetShowTranscript reads the transcript of the conversation to show, set with
+// cfg.Show or cfg.ShowLast, keeping only the turns selected with cfg.ShowTurn or cfg.ShowTail.
+func GetShowTranscript(ctx context.Context, cfg *options.Config, store Store) (*Transcript, error) {
+	var (
+		found *Conversation
+		err   error
+	)
+	if cfg.Show == "" && cfg.ShowLast {
+		found, err = store.LatestConversation(ctx)
+		if err == nil && found.ID == "" {
+			return nil, errbook.New("No conversations found.")
+		}
+	} else {
+		found, err = store.GetConversation(ctx, cfg.Show)
+	}
+	if err != nil {
+		return nil, errbook.Wrap("Couldn't find conversation.", err)
+	}
+
+	messages, err := store.ConversationMessages(ctx, found.ID)
+	if err != nil {
+		return nil, errbook.Wrap("Couldn't read the conversation messages.", err)
+	}
+
+	transcript := &Transcript{Conversation: *found, Messages: messages}
+	transcript, err = transcript.SelectTurns(cfg.ShowTurn, cfg.ShowTail)
+	if err != nil {
+		return nil, errbook.Wrap("Couldn't select the turns to show.", err)
+	}
+	return transcript, nil
+}
@@ -15,6 +15,16 @@ const (
 
 var Sha1reg = regexp.MustCompile(`\b[0-9a-f]{40}\b`)
 
+// CoderHistorySuffix is appended to a conversation ID to store the coder history of
+// the conversation next to its messages.
+const CoderHistorySuffix = "-coder-history"
+
+// CoderHistoryID returns the ID the coder history of the conversation is stored under.
+// The history is deleted together with the conversation.
+func CoderHistoryID(convoID string) string {
+	return convoID + CoderHistorySuffix
+}
+
 func NewConversationID() string {
 	b := make([]byte, Sha1ReadBlockSize)
 	_, _ = rand.Read(b)
@@ -20,9 +20,13 @@ func init() {
 	convo.RegisterConversationStore(&sqliteStoreFactor{})
 }
 
+// timestampLayout is the format of the timestamps the db writes itself.
+const timestampLayout = "2006-01-02 15:04:05.000"
+
 var (
 	errNoMatches   = errors.New("no conversations found")
 	errManyMatches = errors.New("multiple conversations matched the input")
+	errInvalidID   = errors.New("invalid id")
 )
 
 type sqliteStoreFactor struct{}
@@ -35,8 +39,13 @@ func (s *sqliteStoreFactor) Create(options *options.Config) (convo.Store, error)
 	return NewSqliteStore(
 		WithDataPath(filepath.Join(options.DataStore.CachePath, "conversations")),
 		WithConversation(options.CacheWriteToID),
-		WithDBAddress(filepath.Join(options.DataStore.CachePath, "convo.db")),
-	), nil
+		WithDBAddress(DBAddress(options)),
+	)
+}
+
+// DBAddress returns the file path of the convo db.
+func DBAddress(options *options.Config) string {
+	return filepath.Join(options.DataStore.CachePath, "convo.db")
 }
 
 type SqliteStore struct {
@@ -48,24 +57,25 @@ type SqliteStore struct {
 	DBAddress string
 	// ConvoID defines a session name or ConvoID for a convo.
 	ConvoID string
-	// DataPath is the path to the data directory.
+	// DataPath is the directory of the .gob message files, which are imported into the db.
 	DataPath string
 
-	*convo.SimpleChatHistoryStore
+	*sqliteMessageStore
 	*sqliteLoadContextStore
 }
 
 // Statically assert that SqliteStore implement the chat message convo interface.
 var _ convo.Store = &SqliteStore{}
 
-func NewSqliteStore(options ...SqliteChatMessageHistoryOption) *SqliteStore {
+// NewSqliteStore opens the convo db and migrates it to the latest schema.
+func NewSqliteStore(options ...SqliteChatMessageHistoryOption) (*SqliteStore, error) {
 	return applyChatOptions(options...)
 }
 
 // LatestConversation returns the last message in the chat convo.
 func (h *SqliteStore) LatestConversation(ctx context.Context) (*convo.Conversation, error) {
-	var convo convo.Conversation
-	err := h.DB.Get(&convo, `
+	latest := []convo.Conversation{{}}
+	err := h.DB.Get(&latest[0], `
 		SELECT
 		  *
 		FROM
@@ -78,7 +88,10 @@ func (h *SqliteStore) LatestConversation(ctx context.Context) (*convo.Conversati
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return nil, fmt.Errorf("FindHead: %w", err)
 	}
-	return &convo, nil
+	if err := h.withTags(ctx, latest); err != nil {
+		return nil, fmt.Errorf("FindHead: %w", err)
+	}
+	return &latest[0], nil
 }
 
 // GetConversation retrieves a convo from the store
@@ -99,6 +112,9 @@ func (h *SqliteStore) GetConversation(ctx context.Context, id string) (*convo.Co
 		return nil, errManyMatches
 	}
 	if len(conversations) == 1 {
+		if err := h.withTags(ctx, conversations); err != nil {
+			return nil, fmt.Errorf("find: %w", err)
+		}
 		return &conversations[0], nil
 	}
 	return nil, errNoMatches
@@ -146,6 +162,9 @@ func (h *SqliteStore) ListConversations(ctx context.Context) ([]convo.Conversati
 	`); err != nil {
 		return convos, fmt.Errorf("ListContextsByteConvoID: %w", err)
 	}
+	if err := h.withTags(ctx, convos); err != nil {
+		return nil, fmt.Errorf("ListConversations: %w", err)
+	}
 	return convos, nil
 }
 
@@ -162,6 +181,9 @@ func (h *SqliteStore) ListConversationsOlderThan(ctx context.Context, t time.Dur
 		`), time.Now().Add(-t)); err != nil {
 		return nil, fmt.Errorf("ListOlderThan: %w", err)
 	}
+	if err := h.withTags(ctx, convos); err != nil {
+		return nil, fmt.Errorf("ListOlderThan: %w", err)
+	}
 	return convos, nil
 }
 
@@ -200,22 +222,208 @@ func (h *SqliteStore) SaveConversation(ctx context.Context, id, title, model str
 	return nil
 }
 
+// RenameConversation changes the title of the conversation.
+func (h *SqliteStore) RenameConversation(ctx context.Context, id, title string) error {
+	return h.updateConversation(ctx, "RenameConversation", id, `title = ?`, title)
+}
+
+// PinConversation pins or unpins the conversation.
+func (h *SqliteStore) PinConversation(ctx context.Context, id string, pinned bool) error {
+	return h.updateConversation(ctx, "PinConversation", id, `pinned = ?`, pinned)
+}
+
+// updateConversation sets a column of the conversation, failing when it doesn't exist.
+func (h *SqliteStore) updateConversation(ctx context.Context, op, id, set string, value any) error {
+	res, err := h.DB.ExecContext(ctx, h.DB.Rebind(`UPDATE conversations SET `+set+` WHERE id = ?`), value, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", op, errNoMatches)
+	}
+	return nil
+}
+
+// ForkConversation copies the parent conversation with its messages up to the turn and its
+// load contexts and tags into a new conversation linked to the parent, in one transaction.
+func (h *SqliteStore) ForkConversation(ctx context.Context, parentID, forkID string, turn int) (*convo.Conversation, error) {
+	if forkID == "" {
+		return nil, fmt.Errorf("ForkConversation: %w", errInvalidID)
+	}
+
+	parents := []convo.Conversation{{}}
+	if err := h.DB.GetContext(ctx, &parents[0], h.DB.Rebind(`SELECT * FROM conversations WHERE id = ?`), parentID); err != nil {
+		return nil, fmt.Errorf("ForkConversation: %w", err)
+	}
+	if err := h.withTags(ctx, parents); err != nil {
+		return nil, fmt.Errorf("ForkConversation: %w", err)
+	}
+	parent := parents[0]
+	messages, err := selectMessages(ctx, h.DB, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("ForkConversation: %w", err)
+	}
+	messages, err = (&convo.Transcript{Messages: messages}).MessagesUpToTurn(turn)
+	if err != nil {
+		return nil, fmt.Errorf("ForkConversation: %w", err)
+	}
+
+	if err := inTx(ctx, h.DB, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, tx.Rebind(`
+			INSERT INTO
+			  conversations (id, title, model, parent_id, parent_turn)
+			SELECT
+			  ?, title, model, id, ?
+			FROM
+			  conversations
+			WHERE
+			  id = ?
+		`), forkID, turn, parentID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, tx.Rebind(`
+			INSERT INTO
+			  load_contexts (type, url, file_path, content, name, mode, conversation_id, updated_at)
+			SELECT
+			  type, url, file_path, content, name, mode, ?, updated_at
+			FROM
+			  load_contexts
+			WHERE
+			  conversation_id = ?
+		`), forkID, parentID); err != nil {
+			return err
+		}
+		if err := insertTags(ctx, tx, forkID, parent.Tags); err != nil {
+			return err
+		}
+		return insertMessages(ctx, tx, forkID, messages)
+	}); err != nil {
+		return nil, fmt.Errorf("ForkConversation: %w", err)
+	}
+	h.forget(forkID)
+
+	fork := parent
+	fork.ID = forkID
+	fork.Pinned = false
+	fork.ParentID = &parent.ID
+	fork.ParentTurn = &turn
+	return &fork, nil
+}
+
+// DeleteConversation removes the conversation together with its messages, coder history
+// and load contexts. Its forks keep their copied messages and are linked to the parent of
+// the conversation.
 func (h *SqliteStore) DeleteConversation(ctx context.Context, id string) error {
-	if _, err := h.DB.ExecContext(ctx, h.DB.Rebind(`
-		DELETE FROM conversations
-		WHERE
-		  id = ?
-	`), id); err != nil {
-		return fmt.Errorf("DeleteContexts: %w", err)
+	if err := inTx(ctx, h.DB, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, tx.Rebind(`
+			UPDATE conversations
+			SET
+			  parent_id = deleted.parent_id,
+			  parent_turn = CASE
+			    WHEN deleted.parent_id IS NULL THEN NULL
+			    WHEN deleted.parent_turn > 0 AND (conversations.parent_turn = 0 OR deleted.parent_turn < conversations.parent_turn) THEN deleted.parent_turn
+			    ELSE conversations.parent_turn
+			  END
+			FROM
+			  (SELECT parent_id, parent_turn FROM conversations WHERE id = ?) AS deleted
+			WHERE
+			  conversations.parent_id = ?
+		`), id, id); err != nil {
+			return err
+		}
+		for _, table := range []string{"load_contexts", "conversation_tags"} {
+			if _, err := tx.ExecContext(ctx, tx.Rebind(`DELETE FROM `+table+` WHERE conversation_id = ?`), id); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.ExecContext(ctx, tx.Rebind(`
+			DELETE FROM messages
+			WHERE
+			  conversation_id IN (?, ?)
+		`), id, convo.CoderHistoryID(id)); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, tx.Rebind(`
+			DELETE FROM conversations
+			WHERE
+			  id = ?
+		`), id)
+		return err
+	}); err != nil {
+		return fmt.Errorf("DeleteConversation: %w", err)
 	}
+	h.forget(id)
+	h.forget(convo.CoderHistoryID(id))
 	return nil
 }
 
-// ClearConversations resets messages.
+// ClearConversations removes all conversations with their messages and load contexts.
 func (h *SqliteStore) ClearConversations(ctx context.Context) error {
-	if _, err := h.DB.ExecContext(ctx, `DELETE FROM conversations`); err != nil {
-		return fmt.Errorf("CleanContexts: %w", err)
+	if err := inTx(ctx, h.DB, func(tx *sqlx.Tx) error {
+		for _, table := range []string{"messages", "load_contexts", "conversation_tags", "conversations"} {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM `+table); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("ClearConversations: %w", err)
+	}
+	h.forgetAll()
+	return nil
+}
+
+// ImportConversation saves the convo of the transcript with its messages in one transaction,
+// keeping their timestamps. An existing convo with the same ID is only replaced with replace set.
+func (h *SqliteStore) ImportConversation(ctx context.Context, transcript *convo.Transcript, replace bool) error {
+	c := transcript.Conversation
+	if c.UpdatedAt.IsZero() {
+		c.UpdatedAt = time.Now()
+	}
+	model := ""
+	if c.Model != nil {
+		model = *c.Model
+	}
+
+	if err := inTx(ctx, h.DB, func(tx *sqlx.Tx) error {
+		var count int
+		if err := tx.GetContext(ctx, &count, tx.Rebind(`SELECT COUNT(*) FROM conversations WHERE id = ?`), c.ID); err != nil {
+			return err
+		}
+		if count > 0 {
+			if !replace {
+				return fmt.Errorf("conversation %s already exists", c.ID)
+			}
+			for _, table := range []string{"messages", "load_contexts", "conversation_tags"} {
+				if _, err := tx.ExecContext(ctx, tx.Rebind(`DELETE FROM `+table+` WHERE conversation_id = ?`), c.ID); err != nil {
+					return err
+				}
+			}
+			if _, err := tx.ExecContext(ctx, tx.Rebind(`DELETE FROM conversations WHERE id = ?`), c.ID); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, tx.Rebind(`
+			INSERT INTO
+			  conversations (id, title, model, updated_at, parent_id, parent_turn, pinned)
+			VALUES
+			  (?, ?, ?, ?, ?, ?, ?)
+		`), c.ID, c.Title, model, c.UpdatedAt.UTC().Format(timestampLayout), c.ParentID, c.ParentTurn, c.Pinned); err != nil {
+			return err
+		}
+		if err := insertTags(ctx, tx, c.ID, c.Tags); err != nil {
+			return err
+		}
+		return insertMessages(ctx, tx, c.ID, transcript.Messages)
+	}); err != nil {
+		return fmt.Errorf("ImportConversation: %w", err)
 	}
+	h.forget(c.ID)
 	return nil
 }
 
@@ -2,49 +2,18 @@ package sqlite3
 
 import (
 	"context"
-	"os"
 
 	"github.com/jmoiron/sqlx"
 
-	"github.com/coding-hui/ai-terminal/internal/convo"
 	"github.com/coding-hui/ai-terminal/internal/errbook"
 )
 
-// DefaultSchema sets a default schema to be run after connecting.
-const DefaultSchema = `CREATE TABLE
-		  IF NOT EXISTS conversations (
-		    id string NOT NULL PRIMARY KEY,
-		    title string NOT NULL,
-		    model string NOT NULL,
-		    updated_at datetime NOT NULL DEFAULT (strftime ('%Y-%m-%d %H:%M:%f', 'now')),
-		    CHECK (id <> ''),
-		    CHECK (title <> '')
-		  );
-CREATE INDEX IF NOT EXISTS idx_conv_id ON conversations (id);
-CREATE INDEX IF NOT EXISTS idx_conv_title ON conversations (title);
-
-CREATE TABLE IF NOT EXISTS load_contexts (
-	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
-	type string NOT NULL,
-	url string,
-	file_path string,
-	content text NOT NULL,
-	name string NOT NULL,
-	conversation_id string NOT NULL,
-	updated_at datetime NOT NULL DEFAULT (strftime ('%Y-%m-%d %H:%M:%f', 'now')),
-	CHECK (name <> ''),
-	CHECK (conversation_id <> ''),
-	FOREIGN KEY (conversation_id) REFERENCES conversations(id) ON DELETE CASCADE
-);
-CREATE INDEX IF NOT EXISTS idx_loadctx_convo ON load_contexts (conversation_id);
-`
-
 // SqliteChatMessageHistoryOption is a function for creating new
 // chat message convo with other than the default values.
 type SqliteChatMessageHistoryOption func(m *SqliteStore)
 
 // WithDataPath is an option for NewSqliteChatMessageHistory for
-// setting a path to the data directory.
+// setting the directory of the .gob message files to import.
 func WithDataPath(path string) SqliteChatMessageHistoryOption {
 	return func(m *SqliteStore) {
 		m.DataPath = path
@@ -83,7 +52,7 @@ func WithConversation(convoID string) SqliteChatMessageHistoryOption {
 	}
 }
 
-func applyChatOptions(options ...SqliteChatMessageHistoryOption) *SqliteStore {
+func applyChatOptions(options ...SqliteChatMessageHistoryOption) (*SqliteStore, error) {
 	h := &SqliteStore{}
 
 	for _, option := range options {
@@ -107,26 +76,43 @@ func applyChatOptions(options ...SqliteChatMessageHistoryOption) *SqliteStore {
 	}
 
 	if h.DB == nil {
-		db, err := sqlx.Open("sqlite", h.DBAddress)
+		db, err := Open(h.DBAddress)
 		if err != nil {
-			errbook.HandleError(errbook.Wrap("Could not open database.", err))
-			os.Exit(1)
+			return nil, err
 		}
 		h.DB = db
 	}
 
-	if err := h.DB.Ping(); err != nil {
-		errbook.HandleError(errbook.Wrap("Could not connect to database.", err))
-		os.Exit(1)
+	if _, err := Migrate(h.Ctx, h.DB); err != nil {
+		return nil, errbook.Wrap("Could not migrate the convo db.", err)
 	}
 
-	if _, err := h.DB.ExecContext(h.Ctx, DefaultSchema); err != nil {
-		errbook.HandleError(errbook.Wrap("Could not create convo db table.", err))
-		os.Exit(1)
+	if err := importGobMessages(h.Ctx, h.DB, h.DataPath); err != nil {
+		return nil, errbook.Wrap("Could not import the conversation messages.", err)
 	}
 
-	h.SimpleChatHistoryStore = convo.NewSimpleChatHistoryStore(h.DataPath)
+	h.sqliteMessageStore = newMessageStore(h.DB)
 	h.sqliteLoadContextStore = newLoadContextStore(h.DB)
 
-	return h
+	return h, nil
+}
+
+// Open connects to the convo db at addr without migrating it.
+func Open(addr string) (*sqlx.DB, error) {
+	db, err := sqlx.Open("sqlite", addr)
+	if err != nil {
+		return nil, errbook.Wrap("Could not open database.", err)
+	}
+
+	if addr == ":memory:" {
+		// every connection opens its own in-memory database
+		db.SetMaxOpenConns(1)
+	}
+
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, errbook.Wrap("Could not connect to database.", err)
+	}
+
+	return db, nil
 }
@@ -19,11 +19,12 @@ func TestSqliteStore(t *testing.T) {
 
 	ctx := context.Background()
 	convoID := convo.NewConversationID()
-	h := NewSqliteStore(
+	h, err := NewSqliteStore(
 		WithConversation(convoID),
 		WithContext(ctx),
 		WithDataPath(t.TempDir()),
 	)
+	require.NoError(t, err)
 
 	t.Run("Save and Get conversation", func(t *testing.T) {
 		err := h.SaveConversation(ctx, convoID, "foo", "test")
@@ -93,10 +94,11 @@ func TestSqliteChatMessageHistory(t *testing.T) {
 
 	ctx := context.Background()
 	convoID := convo.NewConversationID()
-	h := NewSqliteStore(
+	h, err := NewSqliteStore(
 		WithContext(ctx),
 		WithDataPath(t.TempDir()),
 	)
+	require.NoError(t, err)
 
 	t.Run("Add and get messages", func(t *testing.T) {
 		err := h.AddAIMessage(ctx, convoID, "foo")
@@ -142,3 +144,62 @@ func TestSqliteChatMessageHistory(t *testing.T) {
 		assert.Len(t, messages, 0)
 	})
 }
+
+func TestForkConversation(t *testing.T) {
+	ctx := context.Background()
+	h := newTestStore(t, t.TempDir())
+
+	root := convo.NewConversationID()
+	require.NoError(t, h.SaveConversation(ctx, root, "parser", "gpt-4o"))
+	require.NoError(t, h.SetMessages(ctx, root, []llms.ChatMessage{
+		llms.HumanChatMessage{Content: "one"},
+		llms.AIChatMessage{Content: "1"},
+		llms.HumanChatMessage{Content: "two"},
+		llms.AIChatMessage{Content: "2"},
+	}))
+	require.NoError(t, h.SaveContext(ctx, &convo.LoadContext{
+		Type: convo.ContentTypeText, Content: "notes", Name: "notes", ConversationID: root,
+	}))
+
+	child, err := h.ForkConversation(ctx, root, convo.NewConversationID(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, root, *child.ParentID)
+	assert.Equal(t, "parser", child.Title)
+
+	messages, err := h.Messages(ctx, child.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []llms.ChatMessage{
+		llms.HumanChatMessage{Content: "one"},
+		llms.AIChatMessage{Content: "1"},
+	}, messages)
+	contexts, err := h.ListContextsByteConvoID(ctx, child.ID)
+	require.NoError(t, err)
+	assert.Len(t, contexts, 1)
+
+	grandchild, err := h.ForkConversation(ctx, child.ID, convo.NewConversationID(), 0)
+	require.NoError(t, err)
+	messages, err = h.Messages(ctx, grandchild.ID)
+	require.NoError(t, err)
+	assert.Len(t, messages, 2)
+
+	_, err = h.ForkConversation(ctx, root, convo.NewConversationID(), 5)
+	assert.Error(t, err)
+	_, err = h.ForkConversation(ctx, convo.NewConversationID(), convo.NewConversationID(), 0)
+	assert.Error(t, err)
+
+	// deleting a conversation links its forks to its parent and keeps their messages
+	require.NoError(t, h.DeleteConversation(ctx, child.ID))
+	found, err := h.GetConversation(ctx, grandchild.ID)
+	require.NoError(t, err)
+	assert.Equal(t, root, *found.ParentID)
+	assert.Equal(t, 1, *found.ParentTurn)
+	messages, err = h.Messages(ctx, grandchild.ID)
+	require.NoError(t, err)
+	assert.Len(t, messages, 2)
+
+	require.NoError(t, h.DeleteConversation(ctx, root))
+	found, err = h.GetConversation(ctx, grandchild.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found.ParentID)
+	assert.Nil(t, found.ParentTurn)
+}
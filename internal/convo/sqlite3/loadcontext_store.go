@@ -32,11 +32,12 @@ func (s *sqliteLoadContextStore) SaveContext(ctx context.Context, lc *convo.Load
 			file_path = ?,
 			content = ?,
 			name = ?,
+			mode = ?,
 			conversation_id = ?,
 			updated_at = CURRENT_TIMESTAMP
 		WHERE
 			id = ?
-	`), lc.Type, lc.URL, lc.FilePath, lc.Content, lc.Name, lc.ConversationID, lc.ID)
+	`), lc.Type, lc.URL, lc.FilePath, lc.Content, lc.Name, contextMode(lc), lc.ConversationID, lc.ID)
 	if err != nil {
 		return fmt.Errorf("SaveContext: %w", err)
 	}
@@ -52,11 +53,11 @@ func (s *sqliteLoadContextStore) SaveContext(ctx context.Context, lc *convo.Load
 
 	resp, err := s.db.ExecContext(ctx, s.db.Rebind(`
 		INSERT INTO load_contexts (
-			type, url, file_path, content, name, conversation_id
+			type, url, file_path, content, name, mode, conversation_id
 		) VALUES (
-			?, ?, ?, ?, ?, ?
+			?, ?, ?, ?, ?, ?, ?
 		)
-	`), lc.Type, lc.URL, lc.FilePath, lc.Content, lc.Name, lc.ConversationID)
+	`), lc.Type, lc.URL, lc.FilePath, lc.Content, lc.Name, contextMode(lc), lc.ConversationID)
 	if err != nil {
 		return fmt.Errorf("SaveContext: %w", err)
 	}
@@ -73,7 +74,7 @@ func (s *sqliteLoadContextStore) SaveContext(ctx context.Context, lc *convo.Load
 func (s *sqliteLoadContextStore) GetContext(ctx context.Context, id uint64) (*convo.LoadContext, error) {
 	var lc convo.LoadContext
 	err := s.db.GetContext(ctx, &lc, s.db.Rebind(`
-		SELECT id, type, url, file_path, content, name, conversation_id, updated_at
+		SELECT id, type, url, file_path, content, name, mode, conversation_id, updated_at
 		FROM load_contexts WHERE id = ?
 	`), id)
 	if err != nil {
@@ -88,7 +89,7 @@ func (s *sqliteLoadContextStore) GetContext(ctx context.Context, id uint64) (*co
 func (s *sqliteLoadContextStore) ListContextsByteConvoID(ctx context.Context, conversationID string) ([]convo.LoadContext, error) {
 	var contexts []convo.LoadContext
 	if err := s.db.SelectContext(ctx, &contexts, s.db.Rebind(`
-		SELECT id, type, url, file_path, content, name, conversation_id, updated_at
+		SELECT id, type, url, file_path, content, name, mode, conversation_id, updated_at
 		FROM load_contexts WHERE conversation_id = ?
 	`), conversationID); err != nil {
 		return nil, fmt.Errorf("ListContextsByteConvoID: %w", err)
@@ -131,3 +132,11 @@ func (s *sqliteLoadContextStore) CleanContexts(ctx context.Context, conversation
 
 	return rows, nil
 }
+
+// contextMode returns the mode to persist, contexts without a mode are editable.
+func contextMode(lc *convo.LoadContext) convo.ContextMode {
+	if lc.Mode == "" {
+		return convo.ContextModeEditable
+	}
+	return lc.Mode
+}
@@ -2,6 +2,7 @@ package sqlite3
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 
 	"github.com/jmoiron/sqlx"
@@ -141,13 +142,70 @@ func TestLoadContextStore(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, "updated content", retrieved.Content)
 	})
+
+	t.Run("SaveContext persists the mode", func(t *testing.T) {
+		lc := &convo.LoadContext{
+			ID:             uint64(8),
+			Type:           "file",
+			FilePath:       "/path/to/readonly",
+			Name:           "readonly.txt",
+			ConversationID: "conv6",
+		}
+
+		require.NoError(t, store.SaveContext(ctx, lc))
+		retrieved, err := store.GetContext(ctx, lc.ID)
+		require.NoError(t, err)
+		assert.Equal(t, convo.ContextModeEditable, retrieved.Mode)
+		assert.False(t, retrieved.IsReadOnly())
+
+		lc.Mode = convo.ContextModeReadOnly
+		require.NoError(t, store.SaveContext(ctx, lc))
+		retrieved, err = store.GetContext(ctx, lc.ID)
+		require.NoError(t, err)
+		assert.True(t, retrieved.IsReadOnly())
+	})
+}
+
+func TestUpgradeSchema(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlx.Open("sqlite", filepath.Join(t.TempDir(), "convo.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	// the load_contexts table as it was created before contexts had a mode
+	_, err = db.Exec(`
+		CREATE TABLE load_contexts (
+			id integer not null primary key,
+			type string not null,
+			url string,
+			file_path string,
+			content text,
+			name string,
+			conversation_id string not null,
+			updated_at datetime not null default(strftime('%Y-%m-%d %H:%M:%f', 'now'))
+		);
+		INSERT INTO load_contexts (id, type, url, file_path, content, name, conversation_id) VALUES (1, 'file', '', '/path/to/file', '', 'file', 'conv1');
+	`)
+	require.NoError(t, err)
+
+	_, err = Migrate(ctx, db)
+	require.NoError(t, err)
+	// migrating twice is a no-op
+	_, err = Migrate(ctx, db)
+	require.NoError(t, err)
+
+	lc, err := newLoadContextStore(db).GetContext(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, convo.ContextModeEditable, lc.Mode)
 }
 
 func setupTestDB(t *testing.T) *sqlx.DB {
-	db, err := sqlx.Open("sqlite", ":memory:")
+	db, err := Open(":memory:")
 	require.NoError(t, err)
 
-	_, err = db.Exec(DefaultSchema)
+	_, err = Migrate(context.Background(), db)
 	require.NoError(t, err)
 
 	t.Cleanup(func() {
@@ -0,0 +1,418 @@
+package sqlite3
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+)
+
+// gobExt is the extension of the message files written before messages moved into the db.
+const gobExt = ".gob"
+
+// sqliteMessageStore keeps the messages of the conversations in the messages table.
+// Added messages stay pending in memory until they are persisted, replacing or
+// invalidating the messages writes through immediately.
+type sqliteMessageStore struct {
+	db      *sqlx.DB
+	pending map[string][]convo.Message
+
+	sync.Mutex // protects pending
+}
+
+func newMessageStore(db *sqlx.DB) *sqliteMessageStore {
+	return &sqliteMessageStore{
+		db:      db,
+		pending: make(map[string][]convo.Message),
+	}
+}
+
+// AddAIMessage adds an AIMessage to the chat message convo.
+func (s *sqliteMessageStore) AddAIMessage(ctx context.Context, convoID, content string) error {
+	return s.AddMessage(ctx, convoID, llms.AIChatMessage{Content: content})
+}
+
+// AddAIMessageWithUsage adds an AIMessage with the model that wrote it and its token usage.
+func (s *sqliteMessageStore) AddAIMessageWithUsage(_ context.Context, convoID, content, model string, usage llms.Usage) error {
+	msg := convo.NewMessage(llms.AIChatMessage{Content: content})
+	msg.Model = model
+	msg.PromptTokens = usage.PromptTokens
+	msg.CompletionTokens = usage.CompletionTokens
+	msg.TotalTokens = usage.TotalTokens
+	return s.add(convoID, msg)
+}
+
+// AddUserMessage adds a user to the chat message convo.
+func (s *sqliteMessageStore) AddUserMessage(ctx context.Context, convoID, content string) error {
+	return s.AddMessage(ctx, convoID, llms.HumanChatMessage{Content: content})
+}
+
+func (s *sqliteMessageStore) AddMessage(_ context.Context, convoID string, m llms.ChatMessage) error {
+	if m == nil {
+		return nil
+	}
+	return s.add(convoID, convo.NewMessage(m))
+}
+
+func (s *sqliteMessageStore) add(convoID string, msg convo.Message) error {
+	if convoID == "" {
+		return fmt.Errorf("AddMessage: %w", errInvalidID)
+	}
+
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now().UTC()
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	s.pending[convoID] = append(s.pending[convoID], msg)
+	return nil
+}
+
+func (s *sqliteMessageStore) SetMessages(ctx context.Context, convoID string, messages []llms.ChatMessage) error {
+	if convoID == "" {
+		return fmt.Errorf("SetMessages: %w", errInvalidID)
+	}
+
+	var rows []convo.Message
+	for _, m := range messages {
+		if m != nil {
+			rows = append(rows, convo.NewMessage(m))
+		}
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		revision, err := currentRevision(ctx, tx, convoID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, tx.Rebind(`
+			DELETE FROM messages
+			WHERE
+			  conversation_id = ?
+			  AND revision = ?
+		`), convoID, revision); err != nil {
+			return err
+		}
+		return insertRevision(ctx, tx, convoID, revision, rows)
+	}); err != nil {
+		return fmt.Errorf("SetMessages: %w", err)
+	}
+	delete(s.pending, convoID)
+	return nil
+}
+
+func (s *sqliteMessageStore) Messages(ctx context.Context, convoID string) ([]llms.ChatMessage, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	rows, err := selectMessages(ctx, s.db, convoID)
+	if err != nil {
+		return nil, fmt.Errorf("Messages: %w", err)
+	}
+
+	var messages []llms.ChatMessage
+	for _, row := range append(rows, s.pending[convoID]...) {
+		if m := row.ChatMessage(); m != nil {
+			messages = append(messages, m)
+		}
+	}
+	return messages, nil
+}
+
+func (s *sqliteMessageStore) PersistentMessages(ctx context.Context, convoID string) error {
+	if convoID == "" {
+		return fmt.Errorf("PersistentMessages: %w", errInvalidID)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		return insertMessages(ctx, tx, convoID, s.pending[convoID])
+	}); err != nil {
+		return fmt.Errorf("PersistentMessages: %w", err)
+	}
+	delete(s.pending, convoID)
+	return nil
+}
+
+func (s *sqliteMessageStore) InvalidateMessages(ctx context.Context, convoID string) error {
+	if convoID == "" {
+		return fmt.Errorf("InvalidateMessages: %w", errInvalidID)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		return deleteMessages(ctx, tx, convoID)
+	}); err != nil {
+		return fmt.Errorf("InvalidateMessages: %w", err)
+	}
+	delete(s.pending, convoID)
+	return nil
+}
+
+// CompactMessages stores the messages as a new revision of the convo messages,
+// the messages of the previous revisions are kept.
+func (s *sqliteMessageStore) CompactMessages(ctx context.Context, convoID string, messages []convo.Message) (int, error) {
+	if convoID == "" {
+		return 0, fmt.Errorf("CompactMessages: %w", errInvalidID)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	var revision int
+	if err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		current, err := currentRevision(ctx, tx, convoID)
+		if err != nil {
+			return err
+		}
+		revision = current + 1
+		return insertRevision(ctx, tx, convoID, revision, messages)
+	}); err != nil {
+		return 0, fmt.Errorf("CompactMessages: %w", err)
+	}
+	return revision, nil
+}
+
+// ConversationMessages retrieves the stored messages of the current revision of a convo
+// with their metadata, pending messages are left out.
+func (s *sqliteMessageStore) ConversationMessages(ctx context.Context, convoID string) ([]convo.Message, error) {
+	messages, err := selectMessages(ctx, s.db, convoID)
+	if err != nil {
+		return nil, fmt.Errorf("ConversationMessages: %w", err)
+	}
+	return messages, nil
+}
+
+// forget drops the pending messages of the conversation.
+func (s *sqliteMessageStore) forget(convoID string) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.pending, convoID)
+}
+
+// forgetAll drops the pending messages of all conversations.
+func (s *sqliteMessageStore) forgetAll() {
+	s.Lock()
+	defer s.Unlock()
+	clear(s.pending)
+}
+
+func (s *sqliteMessageStore) inTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	return inTx(ctx, s.db, fn)
+}
+
+// inTx runs fn in a transaction, which is rolled back when fn fails.
+func inTx(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func selectMessages(ctx context.Context, db *sqlx.DB, convoID string) ([]convo.Message, error) {
+	var messages []convo.Message
+	err := db.SelectContext(ctx, &messages, db.Rebind(`
+		SELECT
+		  conversation_id, position, revision, role, content, reasoning_content,
+		  model, prompt_tokens, completion_tokens, total_tokens, created_at
+		FROM
+		  messages
+		WHERE
+		  conversation_id = ?
+		  AND revision = (SELECT COALESCE(MAX(revision), 0) FROM messages WHERE conversation_id = ?)
+		ORDER BY
+		  position
+	`), convoID, convoID)
+	return messages, err
+}
+
+func deleteMessages(ctx context.Context, tx *sqlx.Tx, convoID string) error {
+	_, err := tx.ExecContext(ctx, tx.Rebind(`
+		DELETE FROM messages
+		WHERE
+		  conversation_id = ?
+	`), convoID)
+	return err
+}
+
+// currentRevision returns the revision of the conversation messages that is read and appended to.
+func currentRevision(ctx context.Context, tx *sqlx.Tx, convoID string) (int, error) {
+	var revision int
+	err := tx.GetContext(ctx, &revision, tx.Rebind(`
+		SELECT
+		  COALESCE(MAX(revision), 0)
+		FROM
+		  messages
+		WHERE
+		  conversation_id = ?
+	`), convoID)
+	return revision, err
+}
+
+// insertMessages appends the messages to the current revision of the conversation messages.
+func insertMessages(ctx context.Context, tx *sqlx.Tx, convoID string, messages []convo.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	revision, err := currentRevision(ctx, tx, convoID)
+	if err != nil {
+		return err
+	}
+	return insertRevision(ctx, tx, convoID, revision, messages)
+}
+
+// insertRevision appends the messages to the revision, positions keep growing across
+// revisions so every message of the conversation has its own position.
+func insertRevision(ctx context.Context, tx *sqlx.Tx, convoID string, revision int, messages []convo.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	var next int
+	if err := tx.GetContext(ctx, &next, tx.Rebind(`
+		SELECT
+		  COALESCE(MAX(position) + 1, 0)
+		FROM
+		  messages
+		WHERE
+		  conversation_id = ?
+	`), convoID); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for i, msg := range messages {
+		msg.ConversationID = convoID
+		msg.Position = next + i
+		msg.Revision = revision
+		if msg.CreatedAt.IsZero() {
+			msg.CreatedAt = now
+		}
+		if _, err := tx.NamedExecContext(ctx, `
+			INSERT INTO
+			  messages (
+			    conversation_id, position, revision, role, content, reasoning_content,
+			    model, prompt_tokens, completion_tokens, total_tokens, created_at
+			  )
+			VALUES
+			  (
+			    :conversation_id, :position, :revision, :role, :content, :reasoning_content,
+			    :model, :prompt_tokens, :completion_tokens, :total_tokens, :created_at
+			  )
+		`, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importGobMessages moves the messages of the .gob files in dir into the messages table.
+// A file is removed once its messages are stored, files that can't be decoded are left alone.
+// Coder histories are imported after the conversations, a history whose conversation
+// was deleted is dropped.
+func importGobMessages(ctx context.Context, db *sqlx.DB, dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+gobExt))
+	if err != nil || len(files) == 0 {
+		return err
+	}
+
+	var histories []string
+	legacy := convo.NewSimpleChatHistoryStore(dir)
+	for _, file := range files {
+		convoID := strings.TrimSuffix(filepath.Base(file), gobExt)
+		if strings.HasSuffix(convoID, convo.CoderHistorySuffix) {
+			histories = append(histories, file)
+			continue
+		}
+		if err := importGobFile(ctx, db, legacy, file); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range histories {
+		convoID := strings.TrimSuffix(filepath.Base(file), convo.CoderHistorySuffix+gobExt)
+		var count int
+		if err := db.GetContext(ctx, &count, db.Rebind(`
+			SELECT
+			  (SELECT COUNT(*) FROM conversations WHERE id = ?) + (SELECT COUNT(*) FROM messages WHERE conversation_id = ?)
+		`), convoID, convoID); err != nil {
+			return fmt.Errorf("import %s: %w", file, err)
+		}
+		if count == 0 {
+			if err := os.Remove(file); err != nil {
+				return fmt.Errorf("import %s: %w", file, err)
+			}
+			continue
+		}
+		if err := importGobFile(ctx, db, legacy, file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importGobFile stores the messages of a single .gob file and removes the file.
+func importGobFile(ctx context.Context, db *sqlx.DB, legacy *convo.SimpleChatHistoryStore, file string) error {
+	convoID := strings.TrimSuffix(filepath.Base(file), gobExt)
+	chatMessages, err := legacy.Messages(ctx, convoID)
+	if err != nil {
+		// files that can't be decoded are left alone
+		return nil
+	}
+
+	var rows []convo.Message
+	for _, m := range chatMessages {
+		if m != nil {
+			rows = append(rows, convo.NewMessage(m))
+		}
+	}
+
+	if err := inTx(ctx, db, func(tx *sqlx.Tx) error {
+		var count int
+		if err := tx.GetContext(ctx, &count, tx.Rebind(`
+			SELECT
+			  COUNT(*)
+			FROM
+			  messages
+			WHERE
+			  conversation_id = ?
+		`), convoID); err != nil {
+			return err
+		}
+		if count > 0 {
+			// already imported, the file is left over from an interrupted import
+			return nil
+		}
+		return insertMessages(ctx, tx, convoID, rows)
+	}); err != nil {
+		return fmt.Errorf("import %s: %w", file, err)
+	}
+
+	if err := os.Remove(file); err != nil {
+		return fmt.Errorf("import %s: %w", file, err)
+	}
+	return nil
+}
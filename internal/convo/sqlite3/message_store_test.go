@@ -0,0 +1,254 @@
+package sqlite3
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+)
+
+func TestSqliteMessageStore(t *testing.T) {
+	t.Run("persist pending messages", testPersistMessages)
+	t.Run("delete conversation removes messages", testDeleteConversationMessages)
+	t.Run("import gob messages", testImportGobMessages)
+	t.Run("import conversation", testImportConversation)
+	t.Run("compact messages", testCompactMessages)
+}
+
+func newTestStore(t *testing.T, dir string) *SqliteStore {
+	t.Helper()
+	h, err := NewSqliteStore(
+		WithDBAddress(filepath.Join(dir, "convo.db")),
+		WithDataPath(filepath.Join(dir, "conversations")),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = h.Close() })
+	return h
+}
+
+func testPersistMessages(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	convoID := convo.NewConversationID()
+
+	h := newTestStore(t, dir)
+	require.NoError(t, h.AddUserMessage(ctx, convoID, "question"))
+	require.NoError(t, h.AddAIMessageWithUsage(ctx, convoID, "answer", "gpt-4o", llms.Usage{
+		PromptTokens:     12,
+		CompletionTokens: 3,
+		TotalTokens:      15,
+	}))
+
+	// pending messages are not stored until persisted
+	require.NoError(t, newTestStore(t, dir).AddUserMessage(ctx, convoID, "other"))
+	messages, err := newTestStore(t, dir).Messages(ctx, convoID)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+
+	require.NoError(t, h.PersistentMessages(ctx, convoID))
+	require.NoError(t, h.AddUserMessage(ctx, convoID, "follow up"))
+	require.NoError(t, h.PersistentMessages(ctx, convoID))
+
+	messages, err = newTestStore(t, dir).Messages(ctx, convoID)
+	require.NoError(t, err)
+	assert.Equal(t, []llms.ChatMessage{
+		llms.HumanChatMessage{Content: "question"},
+		llms.AIChatMessage{Content: "answer"},
+		llms.HumanChatMessage{Content: "follow up"},
+	}, messages)
+
+	rows, err := h.ConversationMessages(ctx, convoID)
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, []int{0, 1, 2}, []int{rows[0].Position, rows[1].Position, rows[2].Position})
+	assert.Equal(t, "gpt-4o", rows[1].Model)
+	assert.Equal(t, 15, rows[1].TotalTokens)
+
+	require.NoError(t, h.InvalidateMessages(ctx, convoID))
+	messages, err = h.Messages(ctx, convoID)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+}
+
+func testDeleteConversationMessages(t *testing.T) {
+	ctx := context.Background()
+	h := newTestStore(t, t.TempDir())
+	kept, deleted := convo.NewConversationID(), convo.NewConversationID()
+
+	for _, id := range []string{kept, deleted} {
+		require.NoError(t, h.SaveConversation(ctx, id, id, "test"))
+		require.NoError(t, h.SetMessages(ctx, id, []llms.ChatMessage{llms.HumanChatMessage{Content: id}}))
+		require.NoError(t, h.SetMessages(ctx, convo.CoderHistoryID(id), []llms.ChatMessage{llms.HumanChatMessage{Content: "/coding " + id}}))
+		require.NoError(t, h.AddAIMessage(ctx, id, "pending"))
+	}
+
+	require.NoError(t, h.DeleteConversation(ctx, deleted))
+	messages, err := h.Messages(ctx, deleted)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+	messages, err = h.Messages(ctx, convo.CoderHistoryID(deleted))
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+	messages, err = h.Messages(ctx, convo.CoderHistoryID(kept))
+	require.NoError(t, err)
+	assert.Len(t, messages, 1)
+	messages, err = h.Messages(ctx, kept)
+	require.NoError(t, err)
+	assert.Len(t, messages, 2)
+
+	require.NoError(t, h.ClearConversations(ctx))
+	var count int
+	require.NoError(t, h.DB.Get(&count, `SELECT COUNT(*) FROM messages`))
+	assert.Zero(t, count)
+	messages, err = h.Messages(ctx, kept)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+}
+
+func testImportGobMessages(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	dataPath := filepath.Join(dir, "conversations")
+	convoID := convo.NewConversationID()
+
+	legacy := convo.NewSimpleChatHistoryStore(dataPath)
+	require.NoError(t, legacy.SetMessages(ctx, convoID, []llms.ChatMessage{
+		llms.SystemChatMessage{Content: "system"},
+		llms.HumanChatMessage{Content: "question"},
+		llms.AIChatMessage{Content: "answer"},
+	}))
+	require.NoError(t, legacy.SetMessages(ctx, convo.CoderHistoryID(convoID), []llms.ChatMessage{
+		llms.HumanChatMessage{Content: "/coding question"},
+	}))
+	orphan := convo.CoderHistoryID(convo.NewConversationID())
+	require.NoError(t, legacy.SetMessages(ctx, orphan, []llms.ChatMessage{
+		llms.HumanChatMessage{Content: "/coding deleted"},
+	}))
+	broken := filepath.Join(dataPath, "broken"+gobExt)
+	require.NoError(t, os.WriteFile(broken, []byte("not gob"), 0o600))
+
+	h := newTestStore(t, dir)
+	messages, err := h.Messages(ctx, convoID)
+	require.NoError(t, err)
+	assert.Equal(t, []llms.ChatMessage{
+		llms.SystemChatMessage{Content: "system"},
+		llms.HumanChatMessage{Content: "question"},
+		llms.AIChatMessage{Content: "answer"},
+	}, messages)
+
+	assert.NoFileExists(t, filepath.Join(dataPath, convoID+gobExt))
+	assert.FileExists(t, broken)
+
+	// the coder history is imported with its conversation, a history without one is dropped
+	messages, err = h.Messages(ctx, convo.CoderHistoryID(convoID))
+	require.NoError(t, err)
+	assert.Len(t, messages, 1)
+	messages, err = h.Messages(ctx, orphan)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+	assert.NoFileExists(t, filepath.Join(dataPath, orphan+gobExt))
+
+	// opening the store again doesn't import anything twice
+	messages, err = newTestStore(t, dir).Messages(ctx, convoID)
+	require.NoError(t, err)
+	assert.Len(t, messages, 3)
+}
+
+func testImportConversation(t *testing.T) {
+	ctx := context.Background()
+	h := newTestStore(t, t.TempDir())
+
+	model := "gpt-4o"
+	at := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	transcript := &convo.Transcript{
+		Conversation: convo.Conversation{
+			ID: convo.NewConversationID(), Title: "imported", UpdatedAt: at, Model: &model,
+			Pinned: true, Tags: []string{"parser"},
+		},
+		Messages: []convo.Message{
+			{Role: "human", Content: "question", CreatedAt: at},
+			{Role: "ai", Content: "answer", Model: model, TotalTokens: 42, CreatedAt: at.Add(time.Minute)},
+		},
+	}
+	require.NoError(t, h.ImportConversation(ctx, transcript, false))
+
+	found, err := h.GetConversation(ctx, transcript.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "imported", found.Title)
+	assert.True(t, at.Equal(found.UpdatedAt), found.UpdatedAt)
+	assert.True(t, found.Pinned)
+	assert.Equal(t, []string{"parser"}, found.Tags)
+
+	messages, err := h.ConversationMessages(ctx, transcript.ID)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, 42, messages[1].TotalTokens)
+	assert.True(t, at.Add(time.Minute).Equal(messages[1].CreatedAt), messages[1].CreatedAt)
+
+	assert.Error(t, h.ImportConversation(ctx, transcript, false))
+
+	transcript.Messages = transcript.Messages[:1]
+	require.NoError(t, h.ImportConversation(ctx, transcript, true))
+	messages, err = h.ConversationMessages(ctx, transcript.ID)
+	require.NoError(t, err)
+	assert.Len(t, messages, 1)
+}
+
+func testCompactMessages(t *testing.T) {
+	ctx := context.Background()
+	h := newTestStore(t, t.TempDir())
+	convoID := convo.NewConversationID()
+
+	require.NoError(t, h.SetMessages(ctx, convoID, []llms.ChatMessage{
+		llms.HumanChatMessage{Content: "one"},
+		llms.AIChatMessage{Content: "1"},
+		llms.HumanChatMessage{Content: "two"},
+		llms.AIChatMessage{Content: "2"},
+	}))
+
+	revision, err := h.CompactMessages(ctx, convoID, []convo.Message{
+		{Role: "system", Content: "summary of one"},
+		{Role: "human", Content: "two"},
+		{Role: "ai", Content: "2"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, revision)
+
+	require.NoError(t, h.AddUserMessage(ctx, convoID, "three"))
+	require.NoError(t, h.PersistentMessages(ctx, convoID))
+	messages, err := h.Messages(ctx, convoID)
+	require.NoError(t, err)
+	assert.Equal(t, []llms.ChatMessage{
+		llms.SystemChatMessage{Content: "summary of one"},
+		llms.HumanChatMessage{Content: "two"},
+		llms.AIChatMessage{Content: "2"},
+		llms.HumanChatMessage{Content: "three"},
+	}, messages)
+
+	// the original messages are kept
+	var original int
+	require.NoError(t, h.DB.Get(&original, `SELECT COUNT(*) FROM messages WHERE conversation_id = ? AND revision = 0`, convoID))
+	assert.Equal(t, 4, original)
+
+	// setting messages replaces the current revision only
+	require.NoError(t, h.SetMessages(ctx, convoID, []llms.ChatMessage{llms.HumanChatMessage{Content: "again"}}))
+	stored, err := h.ConversationMessages(ctx, convoID)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, 1, stored[0].Revision)
+	require.NoError(t, h.DB.Get(&original, `SELECT COUNT(*) FROM messages WHERE conversation_id = ? AND revision = 0`, convoID))
+	assert.Equal(t, 4, original)
+
+	require.NoError(t, h.InvalidateMessages(ctx, convoID))
+	var count int
+	require.NoError(t, h.DB.Get(&count, `SELECT COUNT(*) FROM messages WHERE conversation_id = ?`, convoID))
+	assert.Zero(t, count)
+}
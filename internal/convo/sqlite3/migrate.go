@@ -0,0 +1,223 @@
+package sqlite3
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// migrationFiles holds the up-steps of the schema, one NNNN_name.sql file per version.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is a versioned up-step of the convo db schema.
+type Migration struct {
+	// Version orders the migrations, starting at 1
+	Version int
+	// Name describes the migration, taken from its file name
+	Name string
+	// SQL are the statements of the migration
+	SQL string
+}
+
+// MigrationStatus tells whether a migration was applied to a db.
+type MigrationStatus struct {
+	Migration
+
+	// Applied reports whether the migration was applied
+	Applied bool
+	// AppliedAt is the time the migration was applied
+	AppliedAt time.Time
+}
+
+// Migrate applies the pending migrations to db and returns them.
+func Migrate(ctx context.Context, db *sqlx.DB) ([]Migration, error) {
+	return migrate(ctx, db, migrationFiles)
+}
+
+// Status returns all migrations with whether they were applied to db.
+func Status(ctx context.Context, db *sqlx.DB) ([]MigrationStatus, error) {
+	return status(ctx, db, migrationFiles)
+}
+
+func migrate(ctx context.Context, db *sqlx.DB, fsys fs.FS) ([]Migration, error) {
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	versioned, err := tableExists(ctx, db, "schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	if !versioned {
+		// the tables of a db created before the migrations may lack later columns
+		if err := upgradeLegacySchema(ctx, db); err != nil {
+			return nil, fmt.Errorf("upgrade legacy schema: %w", err)
+		}
+		if _, err := db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS schema_migrations (
+			  version INTEGER NOT NULL PRIMARY KEY,
+			  name string NOT NULL,
+			  applied_at datetime NOT NULL DEFAULT (strftime ('%Y-%m-%d %H:%M:%f', 'now'))
+			)
+		`); err != nil {
+			return nil, fmt.Errorf("create schema_migrations: %w", err)
+		}
+	}
+
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if err := inTx(ctx, db, func(tx *sqlx.Tx) error {
+			if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, tx.Rebind(`
+				INSERT INTO
+				  schema_migrations (version, name)
+				VALUES
+				  (?, ?)
+			`), m.Version, m.Name)
+			return err
+		}); err != nil {
+			return done, fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+func status(ctx context.Context, db *sqlx.DB, fsys fs.FS) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := map[int]time.Time{}
+	versioned, err := tableExists(ctx, db, "schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	if versioned {
+		if applied, err = appliedMigrations(ctx, db); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		at, ok := applied[m.Version]
+		statuses = append(statuses, MigrationStatus{Migration: m, Applied: ok, AppliedAt: at})
+	}
+	return statuses, nil
+}
+
+// loadMigrations reads the migrations of fsys ordered by version. The versions
+// must count up from 1 without gaps.
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	for _, file := range files {
+		base := strings.TrimSuffix(path.Base(file), ".sql")
+		version, name, ok := strings.Cut(base, "_")
+		v, err := strconv.Atoi(version)
+		if !ok || err != nil || v <= 0 {
+			return nil, fmt.Errorf("invalid migration file name %s, want NNNN_name.sql", file)
+		}
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: v, Name: name, SQL: string(content)})
+	}
+
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return a.Version - b.Version
+	})
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration %04d_%s is out of sequence, want version %d", m.Version, m.Name, i+1)
+		}
+	}
+
+	return migrations, nil
+}
+
+func appliedMigrations(ctx context.Context, db *sqlx.DB) (map[int]time.Time, error) {
+	var rows []struct {
+		Version   int       `db:"version"`
+		AppliedAt time.Time `db:"applied_at"`
+	}
+	if err := db.SelectContext(ctx, &rows, `SELECT version, applied_at FROM schema_migrations`); err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+
+	applied := make(map[int]time.Time, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row.AppliedAt
+	}
+	return applied, nil
+}
+
+func tableExists(ctx context.Context, db *sqlx.DB, table string) (bool, error) {
+	var count int
+	if err := db.GetContext(ctx, &count, db.Rebind(`
+		SELECT
+		  COUNT(*)
+		FROM
+		  sqlite_master
+		WHERE
+		  type = 'table'
+		  AND name = ?
+	`), table); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// upgradeLegacySchema adds the columns introduced before the schema was versioned
+// to the tables of an existing db, so the first migration finds them up to date.
+func upgradeLegacySchema(ctx context.Context, db *sqlx.DB) error {
+	var columns []struct {
+		Name string `db:"name"`
+	}
+	if err := db.SelectContext(ctx, &columns, `SELECT name FROM pragma_table_info('load_contexts')`); err != nil {
+		return err
+	}
+
+	if len(columns) == 0 {
+		// a new db, the first migration creates the table
+		return nil
+	}
+	for _, column := range columns {
+		if column.Name == "mode" {
+			return nil
+		}
+	}
+
+	_, err := db.ExecContext(ctx, `ALTER TABLE load_contexts ADD COLUMN mode string NOT NULL DEFAULT 'editable'`)
+	return err
+}
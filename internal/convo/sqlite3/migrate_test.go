@@ -0,0 +1,133 @@
+package sqlite3
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+)
+
+func TestMigrate(t *testing.T) {
+	t.Run("upgrade v0 database", testMigrateV0)
+	t.Run("apply pending migrations in order", testMigratePending)
+	t.Run("reject migrations out of sequence", testMigrationsOutOfSequence)
+}
+
+func openTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	db, err := Open(filepath.Join(t.TempDir(), "convo.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func testMigrateV0(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	fixture, err := os.ReadFile(filepath.Join("testdata", "convo_v0.sql"))
+	require.NoError(t, err)
+	_, err = db.Exec(string(fixture))
+	require.NoError(t, err)
+
+	statuses, err := Status(ctx, db)
+	require.NoError(t, err)
+	require.NotEmpty(t, statuses)
+	for _, s := range statuses {
+		assert.False(t, s.Applied, s.Name)
+	}
+
+	applied, err := Migrate(ctx, db)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(statuses))
+
+	statuses, err = Status(ctx, db)
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.True(t, s.Applied, s.Name)
+		assert.False(t, s.AppliedAt.IsZero(), s.Name)
+	}
+
+	applied, err = Migrate(ctx, db)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	// the data of the v0 database is kept and usable with the new tables
+	h, err := NewSqliteStore(WithDB(db), WithDataPath(t.TempDir()))
+	require.NoError(t, err)
+	found, err := h.GetConversation(ctx, "df31ae23ab8b75b5643c2f846c570997edc71333")
+	require.NoError(t, err)
+	assert.Equal(t, "fix the parser", found.Title)
+
+	contexts, err := h.ListContextsByteConvoID(ctx, found.ID)
+	require.NoError(t, err)
+	require.Len(t, contexts, 1)
+	assert.Equal(t, convo.ContextModeEditable, contexts[0].Mode)
+
+	require.NoError(t, h.SetMessages(ctx, found.ID, []llms.ChatMessage{llms.HumanChatMessage{Content: "hi"}}))
+	messages, err := h.Messages(ctx, found.ID)
+	require.NoError(t, err)
+	assert.Len(t, messages, 1)
+}
+
+func testMigratePending(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	fsys := fstest.MapFS{
+		"migrations/0001_notes.sql": {Data: []byte(`CREATE TABLE notes (id INTEGER PRIMARY KEY, body text NOT NULL);`)},
+	}
+
+	applied, err := migrate(ctx, db, fsys)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, "notes", applied[0].Name)
+
+	fsys["migrations/0003_notes_tag.sql"] = &fstest.MapFile{Data: []byte(`UPDATE notes SET tag = 'done';`)}
+	fsys["migrations/0002_notes_tag.sql"] = &fstest.MapFile{Data: []byte(`ALTER TABLE notes ADD COLUMN tag string NOT NULL DEFAULT '';`)}
+	_, err = db.Exec(`INSERT INTO notes (body) VALUES ('first')`)
+	require.NoError(t, err)
+
+	applied, err = migrate(ctx, db, fsys)
+	require.NoError(t, err)
+	require.Len(t, applied, 2)
+	assert.Equal(t, []int{2, 3}, []int{applied[0].Version, applied[1].Version})
+
+	var tag string
+	require.NoError(t, db.Get(&tag, `SELECT tag FROM notes`))
+	assert.Equal(t, "done", tag)
+
+	// a failing migration is rolled back and not recorded
+	fsys["migrations/0004_broken.sql"] = &fstest.MapFile{Data: []byte(`CREATE TABLE extra (id INTEGER); SELECT * FROM missing;`)}
+	_, err = migrate(ctx, db, fsys)
+	require.Error(t, err)
+	exists, err := tableExists(ctx, db, "extra")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	statuses, err := status(ctx, db, fsys)
+	require.NoError(t, err)
+	require.Len(t, statuses, 4)
+	assert.True(t, statuses[2].Applied)
+	assert.False(t, statuses[3].Applied)
+}
+
+func testMigrationsOutOfSequence(t *testing.T) {
+	_, err := loadMigrations(fstest.MapFS{
+		"migrations/0001_init.sql":  {Data: []byte(`SELECT 1;`)},
+		"migrations/0003_later.sql": {Data: []byte(`SELECT 1;`)},
+	})
+	assert.Error(t, err)
+
+	_, err = loadMigrations(fstest.MapFS{
+		"migrations/init.sql": {Data: []byte(`SELECT 1;`)},
+	})
+	assert.Error(t, err)
+}
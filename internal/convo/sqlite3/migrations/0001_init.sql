@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS conversations (
+	id string NOT NULL PRIMARY KEY,
+	title string NOT NULL,
+	model string NOT NULL,
+	updated_at datetime NOT NULL DEFAULT (strftime ('%Y-%m-%d %H:%M:%f', 'now')),
+	CHECK (id <> ''),
+	CHECK (title <> '')
+);
+CREATE INDEX IF NOT EXISTS idx_conv_id ON conversations (id);
+CREATE INDEX IF NOT EXISTS idx_conv_title ON conversations (title);
+
+CREATE TABLE IF NOT EXISTS load_contexts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	type string NOT NULL,
+	url string,
+	file_path string,
+	content text NOT NULL,
+	name string NOT NULL,
+	mode string NOT NULL DEFAULT 'editable',
+	conversation_id string NOT NULL,
+	updated_at datetime NOT NULL DEFAULT (strftime ('%Y-%m-%d %H:%M:%f', 'now')),
+	CHECK (name <> ''),
+	CHECK (conversation_id <> ''),
+	FOREIGN KEY (conversation_id) REFERENCES conversations(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_loadctx_convo ON load_contexts (conversation_id);
+
+CREATE TABLE IF NOT EXISTS messages (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	conversation_id string NOT NULL,
+	position INTEGER NOT NULL,
+	role string NOT NULL,
+	content text NOT NULL,
+	reasoning_content text NOT NULL DEFAULT '',
+	model string NOT NULL DEFAULT '',
+	prompt_tokens INTEGER NOT NULL DEFAULT 0,
+	completion_tokens INTEGER NOT NULL DEFAULT 0,
+	total_tokens INTEGER NOT NULL DEFAULT 0,
+	created_at datetime NOT NULL DEFAULT (strftime ('%Y-%m-%d %H:%M:%f', 'now')),
+	CHECK (conversation_id <> ''),
+	UNIQUE (conversation_id, position)
+);
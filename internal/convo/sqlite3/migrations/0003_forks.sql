@@ -0,0 +1,3 @@
+ALTER TABLE conversations ADD COLUMN parent_id string;
+ALTER TABLE conversations ADD COLUMN parent_turn INTEGER;
+CREATE INDEX IF NOT EXISTS idx_conv_parent ON conversations (parent_id);
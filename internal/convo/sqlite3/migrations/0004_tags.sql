@@ -0,0 +1,9 @@
+ALTER TABLE conversations ADD COLUMN pinned boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS conversation_tags (
+	conversation_id string NOT NULL,
+	tag string NOT NULL,
+	CHECK (tag <> ''),
+	PRIMARY KEY (conversation_id, tag)
+);
+CREATE INDEX IF NOT EXISTS idx_convtags_tag ON conversation_tags (tag);
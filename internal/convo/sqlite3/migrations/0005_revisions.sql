@@ -0,0 +1,2 @@
+ALTER TABLE messages ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_messages_revision ON messages (conversation_id, revision);
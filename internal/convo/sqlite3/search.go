@@ -0,0 +1,141 @@
+package sqlite3
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+)
+
+// snippetTokens is the number of tokens around a match shown in a snippet.
+const snippetTokens = 12
+
+// SearchConversations finds the conversations whose titles or messages match the query,
+// every conversation is listed once with its best hit.
+func (h *SqliteStore) SearchConversations(ctx context.Context, query string, opts convo.SearchOptions) ([]convo.SearchResult, error) {
+	match := ftsQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+
+	// bm25 and snippet only work in the query matching the fts table, the matches
+	// are materialized before they are joined with their rows
+	ctes := []string{`
+		  message_matches AS MATERIALIZED (
+		    SELECT
+		      rowid,
+		      snippet(messages_fts, 0, ?, ?, '…', ?) AS snippet,
+		      bm25(messages_fts) AS rank
+		    FROM
+		      messages_fts
+		    WHERE
+		      messages_fts MATCH ?
+		  )`}
+	args := []any{convo.SnippetMatchStart, convo.SnippetMatchEnd, snippetTokens, match}
+	if opts.Role == "" {
+		ctes = append(ctes, `
+		  title_matches AS MATERIALIZED (
+		    SELECT
+		      rowid,
+		      snippet(conversations_fts, 0, ?, ?, '…', ?) AS snippet,
+		      bm25(conversations_fts) AS rank
+		    FROM
+		      conversations_fts
+		    WHERE
+		      conversations_fts MATCH ?
+		  )`)
+		args = append(args, convo.SnippetMatchStart, convo.SnippetMatchEnd, snippetTokens, match)
+	}
+
+	hits := `
+		    SELECT
+		      m.conversation_id,
+		      m.role,
+		      f.snippet,
+		      f.rank
+		    FROM
+		      message_matches f
+		      JOIN messages m ON m.id = f.rowid`
+	if opts.Role != "" {
+		hits += `
+		    WHERE
+		      m.role = ?`
+		args = append(args, opts.Role)
+	} else {
+		hits += `
+		    UNION ALL
+		    SELECT
+		      c.id,
+		      '',
+		      f.snippet,
+		      f.rank
+		    FROM
+		      title_matches f
+		      JOIN conversations c ON c.rowid = f.rowid`
+	}
+	ctes = append(ctes, `
+		  hits (conversation_id, role, snippet, rank) AS (`+hits+`
+		  )`)
+
+	// the bare columns of the MIN() aggregate come from the best hit of each conversation
+	stmt := `
+		WITH` + strings.Join(ctes, ",") + `
+		SELECT
+		  c.id,
+		  c.title,
+		  c.model,
+		  c.updated_at,
+		  h.role,
+		  h.snippet,
+		  MIN(h.rank) AS rank
+		FROM
+		  hits h
+		  JOIN conversations c ON c.id = h.conversation_id
+		WHERE
+		  1 = 1`
+	if opts.Model != "" {
+		stmt += ` AND c.model = ?`
+		args = append(args, opts.Model)
+	}
+	if !opts.Since.IsZero() {
+		stmt += ` AND c.updated_at >= ?`
+		args = append(args, opts.Since.UTC())
+	}
+	stmt += `
+		GROUP BY
+		  c.id
+		ORDER BY
+		  rank,
+		  c.updated_at DESC`
+	if opts.Limit > 0 {
+		stmt += ` LIMIT ?`
+		args = append(args, opts.Limit)
+	}
+
+	var results []convo.SearchResult
+	if err := h.DB.SelectContext(ctx, &results, h.DB.Rebind(stmt), args...); err != nil {
+		return nil, fmt.Errorf("SearchConversations: %w", err)
+	}
+	return results, nil
+}
+
+// ftsQuery turns the words of query into an FTS5 query matching all of them.
+// Every word is quoted, so punctuation is searched for instead of parsed as syntax,
+// a trailing * keeps matching the word as a prefix.
+func ftsQuery(query string) string {
+	var terms []string
+	for _, word := range strings.Fields(query) {
+		prefix := strings.HasSuffix(word, "*")
+		word = strings.TrimRight(word, "*")
+		if word == "" {
+			continue
+		}
+		term := `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+		if prefix {
+			term += "*"
+		}
+		terms = append(terms, term)
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,82 @@
+package sqlite3
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+)
+
+func TestSearchConversations(t *testing.T) {
+	ctx := context.Background()
+	h := newTestStore(t, t.TempDir())
+
+	parser, cache, old := convo.NewConversationID(), convo.NewConversationID(), convo.NewConversationID()
+	require.NoError(t, h.SaveConversation(ctx, parser, "fix the parser", "gpt-4o"))
+	require.NoError(t, h.SetMessages(ctx, parser, []llms.ChatMessage{
+		llms.HumanChatMessage{Content: "Why does the parser panic on empty input?"},
+		llms.AIChatMessage{Content: "The lexer returns a nil token, check it before parsing."},
+	}))
+	require.NoError(t, h.SaveConversation(ctx, cache, "redis cache", "deepseek-chat"))
+	require.NoError(t, h.SetMessages(ctx, cache, []llms.ChatMessage{
+		llms.HumanChatMessage{Content: "Add a cache in front of the parser service."},
+	}))
+	require.NoError(t, h.SaveConversation(ctx, old, "old parser notes", "gpt-4o"))
+	_, err := h.DB.ExecContext(ctx, `UPDATE conversations SET updated_at = datetime('now', '-30 days') WHERE id = ?`, old)
+	require.NoError(t, err)
+
+	results, err := h.SearchConversations(ctx, "parser", convo.SearchOptions{})
+	require.NoError(t, err)
+	assert.Len(t, results, 3)
+
+	results, err = h.SearchConversations(ctx, "nil token", convo.SearchOptions{})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, parser, results[0].ID)
+	assert.Equal(t, "ai", results[0].Role)
+	assert.Contains(t, results[0].Snippet, convo.SnippetMatchStart+"nil"+convo.SnippetMatchEnd)
+
+	results, err = h.SearchConversations(ctx, "parser", convo.SearchOptions{Model: "deepseek-chat"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, cache, results[0].ID)
+
+	results, err = h.SearchConversations(ctx, "parser", convo.SearchOptions{Since: time.Now().Add(-24 * time.Hour)})
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	results, err = h.SearchConversations(ctx, "parser", convo.SearchOptions{Role: "ai"})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	results, err = h.SearchConversations(ctx, "parser", convo.SearchOptions{Role: "human", Since: time.Now().Add(-24 * time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "human", results[0].Role)
+
+	results, err = h.SearchConversations(ctx, "pars*", convo.SearchOptions{Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	// punctuation is searched for, not parsed as query syntax
+	results, err = h.SearchConversations(ctx, `input? "parser`, convo.SearchOptions{})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, parser, results[0].ID)
+
+	// deleted conversations and replaced messages are not found anymore
+	require.NoError(t, h.DeleteConversation(ctx, cache))
+	require.NoError(t, h.SetMessages(ctx, parser, []llms.ChatMessage{llms.HumanChatMessage{Content: "start over"}}))
+	results, err = h.SearchConversations(ctx, "cache", convo.SearchOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	results, err = h.SearchConversations(ctx, "lexer", convo.SearchOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
@@ -0,0 +1,116 @@
+package sqlite3
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+)
+
+// AddTags labels the conversation with the tags, tags it already has are skipped.
+func (h *SqliteStore) AddTags(ctx context.Context, id string, tags []string) error {
+	if err := h.mustExist(ctx, id); err != nil {
+		return fmt.Errorf("AddTags: %w", err)
+	}
+	if err := inTx(ctx, h.DB, func(tx *sqlx.Tx) error {
+		return insertTags(ctx, tx, id, tags)
+	}); err != nil {
+		return fmt.Errorf("AddTags: %w", err)
+	}
+	return nil
+}
+
+// RemoveTags removes the tags from the conversation.
+func (h *SqliteStore) RemoveTags(ctx context.Context, id string, tags []string) error {
+	if err := h.mustExist(ctx, id); err != nil {
+		return fmt.Errorf("RemoveTags: %w", err)
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	query, args, err := sqlx.In(`DELETE FROM conversation_tags WHERE conversation_id = ? AND tag IN (?)`, id, tags)
+	if err != nil {
+		return fmt.Errorf("RemoveTags: %w", err)
+	}
+	if _, err := h.DB.ExecContext(ctx, h.DB.Rebind(query), args...); err != nil {
+		return fmt.Errorf("RemoveTags: %w", err)
+	}
+	return nil
+}
+
+// withTags fills in the tags of the conversations.
+func (h *SqliteStore) withTags(ctx context.Context, conversations []convo.Conversation) error {
+	if len(conversations) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(conversations))
+	for _, c := range conversations {
+		ids = append(ids, c.ID)
+	}
+	query, args, err := sqlx.In(`
+		SELECT
+		  conversation_id,
+		  tag
+		FROM
+		  conversation_tags
+		WHERE
+		  conversation_id IN (?)
+		ORDER BY
+		  tag
+	`, ids)
+	if err != nil {
+		return err
+	}
+
+	var rows []struct {
+		ConversationID string `db:"conversation_id"`
+		Tag            string `db:"tag"`
+	}
+	if err := h.DB.SelectContext(ctx, &rows, h.DB.Rebind(query), args...); err != nil {
+		return err
+	}
+
+	tags := make(map[string][]string)
+	for _, row := range rows {
+		tags[row.ConversationID] = append(tags[row.ConversationID], row.Tag)
+	}
+	for i := range conversations {
+		conversations[i].Tags = tags[conversations[i].ID]
+	}
+	return nil
+}
+
+// insertTags labels the conversation with the tags in the transaction.
+func insertTags(ctx context.Context, tx *sqlx.Tx, id string, tags []string) error {
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, tx.Rebind(`
+			INSERT OR IGNORE INTO
+			  conversation_tags (conversation_id, tag)
+			VALUES
+			  (?, ?)
+		`), id, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mustExist fails with errNoMatches when the conversation doesn't exist.
+func (h *SqliteStore) mustExist(ctx context.Context, id string) error {
+	ok, err := h.ConversationExists(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errNoMatches
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package sqlite3
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+)
+
+func TestConversationLabels(t *testing.T) {
+	ctx := context.Background()
+	h := newTestStore(t, t.TempDir())
+
+	id := convo.NewConversationID()
+	require.NoError(t, h.SaveConversation(ctx, id, "fix the parser", "gpt-4o"))
+
+	t.Run("rename", func(t *testing.T) {
+		require.NoError(t, h.RenameConversation(ctx, id, "parser panic"))
+		found, err := h.GetConversation(ctx, "parser panic")
+		require.NoError(t, err)
+		assert.Equal(t, id, found.ID)
+
+		results, err := h.SearchConversations(ctx, "panic", convo.SearchOptions{})
+		require.NoError(t, err)
+		assert.Len(t, results, 1)
+
+		assert.Error(t, h.RenameConversation(ctx, convo.NewConversationID(), "missing"))
+	})
+
+	t.Run("pin", func(t *testing.T) {
+		require.NoError(t, h.PinConversation(ctx, id, true))
+		found, err := h.GetConversation(ctx, id)
+		require.NoError(t, err)
+		assert.True(t, found.Pinned)
+
+		require.NoError(t, h.PinConversation(ctx, id, false))
+		found, err = h.GetConversation(ctx, id)
+		require.NoError(t, err)
+		assert.False(t, found.Pinned)
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		require.NoError(t, h.AddTags(ctx, id, []string{"parser", "bug"}))
+		require.NoError(t, h.AddTags(ctx, id, []string{"bug"}))
+		found, err := h.GetConversation(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"bug", "parser"}, found.Tags)
+		assert.True(t, found.HasTag("parser"))
+
+		other := convo.NewConversationID()
+		require.NoError(t, h.SaveConversation(ctx, other, "cache", "gpt-4o"))
+		conversations, err := h.ListConversations(ctx)
+		require.NoError(t, err)
+		require.Len(t, conversations, 2)
+		for _, c := range conversations {
+			if c.ID == id {
+				assert.Equal(t, []string{"bug", "parser"}, c.Tags)
+			} else {
+				assert.Empty(t, c.Tags)
+			}
+		}
+
+		fork, err := h.ForkConversation(ctx, id, convo.NewConversationID(), 0)
+		require.NoError(t, err)
+		found, err = h.GetConversation(ctx, fork.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"bug", "parser"}, found.Tags)
+
+		require.NoError(t, h.RemoveTags(ctx, id, []string{"bug", "unknown"}))
+		found, err = h.GetConversation(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"parser"}, found.Tags)
+
+		assert.Error(t, h.AddTags(ctx, convo.NewConversationID(), []string{"bug"}))
+	})
+
+	t.Run("delete drops the tags", func(t *testing.T) {
+		require.NoError(t, h.DeleteConversation(ctx, id))
+		var count int
+		require.NoError(t, h.DB.Get(&count, `SELECT COUNT(*) FROM conversation_tags WHERE conversation_id = ?`, id))
+		assert.Zero(t, count)
+	})
+}
@@ -0,0 +1,31 @@
+-- a convo db as it was created before the schema was versioned
+CREATE TABLE
+  IF NOT EXISTS conversations (
+    id string NOT NULL PRIMARY KEY,
+    title string NOT NULL,
+    model string NOT NULL,
+    updated_at datetime NOT NULL DEFAULT (strftime ('%Y-%m-%d %H:%M:%f', 'now')),
+    CHECK (id <> ''),
+    CHECK (title <> '')
+  );
+CREATE INDEX IF NOT EXISTS idx_conv_id ON conversations (id);
+CREATE INDEX IF NOT EXISTS idx_conv_title ON conversations (title);
+
+CREATE TABLE IF NOT EXISTS load_contexts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	type string NOT NULL,
+	url string,
+	file_path string,
+	content text NOT NULL,
+	name string NOT NULL,
+	conversation_id string NOT NULL,
+	updated_at datetime NOT NULL DEFAULT (strftime ('%Y-%m-%d %H:%M:%f', 'now')),
+	CHECK (name <> ''),
+	CHECK (conversation_id <> ''),
+	FOREIGN KEY (conversation_id) REFERENCES conversations(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_loadctx_convo ON load_contexts (conversation_id);
+
+INSERT INTO conversations (id, title, model) VALUES ('df31ae23ab8b75b5643c2f846c570997edc71333', 'fix the parser', 'gpt-4o');
+INSERT INTO load_contexts (type, url, file_path, content, name, conversation_id)
+VALUES ('file', '', 'parser.go', 'package parser', 'parser.go', 'df31ae23ab8b75b5643c2f846c570997edc71333');
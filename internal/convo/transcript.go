@@ -0,0 +1,245 @@
+package convo
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark"
+)
+
+// TranscriptVersion is the version of the JSON transcript format.
+const TranscriptVersion = 1
+
+// Formats a transcript can be exported to.
+const (
+	FormatMarkdown = "md"
+	FormatJSON     = "json"
+	FormatHTML     = "html"
+)
+
+// TranscriptFormats lists the formats a transcript can be exported to.
+var TranscriptFormats = []string{FormatMarkdown, FormatJSON, FormatHTML}
+
+//go:embed transcript.html.tmpl
+var transcriptHTML string
+
+// Transcript is a convo with all its messages, as it is exported and imported.
+type Transcript struct {
+	// Version of the transcript format
+	Version int `json:"version"`
+
+	Conversation
+
+	// Messages of the convo, oldest first
+	Messages []Message `json:"messages"`
+}
+
+// ReadTranscript reads a JSON transcript.
+func ReadTranscript(r io.Reader) (*Transcript, error) {
+	var t Transcript
+	if err := json.NewDecoder(r).Decode(&t); err != nil {
+		return nil, fmt.Errorf("read transcript: %w", err)
+	}
+	if t.Version > TranscriptVersion {
+		return nil, fmt.Errorf("read transcript: unsupported version %d", t.Version)
+	}
+	if len(t.Messages) == 0 && t.Title == "" {
+		return nil, fmt.Errorf("read transcript: no conversation found")
+	}
+	for i, m := range t.Messages {
+		if m.ChatMessage() == nil {
+			return nil, fmt.Errorf("read transcript: message %d has unknown role %q", i+1, m.Role)
+		}
+	}
+	return &t, nil
+}
+
+// Write writes the transcript in the format, one of TranscriptFormats.
+func (t *Transcript) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatMarkdown:
+		_, err := io.WriteString(w, t.Markdown())
+		return err
+	case FormatJSON:
+		t.Version = TranscriptVersion
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(t)
+	case FormatHTML:
+		return t.writeHTML(w)
+	default:
+		return fmt.Errorf("unknown transcript format %q, use one of %s", format, strings.Join(TranscriptFormats, ", "))
+	}
+}
+
+// Markdown renders the transcript as a Markdown document.
+func (t *Transcript) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", t.Title)
+	fmt.Fprintf(&b, "- ID: `%s`\n", t.ID)
+	if t.Model != nil && *t.Model != "" {
+		fmt.Fprintf(&b, "- Model: %s\n", *t.Model)
+	}
+	if !t.UpdatedAt.IsZero() {
+		fmt.Fprintf(&b, "- Updated: %s\n", formatTime(t.UpdatedAt))
+	}
+
+	for _, m := range t.Messages {
+		fmt.Fprintf(&b, "\n## %s\n\n", m.Heading())
+		if details := m.Details(); details != "" {
+			fmt.Fprintf(&b, "_%s_\n\n", details)
+		}
+		if m.ReasoningContent != "" {
+			fmt.Fprintf(&b, "<details>\n<summary>Reasoning</summary>\n\n%s\n\n</details>\n\n", strings.TrimSpace(m.ReasoningContent))
+		}
+		b.WriteString(strings.TrimSpace(m.Content))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Text renders the transcript as plain text.
+func (t *Transcript) Text() string {
+	var b strings.Builder
+	for i, m := range t.Messages {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(m.Heading())
+		if details := m.Details(); details != "" {
+			fmt.Fprintf(&b, " (%s)", details)
+		}
+		b.WriteString(":\n")
+		b.WriteString(strings.TrimSpace(m.Content))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Turn is a prompt of the user and the messages answering it.
+type Turn []Message
+
+// Turns splits the messages into turns, each starting with a prompt of the user.
+// Messages before the first prompt, like the system prompt, belong to the first turn.
+func (t *Transcript) Turns() []Turn {
+	var turns []Turn
+	for _, m := range t.Messages {
+		if len(turns) == 0 || (m.Role == "human" && hasPrompt(turns[len(turns)-1])) {
+			turns = append(turns, nil)
+		}
+		turns[len(turns)-1] = append(turns[len(turns)-1], m)
+	}
+	return turns
+}
+
+// SelectTurns returns the transcript with only the turn n, counting from 1, when n is positive,
+// or only the last tail turns when tail is positive.
+func (t *Transcript) SelectTurns(n, tail int) (*Transcript, error) {
+	if n <= 0 && tail <= 0 {
+		return t, nil
+	}
+
+	turns := t.Turns()
+	switch {
+	case n > len(turns):
+		return nil, fmt.Errorf("turn %d not found, the conversation has %d turns", n, len(turns))
+	case n > 0:
+		turns = turns[n-1 : n]
+	case tail < len(turns):
+		turns = turns[len(turns)-tail:]
+	}
+
+	selected := *t
+	selected.Messages = nil
+	for _, turn := range turns {
+		selected.Messages = append(selected.Messages, turn...)
+	}
+	return &selected, nil
+}
+
+// MessagesUpToTurn returns the messages of the turns up to n, counting from 1,
+// or all messages when n is 0.
+func (t *Transcript) MessagesUpToTurn(n int) ([]Message, error) {
+	if n <= 0 {
+		return t.Messages, nil
+	}
+
+	turns := t.Turns()
+	if n > len(turns) {
+		return nil, fmt.Errorf("turn %d not found, the conversation has %d turns", n, len(turns))
+	}
+
+	var messages []Message
+	for _, turn := range turns[:n] {
+		messages = append(messages, turn...)
+	}
+	return messages, nil
+}
+
+func hasPrompt(turn Turn) bool {
+	for _, m := range turn {
+		if m.Role == "human" {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Transcript) writeHTML(w io.Writer) error {
+	tmpl, err := template.New("transcript").Funcs(template.FuncMap{
+		"markdown": renderHTML,
+		"time":     formatTime,
+	}).Parse(transcriptHTML)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, t)
+}
+
+// Heading names the role of the message.
+func (m Message) Heading() string {
+	switch m.Role {
+	case "human":
+		return "User"
+	case "ai":
+		return "Assistant"
+	case "":
+		return "Message"
+	default:
+		return strings.ToUpper(m.Role[:1]) + m.Role[1:]
+	}
+}
+
+// Details tells when the message was written, by which model and its token usage.
+func (m Message) Details() string {
+	var details []string
+	if !m.CreatedAt.IsZero() {
+		details = append(details, formatTime(m.CreatedAt))
+	}
+	if m.Model != "" {
+		details = append(details, m.Model)
+	}
+	if m.TotalTokens > 0 {
+		details = append(details, fmt.Sprintf("%d tokens (%d prompt, %d completion)", m.TotalTokens, m.PromptTokens, m.CompletionTokens))
+	}
+	return strings.Join(details, " · ")
+}
+
+func formatTime(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+// renderHTML converts Markdown to HTML, raw HTML in the source is omitted.
+func renderHTML(source string) template.HTML {
+	var buf bytes.Buffer
+	if err := goldmark.Convert([]byte(source), &buf); err != nil {
+		return template.HTML("<pre>" + template.HTMLEscapeString(source) + "</pre>") //nolint:gosec
+	}
+	return template.HTML(buf.String()) //nolint:gosec
+}
@@ -0,0 +1,141 @@
+package convo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTranscript() *Transcript {
+	model := "gpt-4o"
+	at := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	return &Transcript{
+		Conversation: Conversation{
+			ID:        NewConversationID(),
+			Title:     "fix the parser",
+			UpdatedAt: at,
+			Model:     &model,
+		},
+		Messages: []Message{
+			{Role: "human", Content: "Why does the parser panic?\n<script>alert(1)</script>", CreatedAt: at},
+			{
+				Role:             "ai",
+				Content:          "Check the token:\n\n```go\nif tok == nil {\n\treturn\n}\n```",
+				ReasoningContent: "The lexer returns nil at EOF.",
+				Model:            model,
+				PromptTokens:     120,
+				CompletionTokens: 30,
+				TotalTokens:      150,
+				CreatedAt:        at.Add(time.Minute),
+			},
+		},
+	}
+}
+
+func TestTranscript(t *testing.T) {
+	t.Run("json round trip", func(t *testing.T) {
+		transcript := testTranscript()
+		var buf bytes.Buffer
+		require.NoError(t, transcript.Write(&buf, FormatJSON))
+
+		read, err := ReadTranscript(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, TranscriptVersion, read.Version)
+		assert.Equal(t, transcript.ID, read.ID)
+		assert.Equal(t, *transcript.Model, *read.Model)
+		require.Len(t, read.Messages, 2)
+		assert.Equal(t, transcript.Messages[1].Content, read.Messages[1].Content)
+		assert.Equal(t, 150, read.Messages[1].TotalTokens)
+		assert.True(t, transcript.Messages[1].CreatedAt.Equal(read.Messages[1].CreatedAt))
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, testTranscript().Write(&buf, FormatMarkdown))
+		md := buf.String()
+
+		assert.True(t, strings.HasPrefix(md, "# fix the parser\n"))
+		assert.Contains(t, md, "- Model: gpt-4o")
+		assert.Contains(t, md, "## User")
+		assert.Contains(t, md, "## Assistant")
+		assert.Contains(t, md, "gpt-4o · 150 tokens (120 prompt, 30 completion)")
+		assert.Contains(t, md, "<summary>Reasoning</summary>")
+	})
+
+	t.Run("html", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, testTranscript().Write(&buf, FormatHTML))
+		html := buf.String()
+
+		assert.Contains(t, html, "<title>fix the parser</title>")
+		assert.Contains(t, html, `<section class="message ai">`)
+		assert.Contains(t, html, `<code class="language-go">`)
+		assert.NotContains(t, html, "<script>")
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		assert.Error(t, testTranscript().Write(&bytes.Buffer{}, "pdf"))
+	})
+
+	t.Run("invalid transcripts", func(t *testing.T) {
+		for _, input := range []string{
+			`not json`,
+			`{}`,
+			`{"version": 99, "title": "future"}`,
+			`{"title": "t", "messages": [{"role": "robot", "content": "hi"}]}`,
+		} {
+			_, err := ReadTranscript(strings.NewReader(input))
+			assert.Error(t, err, input)
+		}
+	})
+
+	t.Run("text", func(t *testing.T) {
+		text := testTranscript().Text()
+		assert.True(t, strings.HasPrefix(text, "User ("))
+		assert.Contains(t, text, "Assistant (")
+		assert.Contains(t, text, "<script>alert(1)</script>")
+	})
+}
+
+func TestSelectTurns(t *testing.T) {
+	transcript := &Transcript{Messages: []Message{
+		{Role: "system", Content: "be brief"},
+		{Role: "human", Content: "one"},
+		{Role: "ai", Content: "1"},
+		{Role: "human", Content: "two"},
+		{Role: "human", Content: "two again"},
+		{Role: "ai", Content: "2"},
+		{Role: "human", Content: "three"},
+	}}
+
+	turns := transcript.Turns()
+	require.Len(t, turns, 4)
+	assert.Len(t, turns[0], 3)
+	assert.Equal(t, "two again", turns[2][0].Content)
+
+	selected, err := transcript.SelectTurns(0, 0)
+	require.NoError(t, err)
+	assert.Len(t, selected.Messages, 7)
+
+	selected, err = transcript.SelectTurns(3, 0)
+	require.NoError(t, err)
+	require.Len(t, selected.Messages, 2)
+	assert.Equal(t, "2", selected.Messages[1].Content)
+	assert.Len(t, transcript.Messages, 7)
+
+	selected, err = transcript.SelectTurns(0, 2)
+	require.NoError(t, err)
+	require.Len(t, selected.Messages, 3)
+	assert.Equal(t, "two again", selected.Messages[0].Content)
+
+	selected, err = transcript.SelectTurns(0, 10)
+	require.NoError(t, err)
+	assert.Len(t, selected.Messages, 7)
+
+	_, err = transcript.SelectTurns(5, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,49 @@
+package convo
+
+// ConversationNode is a convo with the convos forked from it.
+type ConversationNode struct {
+	Conversation
+
+	// Children are the forks of the convo
+	Children []*ConversationNode
+}
+
+// ConversationTree arranges the conversations by the convos they were forked from.
+// Conversations without a parent, or whose parent is gone, are the roots. Roots and
+// children keep the order of the given conversations.
+func ConversationTree(conversations []Conversation) []*ConversationNode {
+	nodes := make(map[string]*ConversationNode, len(conversations))
+	for _, c := range conversations {
+		nodes[c.ID] = &ConversationNode{Conversation: c}
+	}
+
+	var roots []*ConversationNode
+	for _, c := range conversations {
+		node := nodes[c.ID]
+		var parent *ConversationNode
+		if c.ParentID != nil {
+			parent = nodes[*c.ParentID]
+		}
+		if parent != nil && !isDescendant(parent, node, nodes) {
+			parent.Children = append(parent.Children, node)
+			continue
+		}
+		roots = append(roots, node)
+	}
+	return roots
+}
+
+// isDescendant tells whether candidate is node or one of its descendants, so linking
+// node to candidate as its parent would make a cycle.
+func isDescendant(candidate, node *ConversationNode, nodes map[string]*ConversationNode) bool {
+	for seen := 0; candidate != nil && seen < len(nodes); seen++ {
+		if candidate == node {
+			return true
+		}
+		if candidate.ParentID == nil {
+			return false
+		}
+		candidate = nodes[*candidate.ParentID]
+	}
+	return candidate != nil
+}
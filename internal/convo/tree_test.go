@@ -0,0 +1,48 @@
+package convo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversationTree(t *testing.T) {
+	ref := func(id string) *string { return &id }
+	roots := ConversationTree([]Conversation{
+		{ID: "a"},
+		{ID: "b", ParentID: ref("a")},
+		{ID: "c", ParentID: ref("b")},
+		{ID: "d", ParentID: ref("a")},
+		{ID: "e", ParentID: ref("gone")},
+		{ID: "f", ParentID: ref("g")},
+		{ID: "g", ParentID: ref("f")},
+	})
+
+	require.Len(t, roots, 4)
+	assert.Equal(t, "a", roots[0].ID)
+	require.Len(t, roots[0].Children, 2)
+	assert.Equal(t, "b", roots[0].Children[0].ID)
+	assert.Equal(t, "c", roots[0].Children[0].Children[0].ID)
+	assert.Equal(t, "d", roots[0].Children[1].ID)
+	assert.Equal(t, "e", roots[1].ID)
+	assert.Equal(t, []string{"f", "g"}, []string{roots[2].ID, roots[3].ID})
+}
+
+func TestParseForkRef(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		ref  string
+		turn int
+	}{
+		{in: "6a8e1d2", ref: "6a8e1d2"},
+		{in: "6a8e1d2@3", ref: "6a8e1d2", turn: 3},
+		{in: "mail me@example.com", ref: "mail me@example.com"},
+		{in: "a@b@2", ref: "a@b", turn: 2},
+		{in: "6a8e1d2@-1", ref: "6a8e1d2@-1"},
+	} {
+		ref, turn := ParseForkRef(tc.in)
+		assert.Equal(t, tc.ref, ref, tc.in)
+		assert.Equal(t, tc.turn, turn, tc.in)
+	}
+}
@@ -36,6 +36,8 @@ type Command struct {
 	diffUnified int
 	excludeList []string
 	isAmend     bool
+	// dir is the working tree the commands run in, the current directory when empty
+	dir string
 }
 
 func New(opts ...Option) *Command {
@@ -50,6 +52,7 @@ func New(opts ...Option) *Command {
 		// Append the user-defined excludeList to the default excludeFromDiff
 		excludeList: append(excludeFromDiff, cfg.excludeList...),
 		isAmend:     cfg.isAmend,
+		dir:         cfg.dir,
 	}
 
 	return cmd
@@ -57,7 +60,7 @@ func New(opts ...Option) *Command {
 
 func (c *Command) AddFiles(files []string) error {
 	for _, file := range files {
-		output, err := exec.Command("git", "add", file).CombinedOutput()
+		output, err := c.command("add", file).CombinedOutput()
 		if err != nil {
 			return fmt.Errorf("failed to add file %s: %w, output: %s", file, err, string(output))
 		}
@@ -76,13 +79,109 @@ func (c *Command) Commit(val string) (string, error) {
 
 // RollbackLastCommit rolls back the most recent commit, leaving changes staged.
 func (c *Command) RollbackLastCommit() error {
-	output, err := exec.Command("git", "reset", "--hard", "HEAD~1").CombinedOutput()
+	output, err := c.command("reset", "--hard", "HEAD~1").CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to rollback last commit: %w, output: %s", err, string(output))
 	}
 	return nil
 }
 
+// HeadCommit returns the SHA of the commit HEAD points to.
+func (c *Command) HeadCommit() (string, error) {
+	output, err := c.command("rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// AddWorktree checks out a new branch, started at base, into a new worktree at path.
+// An empty base checks out the existing branch instead.
+func (c *Command) AddWorktree(path, branch, base string) error {
+	args := []string{"worktree", "add", path, branch}
+	if base != "" {
+		args = []string{"worktree", "add", "-b", branch, path, base}
+	}
+	output, err := c.command(args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to add worktree %s: %w, output: %s", path, err, string(output))
+	}
+	return nil
+}
+
+// RemoveWorktree removes the worktree at path, discarding its uncommitted changes.
+func (c *Command) RemoveWorktree(path string) error {
+	output, err := c.command("worktree", "remove", "--force", path).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to remove worktree %s: %w, output: %s", path, err, string(output))
+	}
+	return nil
+}
+
+// DeleteBranch deletes the branch, even when it was not merged.
+func (c *Command) DeleteBranch(branch string) error {
+	output, err := c.command("branch", "-D", branch).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to delete branch %s: %w, output: %s", branch, err, string(output))
+	}
+	return nil
+}
+
+// BranchExists reports whether the local branch exists.
+func (c *Command) BranchExists(branch string) bool {
+	return c.command("rev-parse", "--verify", "--quiet", "refs/heads/"+branch).Run() == nil
+}
+
+// CurrentBranch returns the name of the checked out branch.
+func (c *Command) CurrentBranch() (string, error) {
+	output, err := c.command("rev-parse", "--abbrev-ref", "HEAD").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// HasChanges reports whether the working tree has uncommitted changes.
+func (c *Command) HasChanges() (bool, error) {
+	output, err := c.command("status", "--porcelain").Output()
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(output)) != "", nil
+}
+
+// CommitsAhead returns the number of commits on branch that are not on HEAD.
+func (c *Command) CommitsAhead(branch string) (int, error) {
+	output, err := c.command("rev-list", "--count", "HEAD.."+branch).Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(output)))
+}
+
+// Merge merges the branch into the checked out branch, either fast-forward only or
+// squashed into a single commit that uses the messages of the merged commits.
+func (c *Command) Merge(branch string, squash bool) error {
+	if !squash {
+		output, err := c.command("merge", "--ff-only", branch).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("failed to merge %s: %w, output: %s", branch, err, string(output))
+		}
+		return nil
+	}
+
+	output, err := c.command("merge", "--squash", branch).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to merge %s: %w, output: %s", branch, err, string(output))
+	}
+	output, err = c.command("commit", "--no-verify", "--no-edit").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to commit the merge of %s: %w, output: %s", branch, err, string(output))
+	}
+	return nil
+}
+
 // GitDir to show the (by default, absolute) path of the git directory of the working tree.
 func (c *Command) GitDir() (string, error) {
 	output, err := c.gitDir().Output()
@@ -94,8 +193,7 @@ func (c *Command) GitDir() (string, error) {
 }
 
 func (c *Command) ListAllFiles() ([]string, error) {
-	cmd := exec.Command("git", "ls-files")
-	output, err := cmd.Output()
+	output, err := c.command("ls-files").Output()
 	if err != nil {
 		return nil, err
 	}
@@ -104,6 +202,40 @@ func (c *Command) ListAllFiles() ([]string, error) {
 	return fileNames, nil
 }
 
+// IgnoredFiles returns the files, relative to the working tree of c, that are matched by .gitignore.
+func (c *Command) IgnoredFiles(files []string) ([]string, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	output, err := c.command(append([]string{"check-ignore", "--"}, files...)...).Output()
+	if err != nil {
+		// exit status 1 means that none of the files is ignored
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var ignored []string
+	for _, f := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if f != "" {
+			ignored = append(ignored, f)
+		}
+	}
+	return ignored, nil
+}
+
+// WorkingDiff returns the uncommitted changes of the working tree compared to HEAD.
+func (c *Command) WorkingDiff() (string, error) {
+	output, err := c.command("diff", "HEAD").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
 // DiffFiles compares the differences between two sets of data.
 func (c *Command) DiffFiles() (string, error) {
 	output, err := c.diffNames().Output()
@@ -161,6 +293,13 @@ func (c *Command) UninstallHook() error {
 	return nil
 }
 
+// command returns the git command with the given arguments, run in the working tree of c.
+func (c *Command) command(args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = c.dir
+	return cmd
+}
+
 func (c *Command) excludeFiles() []string {
 	excludedFiles := []string{}
 	for _, f := range c.excludeList {
@@ -184,10 +323,7 @@ func (c *Command) diffNames() *exec.Cmd {
 	excludedFiles := c.excludeFiles()
 	args = append(args, excludedFiles...)
 
-	return exec.Command(
-		"git",
-		args...,
-	)
+	return c.command(args...)
 }
 
 func (c *Command) diffFiles() *exec.Cmd {
@@ -207,10 +343,7 @@ func (c *Command) diffFiles() *exec.Cmd {
 	excludedFiles := c.excludeFiles()
 	args = append(args, excludedFiles...)
 
-	return exec.Command(
-		"git",
-		args...,
-	)
+	return c.command(args...)
 }
 
 func (c *Command) hookPath() *exec.Cmd {
@@ -220,10 +353,7 @@ func (c *Command) hookPath() *exec.Cmd {
 		"hooks",
 	}
 
-	return exec.Command(
-		"git",
-		args...,
-	)
+	return c.command(args...)
 }
 
 func (c *Command) gitDir() *exec.Cmd {
@@ -232,10 +362,7 @@ func (c *Command) gitDir() *exec.Cmd {
 		"--git-dir",
 	}
 
-	return exec.Command(
-		"git",
-		args...,
-	)
+	return c.command(args...)
 }
 
 func (c *Command) commit(val string) *exec.Cmd {
@@ -250,10 +377,7 @@ func (c *Command) commit(val string) *exec.Cmd {
 		args = append(args, "--amend")
 	}
 
-	return exec.Command(
-		"git",
-		args...,
-	)
+	return c.command(args...)
 }
 
 // DiffStats holds statistics about the diff
@@ -1,6 +1,9 @@
 package git
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -15,3 +18,47 @@ func TestCommand_GitDir(t *testing.T) {
 	assert.NotEmpty(t, dir)
 	assert.True(t, strings.HasSuffix(strings.TrimSpace(dir), ".git"))
 }
+
+func TestCommand_IgnoredFiles(t *testing.T) {
+	dir := t.TempDir()
+	cmd := exec.Command("git", "init", "-q")
+	cmd.Dir = dir
+	require.NoError(t, cmd.Run())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*.log\n"), 0o600))
+
+	g := New(WithDir(dir))
+	ignored, err := g.IgnoredFiles([]string{"main.go", "debug.log"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"debug.log"}, ignored)
+
+	ignored, err = g.IgnoredFiles([]string{"main.go"})
+	require.NoError(t, err)
+	assert.Empty(t, ignored)
+}
+
+func TestCommand_Worktree(t *testing.T) {
+	root := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		require.NoError(t, cmd.Run())
+	}
+
+	g := New(WithDir(root))
+	head, err := g.HeadCommit()
+	require.NoError(t, err)
+
+	worktree := filepath.Join(t.TempDir(), "wt")
+	require.NoError(t, g.AddWorktree(worktree, "ai/test", "HEAD"))
+
+	wtHead, err := New(WithDir(worktree)).HeadCommit()
+	require.NoError(t, err)
+	assert.Equal(t, head, wtHead)
+
+	require.NoError(t, g.RemoveWorktree(worktree))
+	assert.NoDirExists(t, worktree)
+	require.NoError(t, g.DeleteBranch("ai/test"))
+}
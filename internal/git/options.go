@@ -42,9 +42,17 @@ func WithEnableAmend(val bool) Option {
 	})
 }
 
+// WithDir returns an Option that runs every git command in the given working tree.
+func WithDir(dir string) Option {
+	return optionFunc(func(c *config) {
+		c.dir = dir
+	})
+}
+
 // config is a struct that stores configuration options for the instrumentation.
 type config struct {
 	diffUnified int
 	excludeList []string
 	isAmend     bool
+	dir         string
 }
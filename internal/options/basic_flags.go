@@ -32,8 +32,11 @@ func AddBasicFlags(flags *pflag.FlagSet, cfg *Config) {
 	flags.BoolVar(&cfg.NoCache, "no-cache", cfg.NoCache, console.StdoutStyles().FlagDesc.Render(Help["no-cache"]))
 	flags.StringVarP(&cfg.Show, "show", "s", cfg.Show, console.StdoutStyles().FlagDesc.Render(Help["show"]))
 	flags.BoolVarP(&cfg.ShowLast, "show-last", "S", false, console.StdoutStyles().FlagDesc.Render(Help["show-last"]))
+	flags.IntVar(&cfg.ShowTurn, "turn", cfg.ShowTurn, console.StdoutStyles().FlagDesc.Render(Help["show-turn"]))
+	flags.IntVar(&cfg.ShowTail, "tail", cfg.ShowTail, console.StdoutStyles().FlagDesc.Render(Help["show-tail"]))
 	flags.StringVarP(&cfg.Continue, "continue", "c", "", console.StdoutStyles().FlagDesc.Render(Help["continue"]))
 	flags.BoolVarP(&cfg.ContinueLast, "continue-last", "C", false, console.StdoutStyles().FlagDesc.Render(Help["continue-last"]))
+	flags.StringVar(&cfg.Fork, "fork", "", console.StdoutStyles().FlagDesc.Render(Help["fork"]))
 	flags.StringVarP(&cfg.Title, "title", "T", cfg.Title, console.StdoutStyles().FlagDesc.Render(Help["title"]))
 	flags.IntVarP(&cfg.Verbose, "verbose", "v", cfg.Verbose, console.StdoutStyles().FlagDesc.Render(Help["verbose"]))
 	//flags.StringVarP(&cfg.Role, "role", "R", cfg.Role, console.StdoutStyles().FlagDesc.Render(Help["role"]))
@@ -33,6 +33,13 @@ const (
 
 	defaultMarkdownFormatText = "Format the response as markdown without enclosing backticks."
 	defaultJSONFormatText     = "Format the response as json without enclosing backticks."
+
+	defaultMaxReflections = 3
+	defaultMaxFixRounds   = 3
+	defaultMapTokens      = 1024
+	defaultHistoryTokens  = 2048
+
+	defaultCompactThreshold = 0.8
 )
 
 var Help = map[string]string{
@@ -68,19 +75,32 @@ var Help = map[string]string{
 	"reset-settings":      "Backup your old settings file and reset everything to the defaults.",
 	"continue":            "Continue from the last response or a given save title.",
 	"continue-last":       "Continue from the last response.",
+	"fork":                "Continues a copy of the conversation with the given title or ID, up to a turn with <id>@<turn>.",
 	"no-cache":            "Disables caching of the prompt/response.",
 	"title":               "Saves the current conversation with the given title.",
 	"ls-convo":            "Lists saved conversations.",
 	"rm-convo":            "Deletes a saved conversation with the given title or ID.",
-	"rm-convo-older-than": "Deletes all saved conversations older than the specified duration. Valid units are: " + str.EnglishJoin(duration.ValidUnits(), true) + ".",
-	"rm-all-convo":        "Deletes all saved conversations.",
+	"rm-convo-older-than": "Deletes all saved conversations older than the specified duration, except the pinned ones. Valid units are: " + str.EnglishJoin(duration.ValidUnits(), true) + ".",
+	"rm-all-convo":        "Deletes all saved conversations, except the pinned ones.",
 	"show-convo":          "Show a saved conversation with the given title or ID.",
 	"theme":               "Theme to use in the forms. Valid units are: 'charm', 'catppuccin', 'dracula', and 'base16'",
 	"show-last":           "Show the last saved conversation.",
+	"show-turn":           "Show only the given turn of the conversation, counting from 1.",
+	"show-tail":           "Show only the last given number of turns of the conversation.",
 	"datastore":           "Configure the datastore to use.",
 	"auto-coder":          "Configure the auto coder to use.",
 	"auto-commit":         "Automatically commit code changes after generation.",
+	"lint-cmd":            "Command run after the auto coder applied edits, e.g. 'go vet ./...'. Failures are sent back to the model.",
+	"test-cmd":            "Command run after the auto coder applied edits, e.g. 'go test ./...'. Failures are sent back to the model.",
+	"max-fix-rounds":      "How many times lint or test failures are sent back to the model for a fix. A negative value disables it.",
+	"summary-model":       "Model used to summarize files that don't fit the context window. Uses a symbol outline when empty.",
+	"history-tokens":      "Token budget of the chat history replayed with auto coder requests. Older turns are summarized. A negative value disables the history.",
+	"map-tokens":          "Token budget of the repository map sent with auto coder prompts. A negative value disables it.",
+	"max-reflections":     "How many times failed edits are sent back to the model for correction. A negative value disables it.",
+	"edit-format":         "Edit format the auto coder asks the model for: diff (SEARCH/REPLACE blocks), whole (entire files) or udiff (unified diffs).",
 	"show-token-usage":    "Show token usage in the response.",
+	"compact-threshold":   "Fraction of the model input budget a continued conversation may fill before its older turns are summarized. A negative value disables it.",
+	"title-model":         "Model that names new conversations after their first exchange. The first line of the prompt is used when empty or unreachable.",
 	"coding-fences":       "Specify the code fences to be used. The value should be a two-part array, such as ['```', '```'].",
 	"verbose":             "Verbose mode. 0: no verbose, 1: debug verbose",
 }
@@ -111,6 +131,10 @@ type Config struct {
 	DataStore       DataStore  `yaml:"datastore"`
 	AutoCoder       AutoCoder  `yaml:"auto-coder"`
 	ShowTokenUsages bool       `yaml:"show-token-usage" env:"SHOW_TOKEN_USAGES"`
+	TitleModel      string     `yaml:"title-model" env:"TITLE_MODEL"`
+	// CompactThreshold is the fraction of the model input budget a continued conversation
+	// may fill before its older turns are summarized.
+	CompactThreshold float64 `yaml:"compact-threshold" env:"COMPACT_THRESHOLD"`
 
 	DefaultPromptMode string `yaml:"default-prompt-mode,omitempty"`
 	ConversationID    string `yaml:"convo-id,omitempty"`
@@ -125,9 +149,12 @@ type Config struct {
 	PromptFile   string
 	ContinueLast bool
 	Continue     string
+	Fork         string
 	Title        string
 	Show         string
 	ShowLast     bool
+	ShowTurn     int
+	ShowTail     int
 
 	CacheReadFromID, CacheWriteToID, CacheWriteToTitle string
 }
@@ -141,6 +168,18 @@ type AutoCoder struct {
 	DesignModel  string   `yaml:"design-model" env:"DESIGN_MODEL"`
 	CodingModel  string   `yaml:"coding-model" env:"CODING_MODEL"`
 	CodingFences []string `yaml:"coding-fences" env:"CODING_FENCES"`
+	// MaxReflections limits how often failed edits are sent back to the model.
+	MaxReflections int `yaml:"max-reflections" env:"MAX_REFLECTIONS"`
+	// LintCmd and TestCmd are run after edits are applied, their failures are sent back to the model.
+	LintCmd      string `yaml:"lint-cmd" env:"LINT_CMD"`
+	TestCmd      string `yaml:"test-cmd" env:"TEST_CMD"`
+	MaxFixRounds int    `yaml:"max-fix-rounds" env:"MAX_FIX_ROUNDS"`
+	// MapTokens is the token budget of the repository outline added to the prompts.
+	MapTokens int `yaml:"map-tokens" env:"MAP_TOKENS"`
+	// SummaryModel summarizes files that don't fit the context window, an outline is used when empty.
+	SummaryModel string `yaml:"summary-model" env:"SUMMARY_MODEL"`
+	// HistoryTokens is the token budget of the chat history replayed with every request.
+	HistoryTokens int `yaml:"history-tokens" env:"HISTORY_TOKENS"`
 }
 
 func (a AutoCoder) GetDefaultFences() []string {
@@ -150,6 +189,44 @@ func (a AutoCoder) GetDefaultFences() []string {
 	return []string{}
 }
 
+// withDefault resolves a configured limit: zero means the default is used and a negative
+// value disables the feature, which is reported as zero.
+func withDefault[T int | float64](value, def T) T {
+	switch {
+	case value == 0:
+		return def
+	case value < 0:
+		return 0
+	default:
+		return value
+	}
+}
+
+// GetCompactThreshold returns the fraction of the model input budget filled before compaction.
+func (c *Config) GetCompactThreshold() float64 {
+	return withDefault(c.CompactThreshold, defaultCompactThreshold)
+}
+
+// GetMaxFixRounds returns the number of rounds the model gets to fix lint or test failures.
+func (a AutoCoder) GetMaxFixRounds() int {
+	return withDefault(a.MaxFixRounds, defaultMaxFixRounds)
+}
+
+// GetMaxReflections returns the number of reflection rounds for failed edits.
+func (a AutoCoder) GetMaxReflections() int {
+	return withDefault(a.MaxReflections, defaultMaxReflections)
+}
+
+// GetMapTokens returns the token budget of the repository map.
+func (a AutoCoder) GetMapTokens() int {
+	return withDefault(a.MapTokens, defaultMapTokens)
+}
+
+// GetHistoryTokens returns the token budget of the replayed chat history.
+func (a AutoCoder) GetHistoryTokens() int {
+	return withDefault(a.HistoryTokens, defaultHistoryTokens)
+}
+
 // Model represents the LLM model used in the API call.
 type Model struct {
 	Name     string
@@ -157,6 +234,14 @@ type Model struct {
 	MaxChars int      `yaml:"max-input-chars"`
 	Aliases  []string `yaml:"aliases"`
 	Fallback string   `yaml:"fallback"`
+	// InputPrice and OutputPrice are in USD per million prompt and completion tokens
+	InputPrice  float64 `yaml:"input-price"`
+	OutputPrice float64 `yaml:"output-price"`
+}
+
+// Cost returns the price in USD of the given token usage, 0 when the model has no pricing.
+func (m Model) Cost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*m.InputPrice + float64(completionTokens)*m.OutputPrice) / 1e6
 }
 
 // API represents an API endpoint and its models.
@@ -251,7 +336,7 @@ func (c *Config) GetModel(name string) (model Model, err error) {
 			return model, errbook.Wrap(
 				fmt.Sprintf(
 					"model %s is not in the settings file.",
-					console.StderrStyles().InlineCode.Render(c.Model),
+					console.StderrStyles().InlineCode.Render(name),
 				),
 				errbook.NewUserErrorf(
 					"Please specify an API endpoint with %s or configure the model in the settings: %s",
@@ -260,7 +345,7 @@ func (c *Config) GetModel(name string) (model Model, err error) {
 				),
 			)
 		}
-		mod.Name = c.Model
+		mod.Name = name
 		mod.API = c.API
 		mod.MaxChars = c.MaxInputChars
 	}
@@ -23,4 +23,18 @@ func TestConfig(t *testing.T) {
 			"json":     "as json",
 		}), cfg.FormatText)
 	})
+	t.Run("max reflections", func(t *testing.T) {
+		var cfg Config
+		require.NoError(t, yaml.Unmarshal([]byte("auto-coder:\n  max-reflections: 5"), &cfg))
+		require.Equal(t, 5, cfg.AutoCoder.GetMaxReflections())
+		require.Equal(t, defaultMaxReflections, AutoCoder{}.GetMaxReflections())
+		require.Equal(t, 0, AutoCoder{MaxReflections: -1}.GetMaxReflections())
+	})
+	t.Run("compact threshold", func(t *testing.T) {
+		var cfg Config
+		require.NoError(t, yaml.Unmarshal([]byte("compact-threshold: 0.5"), &cfg))
+		require.Equal(t, 0.5, cfg.GetCompactThreshold())
+		require.Equal(t, defaultCompactThreshold, (&Config{}).GetCompactThreshold())
+		require.Zero(t, (&Config{CompactThreshold: -1}).GetCompactThreshold())
+	})
 }
@@ -0,0 +1,252 @@
+// Package repomap builds a compact outline of the symbols defined in a git repository,
+// so the model knows about the files that were not added to the chat.
+package repomap
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxFileSize skips generated or vendored blobs that are too large to be useful.
+	maxFileSize = 512 * 1024
+
+	// charsPerToken is the rough ratio used to turn a token budget into characters.
+	charsPerToken = 4
+)
+
+var identRegexp = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]{2,}`)
+
+// fileEntry is the cached outline of a single file.
+type fileEntry struct {
+	path    string
+	modTime time.Time
+	size    int64
+	symbols []Symbol
+}
+
+// RepoMap extracts and caches the symbols of every tracked file in a repository.
+// Files are only parsed again when their modification time or size changes.
+type RepoMap struct {
+	root      string
+	listFiles func() ([]string, error)
+
+	mu    sync.Mutex
+	files map[string]*fileEntry
+}
+
+type Option func(*RepoMap)
+
+// WithFileLister replaces `git ls-files` as the source of the files to map.
+// The lister must return paths relative to the root.
+func WithFileLister(lister func() ([]string, error)) Option {
+	return func(r *RepoMap) {
+		r.listFiles = lister
+	}
+}
+
+// New creates a repo map for the git repository at root.
+func New(root string, opts ...Option) *RepoMap {
+	r := &RepoMap{
+		root:  root,
+		files: map[string]*fileEntry{},
+	}
+	r.listFiles = r.gitLsFiles
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+func (r *RepoMap) gitLsFiles() ([]string, error) {
+	cmd := exec.Command("git", "ls-files")
+	cmd.Dir = r.root
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, f := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// Refresh brings the cache in line with the repository. Only new and changed files
+// are parsed, files that are no longer tracked are dropped.
+func (r *RepoMap) Refresh() error {
+	paths, err := r.listFiles()
+	if err != nil {
+		return fmt.Errorf("list repository files: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seen := make(map[string]struct{}, len(paths))
+	for _, path := range paths {
+		if !Supported(path) {
+			continue
+		}
+		seen[path] = struct{}{}
+
+		info, err := os.Stat(filepath.Join(r.root, path))
+		if err != nil || info.IsDir() || info.Size() > maxFileSize {
+			delete(r.files, path)
+			continue
+		}
+
+		if cached, ok := r.files[path]; ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+			continue
+		}
+
+		entry := &fileEntry{path: path, modTime: info.ModTime(), size: info.Size()}
+		if src, err := os.ReadFile(filepath.Join(r.root, path)); err == nil {
+			// files that don't parse are still listed, just without symbols
+			entry.symbols, _ = ExtractSymbols(path, src)
+		}
+		r.files[path] = entry
+	}
+
+	for path := range r.files {
+		if _, ok := seen[path]; !ok {
+			delete(r.files, path)
+		}
+	}
+
+	return nil
+}
+
+// FindSymbol returns the Go files declaring the named top-level symbol, sorted by path.
+// Methods are looked up as Type.Method. Call Refresh first to index the repository.
+func (r *RepoMap) FindSymbol(name string) []string {
+	receiver, method, isMethod := strings.Cut(name, ".")
+	if !isMethod {
+		method = name
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var files []string
+	for path, entry := range r.files {
+		if filepath.Ext(path) != ".go" {
+			continue
+		}
+		for _, sym := range entry.symbols {
+			if sym.Name == method && (!isMethod || sym.Receiver == receiver) {
+				files = append(files, path)
+				break
+			}
+		}
+	}
+	sort.Strings(files)
+
+	return files
+}
+
+// Render returns the outline of the repository within maxTokens. Files in chatFiles
+// are left out since their whole content is already in the chat. The remaining files
+// are ranked by how often their path and symbols are mentioned in the mentions text,
+// which usually is the user question plus the content of the chat files.
+func (r *RepoMap) Render(maxTokens int, chatFiles []string, mentions string) string {
+	if maxTokens <= 0 {
+		return ""
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	excluded := make(map[string]struct{}, len(chatFiles))
+	for _, f := range chatFiles {
+		excluded[filepath.ToSlash(f)] = struct{}{}
+	}
+
+	idents := map[string]int{}
+	for _, ident := range identRegexp.FindAllString(mentions, -1) {
+		idents[ident]++
+	}
+	lowerMentions := strings.ToLower(mentions)
+
+	type ranked struct {
+		entry *fileEntry
+		score float64
+	}
+	var candidates []ranked
+	for path, entry := range r.files {
+		if _, ok := excluded[path]; ok {
+			continue
+		}
+		candidates = append(candidates, ranked{entry: entry, score: rankFile(entry, idents, lowerMentions)})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].entry.path < candidates[j].entry.path
+	})
+
+	budget := maxTokens * charsPerToken
+	var sb strings.Builder
+	for _, c := range candidates {
+		block := renderFile(c.entry)
+		if sb.Len()+len(block) > budget {
+			continue
+		}
+		sb.WriteString(block)
+	}
+
+	return sb.String()
+}
+
+// rankFile scores a file by the mentions of its path and its symbols.
+// Shallow files and files with symbols get a small boost, so the map still
+// favors the core of the repository when nothing is mentioned.
+func rankFile(entry *fileEntry, idents map[string]int, lowerMentions string) float64 {
+	score := 0.0
+
+	base := strings.TrimSuffix(filepath.Base(entry.path), filepath.Ext(entry.path))
+	if strings.Contains(lowerMentions, strings.ToLower(entry.path)) {
+		score += 20
+	} else if len(base) > 2 && strings.Contains(lowerMentions, strings.ToLower(base)) {
+		score += 5
+	}
+
+	for _, sym := range entry.symbols {
+		if n, ok := idents[sym.Name]; ok {
+			score += 3 * float64(n)
+		}
+	}
+
+	if len(entry.symbols) > 0 {
+		score += 1
+	}
+	score += 1 / float64(1+strings.Count(entry.path, "/"))
+
+	return score
+}
+
+func renderFile(entry *fileEntry) string {
+	var sb strings.Builder
+	sb.WriteString(entry.path)
+	sb.WriteString(":\n")
+	for _, sym := range entry.symbols {
+		sb.WriteString("│")
+		sb.WriteString(sym.Signature)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,150 @@
+package repomap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepoMap(t *testing.T) {
+	t.Run("go symbols", testGoSymbols)
+	t.Run("regex symbols", testRegexSymbols)
+	t.Run("render ranks mentioned files", testRenderRanking)
+	t.Run("render respects budget", testRenderBudget)
+	t.Run("refresh is incremental", testRefreshIncremental)
+}
+
+const goSource = `package demo
+
+// Engine runs things.
+type Engine struct {
+	name string
+}
+
+type Runner interface {
+	Run() error
+}
+
+const defaultName = "demo"
+
+var _ = 1
+
+func New(name string) *Engine {
+	return &Engine{name: name}
+}
+
+func (e *Engine) Run(ctx context.Context, args ...string) (int, error) {
+	return 0, nil
+}
+`
+
+func testGoSymbols(t *testing.T) {
+	symbols, err := ExtractSymbols("demo.go", []byte(goSource))
+	require.NoError(t, err)
+
+	var sigs []string
+	for _, s := range symbols {
+		sigs = append(sigs, s.Signature)
+	}
+	require.Equal(t, []string{
+		"type Engine struct",
+		"type Runner interface",
+		"const defaultName",
+		"func New(name string) *Engine",
+		"func (e *Engine) Run(ctx context.Context, args ...string) (int, error)",
+	}, sigs)
+	require.Equal(t, 4, symbols[0].Line)
+}
+
+func testRegexSymbols(t *testing.T) {
+	py := "import os\n\nclass Greeter:\n    def hello(self):\n        pass\n\ndef main():\n    pass\n"
+	symbols, err := ExtractSymbols("app.py", []byte(py))
+	require.NoError(t, err)
+	require.Len(t, symbols, 2)
+	require.Equal(t, "Greeter", symbols[0].Name)
+	require.Equal(t, "main", symbols[1].Name)
+
+	ts := "export async function load(id: string) {\n}\nexport const handler = async (req) => {\n}\nexport interface Props {\n}\n"
+	symbols, err = ExtractSymbols("index.ts", []byte(ts))
+	require.NoError(t, err)
+	require.Len(t, symbols, 3)
+	require.Equal(t, "load", symbols[0].Name)
+	require.Equal(t, "handler", symbols[1].Name)
+	require.Equal(t, "Props", symbols[2].Name)
+
+	require.False(t, Supported("README.md"))
+}
+
+func writeRepo(t *testing.T, files map[string]string) (string, []string) {
+	t.Helper()
+
+	root := t.TempDir()
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		paths = append(paths, name)
+	}
+	return root, paths
+}
+
+func testRenderRanking(t *testing.T) {
+	root, paths := writeRepo(t, map[string]string{
+		"a/a.go":        "package a\n\nfunc Alpha() {}\n",
+		"b/b.go":        "package b\n\nfunc Beta() {}\n",
+		"c/c.go":        "package c\n\nfunc Gamma() {}\n",
+		"docs/notes.md": "# notes\n",
+	})
+	rm := New(root, WithFileLister(func() ([]string, error) { return paths, nil }))
+	require.NoError(t, rm.Refresh())
+
+	out := rm.Render(1024, []string{"a/a.go"}, "please change Gamma")
+	require.NotContains(t, out, "a/a.go")
+	require.NotContains(t, out, "notes.md")
+	require.Contains(t, out, "b/b.go:\n│func Beta()\n")
+	require.Less(t, strings.Index(out, "c/c.go"), strings.Index(out, "b/b.go"))
+
+	require.Empty(t, rm.Render(0, nil, ""))
+}
+
+func testRenderBudget(t *testing.T) {
+	root, paths := writeRepo(t, map[string]string{
+		"one.go": "package x\n\nfunc One() {}\n",
+		"two.go": "package x\n\nfunc Two() {}\n",
+	})
+	rm := New(root, WithFileLister(func() ([]string, error) { return paths, nil }))
+	require.NoError(t, rm.Refresh())
+
+	out := rm.Render(6, nil, "Two")
+	require.Equal(t, "two.go:\n│func Two()\n", out)
+}
+
+func testRefreshIncremental(t *testing.T) {
+	root, paths := writeRepo(t, map[string]string{
+		"main.go": "package main\n\nfunc main() {}\n",
+	})
+	lister := func() ([]string, error) { return paths, nil }
+	rm := New(root, WithFileLister(func() ([]string, error) { return lister() }))
+	require.NoError(t, rm.Refresh())
+	first := rm.files["main.go"]
+
+	require.NoError(t, rm.Refresh())
+	require.Same(t, first, rm.files["main.go"])
+
+	path := filepath.Join(root, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\nfunc main() {}\n\nfunc run() {}\n"), 0o600))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+	require.NoError(t, rm.Refresh())
+	require.NotSame(t, first, rm.files["main.go"])
+	require.Len(t, rm.files["main.go"].symbols, 2)
+
+	lister = func() ([]string, error) { return nil, nil }
+	require.NoError(t, rm.Refresh())
+	require.Empty(t, rm.files)
+}
@@ -0,0 +1,222 @@
+package repomap
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Symbol is a top-level definition found in a source file.
+type Symbol struct {
+	// Name is the identifier of the symbol.
+	Name string
+	// Receiver is the receiver type name of Go methods.
+	Receiver string
+	// Signature is the one line outline rendered in the map.
+	Signature string
+	// Line is the 1-based line the symbol is defined on.
+	Line int
+}
+
+// maxSignatureLen bounds the length of a rendered signature.
+const maxSignatureLen = 160
+
+// symbolPatterns holds the regex heuristics used for languages without a parser.
+// Every pattern must capture the symbol name in its last group.
+var symbolPatterns = map[string][]*regexp.Regexp{
+	".py": {
+		regexp.MustCompile(`^(?:async\s+)?(?:def|class)\s+(\w+)`),
+	},
+	".js":  jsPatterns,
+	".jsx": jsPatterns,
+	".ts":  jsPatterns,
+	".tsx": jsPatterns,
+	".mjs": jsPatterns,
+	".java": {
+		regexp.MustCompile(`^(?:public\s+|protected\s+|private\s+|abstract\s+|final\s+|static\s+)*(?:class|interface|enum|record)\s+(\w+)`),
+		regexp.MustCompile(`^\s{2,4}(?:public|protected|private)\s+(?:static\s+)?(?:final\s+)?[\w<>\[\], ]+\s+(\w+)\s*\(`),
+	},
+	".kt": {
+		regexp.MustCompile(`^(?:(?:public|private|internal|data|sealed|abstract|open)\s+)*(?:class|interface|object|fun)\s+(\w+)`),
+	},
+	".rs": {
+		regexp.MustCompile(`^(?:pub(?:\([\w:]+\))?\s+)?(?:async\s+)?(?:fn|struct|enum|trait|type|mod|const|static)\s+(\w+)`),
+		regexp.MustCompile(`^impl(?:<[^>]*>)?\s+(?:[\w:<>]+\s+for\s+)?([\w:]+)`),
+	},
+	".rb": {
+		regexp.MustCompile(`^(?:class|module|def)\s+([\w.:]+)`),
+	},
+	".php": {
+		regexp.MustCompile(`^(?:abstract\s+|final\s+)?(?:class|interface|trait|function)\s+(\w+)`),
+	},
+	".c":   cPatterns,
+	".h":   cPatterns,
+	".cc":  cPatterns,
+	".cpp": cPatterns,
+	".hpp": cPatterns,
+	".sh": {
+		regexp.MustCompile(`^(?:function\s+)?([\w-]+)\s*\(\)\s*\{`),
+	},
+}
+
+var (
+	jsPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:async\s+)?(?:function\*?|class|interface|type|enum)\s+(\w+)`),
+		regexp.MustCompile(`^(?:export\s+)?(?:const|let|var)\s+(\w+)\s*=\s*(?:async\s+)?(?:\([^)]*\)|\w+)\s*=>`),
+	}
+	cPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`^(?:struct|class|enum|union)\s+(\w+)\s*\{`),
+		regexp.MustCompile(`^(?:static\s+|inline\s+|extern\s+)*[\w:<>\*&]+(?:\s+[\w:<>\*&]+)*\s+\**(\w+)\s*\([^;]*$`),
+	}
+)
+
+// Supported reports whether symbols can be extracted from the file at path.
+func Supported(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".go" {
+		return true
+	}
+	_, ok := symbolPatterns[ext]
+	return ok
+}
+
+// ExtractSymbols returns the top-level symbols defined in the given source.
+// Go files are parsed with go/parser, other languages use regex heuristics.
+func ExtractSymbols(path string, src []byte) ([]Symbol, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".go" {
+		return extractGoSymbols(path, src)
+	}
+	return extractRegexSymbols(symbolPatterns[ext], src), nil
+}
+
+func extractGoSymbols(path string, src []byte) ([]Symbol, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var symbols []Symbol
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			fn := *d
+			fn.Body = nil
+			fn.Doc = nil
+			symbols = append(symbols, Symbol{
+				Name:      d.Name.Name,
+				Receiver:  goReceiverName(d),
+				Signature: renderGoNode(fset, &fn),
+				Line:      fset.Position(d.Pos()).Line,
+			})
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					symbols = append(symbols, Symbol{
+						Name:      s.Name.Name,
+						Signature: "type " + s.Name.Name + " " + goTypeKind(s.Type),
+						Line:      fset.Position(s.Pos()).Line,
+					})
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						if name.Name == "_" {
+							continue
+						}
+						symbols = append(symbols, Symbol{
+							Name:      name.Name,
+							Signature: d.Tok.String() + " " + name.Name,
+							Line:      fset.Position(name.Pos()).Line,
+						})
+					}
+				}
+			}
+		}
+	}
+
+	return symbols, nil
+}
+
+// goReceiverName returns the type name of a method receiver, or "" for functions.
+func goReceiverName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+
+	expr := fn.Recv.List[0].Type
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// goTypeKind describes a type expression without its body.
+func goTypeKind(expr ast.Expr) string {
+	switch expr.(type) {
+	case *ast.StructType:
+		return "struct"
+	case *ast.InterfaceType:
+		return "interface"
+	case *ast.FuncType:
+		return "func"
+	case *ast.MapType:
+		return "map"
+	case *ast.ArrayType:
+		return "slice"
+	case *ast.ChanType:
+		return "chan"
+	default:
+		return renderGoNode(token.NewFileSet(), expr)
+	}
+}
+
+func renderGoNode(fset *token.FileSet, node any) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return truncateSignature(strings.Join(strings.Fields(buf.String()), " "))
+}
+
+func extractRegexSymbols(patterns []*regexp.Regexp, src []byte) []Symbol {
+	var symbols []Symbol
+	for i, line := range strings.Split(string(src), "\n") {
+		for _, re := range patterns {
+			m := re.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			symbols = append(symbols, Symbol{
+				Name:      m[len(m)-1],
+				Signature: truncateSignature(strings.TrimRight(strings.TrimSpace(line), "{:")),
+				Line:      i + 1,
+			})
+			break
+		}
+	}
+	return symbols
+}
+
+func truncateSignature(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= maxSignatureLen {
+		return s
+	}
+	return s[:maxSignatureLen] + "..."
+}
@@ -27,6 +27,15 @@ func PrepareInteractiveCommand(input string) *exec.Cmd {
 	return prepareUnixCommand(input)
 }
 
+// RunShell runs input through the default shell inside dir and returns the
+// combined stdout and stderr output.
+func RunShell(dir, input string) (string, error) {
+	cmd := PrepareInteractiveCommand(input)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
 func PrepareEditSettingsCommand(editor, filename string) *exec.Cmd {
 	switch editor {
 	case "vim":
@@ -2,6 +2,9 @@ package runner
 
 import (
 	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,6 +13,7 @@ import (
 func TestRun(t *testing.T) {
 	t.Run("PrepareUnixEditSettingsCommand", testPrepareUnixEditSettingsCommand)
 	t.Run("PrepareWinEditSettingsCommand", testPrepareWinEditSettingsCommand)
+	t.Run("RunShell", testRunShell)
 }
 
 func testPrepareUnixEditSettingsCommand(t *testing.T) {
@@ -35,3 +39,17 @@ func testPrepareWinEditSettingsCommand(t *testing.T) {
 
 	assert.Equal(t, expectedCmd.Args, cmd.Args, "The command arguments should be the same.")
 }
+
+func testRunShell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("bash is required")
+	}
+
+	dir := t.TempDir()
+	out, err := RunShell(dir, "pwd && echo oops >&2 && exit 3")
+	assert.Error(t, err)
+
+	resolved, _ := filepath.EvalSymlinks(dir)
+	assert.Contains(t, strings.TrimSpace(out), filepath.Base(resolved))
+	assert.Contains(t, out, "oops")
+}
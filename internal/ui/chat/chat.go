@@ -9,6 +9,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 	"unicode"
 
 	"github.com/atotto/clipboard"
@@ -16,6 +17,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
+	"k8s.io/klog/v2"
 
 	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
 
@@ -63,8 +65,13 @@ type Chat struct {
 
 	content      []string    // Buffered content for non-TTY output
 	contentMutex *sync.Mutex // Mutex for thread-safe content access
+
+	titles chan string // Title generated in the background for a new conversation
 }
 
+// titleTimeout limits how long the title model may take to name a conversation
+const titleTimeout = 15 * time.Second
+
 // NewChat creates and initializes a new Chat instance
 // cfg: Application configuration
 // opts: Optional parameters for customizing chat behavior
@@ -96,7 +103,11 @@ func NewChat(cfg *options.Config, opts ...Option) *Chat {
 // Run starts the chat application and handles the main execution loop
 // Returns error if the program fails to start or encounters an error during execution
 func (c *Chat) Run() error {
-	if _, err := tea.NewProgram(c).Run(); err != nil {
+	if c.config.Show != "" || c.config.ShowLast {
+		return c.showTranscript()
+	}
+
+	if _, err := tea.NewProgram(c, tea.WithOutput(c.opts.output)).Run(); err != nil {
 		return errbook.Wrap("Couldn't start Bubble Tea program.", err)
 	}
 
@@ -106,13 +117,13 @@ func (c *Chat) Run() error {
 
 	if term.IsOutputTTY() {
 		if c.config.Raw && c.output != "" {
-			fmt.Print(c.output)
+			_, _ = fmt.Fprint(c.opts.output, c.output)
 		} else {
 			switch {
 			case c.glamOutput != "":
-				fmt.Print(c.glamOutput)
+				_, _ = fmt.Fprint(c.opts.output, c.glamOutput)
 			case c.output != "":
-				fmt.Print(c.output)
+				_, _ = fmt.Fprint(c.opts.output, c.output)
 			}
 		}
 	}
@@ -126,10 +137,6 @@ func (c *Chat) Run() error {
 		}
 	}
 
-	if c.config.Show != "" || c.config.ShowLast {
-		return nil
-	}
-
 	if c.config.CacheWriteToID != "" {
 		return c.saveConversation()
 	}
@@ -173,13 +180,13 @@ func (c *Chat) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return c, c.quit
 		}
 		c.state = requestState
-		if c.config.Show != "" || c.config.ShowLast {
-			cmds = append(cmds, c.readFromCacheCmd())
-		} else {
-			cmds = append(cmds, c.startCompletionCmd(msg.Messages), c.awaitChatCompletedCmd())
-		}
+		cmds = append(cmds, c.startCompletionCmd(msg.Messages), c.awaitChatCompletedCmd())
 
 	case ai.StreamCompletionOutput:
+		if status := msg.GetStatus(); status != "" && !c.config.Quiet {
+			// the running animation keeps ticking, only its label changes
+			c.anim = console.NewAnim(c.config.Fanciness, status, c.renderer, c.styles)
+		}
 		if msg.GetContent() != "" {
 			c.appendToOutput(msg.GetContent())
 			c.state = responseState
@@ -187,6 +194,7 @@ func (c *Chat) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.IsLast() {
 			c.state = doneState
 			c.TokenUsage = msg.GetUsage()
+			c.startTitle()
 			return c, c.quit
 		}
 		cmds = append(cmds, c.awaitChatCompletedCmd())
@@ -258,14 +266,14 @@ func (c *Chat) View() string {
 		}
 
 		c.contentMutex.Lock()
-		for _, c := range c.content {
-			fmt.Print(c)
+		for _, content := range c.content {
+			_, _ = fmt.Fprint(c.opts.output, content)
 		}
 		c.content = []string{}
 		c.contentMutex.Unlock()
 	case doneState:
 		if !term.IsOutputTTY() {
-			fmt.Printf("\n")
+			_, _ = fmt.Fprintln(c.opts.output)
 		}
 		return ""
 	}
@@ -341,24 +349,14 @@ func (c *Chat) startCliCmd() tea.Cmd {
 	}
 }
 
-// readFromCacheCmd creates a command to read messages from the conversation cache
-// Returns a command that will fetch cached messages
-func (c *Chat) readFromCacheCmd() tea.Cmd {
-	return func() tea.Msg {
-		convoStore := c.engine.GetConvoStore()
-		messages, err := convoStore.Messages(context.Background(), c.config.CacheReadFromID)
-		if err != nil {
-			return err
-		}
-		lastContent := ""
-		if len(messages) > 0 {
-			lastContent = messages[len(messages)-1].GetContent()
-		}
-		return ai.StreamCompletionOutput{
-			Content: lastContent,
-			Last:    true,
-		}
+// showTranscript shows the whole conversation set with --show or --show-last
+// Returns error if the conversation can't be read
+func (c *Chat) showTranscript() error {
+	transcript, err := convo.GetShowTranscript(context.Background(), c.config, c.engine.GetConvoStore())
+	if err != nil {
+		return err
 	}
+	return ShowTranscript(c.config, c.opts.output, transcript)
 }
 
 // readStdinCmd reads input from stdin and creates a completion input message
@@ -424,12 +422,21 @@ func (c *Chat) saveConversation() error {
 	writeToID := c.config.CacheWriteToID
 	writeToTitle := strings.TrimSpace(c.config.CacheWriteToTitle)
 
+	// keep the title of a continued conversation, it may have been renamed
+	pendingTitle := false
 	if convo.MatchSha1(writeToTitle) || writeToTitle == "" {
-		messages, err := convoStore.Messages(ctx, writeToID)
-		if err != nil {
-			return err
+		if existing, err := convoStore.GetConversation(ctx, writeToID); err == nil {
+			writeToTitle = existing.Title
+		} else if title, ready := c.readyTitle(); title != "" {
+			writeToTitle = title
+		} else {
+			pendingTitle = !ready
+			messages, err := convoStore.Messages(ctx, writeToID)
+			if err != nil {
+				return err
+			}
+			writeToTitle = firstLine(lastPrompt(messages))
 		}
-		writeToTitle = firstLine(lastPrompt(messages))
 	}
 
 	if writeToTitle == "" {
@@ -454,6 +461,16 @@ func (c *Chat) saveConversation() error {
 		), err)
 	}
 
+	if pendingTitle {
+		renamed := c.renameWhenTitled(writeToID)
+		// one-shot commands exit when Run returns, which would drop the title
+		if c.opts.waitForTitle {
+			if title := <-renamed; title != "" {
+				writeToTitle = title
+			}
+		}
+	}
+
 	if !c.config.Quiet {
 		content := fmt.Sprintf("\n**Conversation successfully saved:** `%s` `%s`\n", c.config.CacheWriteToID[:convo.Sha1short], writeToTitle)
 		if c.config.ShowTokenUsages {
@@ -470,6 +487,96 @@ func (c *Chat) saveConversation() error {
 	return nil
 }
 
+// startTitle asks the title model to name a new conversation in the background,
+// the conversation is saved without waiting for it and renamed once it arrives
+func (c *Chat) startTitle() {
+	if c.config.TitleModel == "" || c.config.NoCache || c.config.CacheWriteToID == "" || c.titles != nil {
+		return
+	}
+	if title := strings.TrimSpace(c.config.CacheWriteToTitle); title != "" && !convo.MatchSha1(title) {
+		return
+	}
+
+	// the store is only read here, the title model runs while the conversation is saved
+	messages, err := c.titleMessages(context.Background(), c.config.CacheWriteToID)
+	if err != nil || len(messages) == 0 {
+		if err != nil {
+			klog.V(1).Infof("Couldn't generate a conversation title, using the prompt instead: %v", err)
+		}
+		return
+	}
+
+	c.titles = make(chan string, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), titleTimeout)
+		defer cancel()
+		title, err := c.engine.GenerateTitle(ctx, messages)
+		if err != nil {
+			klog.V(1).Infof("Couldn't generate a conversation title, using the prompt instead: %v", err)
+		}
+		c.titles <- title
+	}()
+}
+
+// titleMessages returns the first exchange of a new conversation to name it after,
+// continued conversations keep their title and get none
+func (c *Chat) titleMessages(ctx context.Context, convoID string) ([]llms.ChatMessage, error) {
+	store := c.engine.GetConvoStore()
+	if exists, err := store.ConversationExists(ctx, convoID); err != nil || exists {
+		return nil, err
+	}
+
+	messages, err := store.Messages(ctx, convoID)
+	if err != nil {
+		return nil, err
+	}
+	// the reply may not be stored yet when the output is complete
+	if n := len(messages); n == 0 || messages[n-1].GetType() != llms.ChatMessageTypeAI {
+		messages = append(messages, llms.AIChatMessage{Content: c.output})
+	}
+
+	return messages, nil
+}
+
+// readyTitle returns the title generated in the background without waiting for it,
+// ready is false while the title model is still running
+func (c *Chat) readyTitle() (title string, ready bool) {
+	if c.titles == nil {
+		return "", true
+	}
+	select {
+	case title = <-c.titles:
+		return title, true
+	default:
+		return "", false
+	}
+}
+
+// renameWhenTitled renames the saved conversation once the title model answers within
+// titleTimeout. The returned channel yields the applied title, empty when there is none.
+func (c *Chat) renameWhenTitled(convoID string) <-chan string {
+	renamed := make(chan string, 1)
+	titles := c.titles
+	go func() {
+		defer close(renamed)
+
+		var title string
+		select {
+		case title = <-titles:
+		case <-time.After(titleTimeout):
+		}
+		if title == "" {
+			return
+		}
+		if err := c.engine.GetConvoStore().RenameConversation(context.Background(), convoID, title); err != nil {
+			klog.V(1).Infof("Couldn't rename conversation %s to its generated title: %v", convoID, err)
+			return
+		}
+		renamed <- title
+	}()
+	return renamed
+}
+
 // lastPrompt finds the last human prompt in a list of chat messages
 // messages: The list of chat messages to search
 // Returns the content of the last human message
@@ -0,0 +1,77 @@
+package chat
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
+
+	"github.com/coding-hui/ai-terminal/internal/ai"
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/convo/sqlite3"
+	"github.com/coding-hui/ai-terminal/internal/options"
+)
+
+func TestSaveConversation(t *testing.T) {
+	t.Run("rename when titled", testRenameWhenTitled)
+}
+
+func testRenameWhenTitled(t *testing.T) {
+	// the title model answers after the conversation was saved
+	saved := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-saved
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Parser panic on empty input"},"finish_reason":"stop"}]}`)
+	}))
+	defer server.Close()
+
+	convoID := convo.NewConversationID()
+	cfg := &options.Config{
+		Model:          "gpt-4o",
+		API:            ai.ModelTypeOpenAI,
+		TitleModel:     "gpt-4o",
+		Quiet:          true,
+		CacheWriteToID: convoID,
+		Models:         map[string]options.Model{"gpt-4o": {Name: "gpt-4o", API: ai.ModelTypeOpenAI, MaxChars: 1000}},
+		APIs:           options.APIs{{Name: ai.ModelTypeOpenAI, APIKey: "test", BaseURL: server.URL}},
+	}
+	store, err := sqlite3.NewSqliteStore(sqlite3.WithDBAddress(filepath.Join(t.TempDir(), "convo.db")))
+	require.NoError(t, err)
+	defer store.Close() //nolint:errcheck
+	engine, err := ai.New(ai.WithConfig(cfg), ai.WithStore(store))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, store.AddMessage(ctx, convoID, llms.HumanChatMessage{Content: "why does the parser panic?"}))
+
+	c := NewChat(cfg, WithEngine(engine), WithWaitForTitle(true))
+	c.output = "the lexer returns a nil token"
+	c.startTitle()
+
+	go func() {
+		// release the title model once the conversation is stored under the prompt
+		for {
+			if conversation, err := store.GetConversation(ctx, convoID); err == nil {
+				assert.Equal(t, "why does the parser panic?", conversation.Title)
+				close(saved)
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	require.NoError(t, c.saveConversation())
+
+	conversation, err := store.GetConversation(ctx, convoID)
+	require.NoError(t, err)
+	assert.Equal(t, "Parser panic on empty input", conversation.Title)
+}
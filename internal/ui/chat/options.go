@@ -2,6 +2,8 @@ package chat
 
 import (
 	"context"
+	"io"
+	"os"
 
 	"github.com/charmbracelet/lipgloss"
 
@@ -19,6 +21,8 @@ type Options struct {
 	renderer        *lipgloss.Renderer
 	wordWrap        int
 	copyToClipboard bool
+	waitForTitle    bool
+	output          io.Writer
 
 	engine *ai.Engine
 
@@ -82,11 +86,27 @@ func WithCopyToClipboard(copy bool) Option {
 	}
 }
 
+// WithWaitForTitle makes Run wait for the generated title of a new conversation,
+// for one-shot commands that exit right after it returns.
+func WithWaitForTitle(wait bool) Option {
+	return func(o *Options) {
+		o.waitForTitle = wait
+	}
+}
+
+// WithOutput sets the writer the chat is rendered to, stdout by default.
+func WithOutput(output io.Writer) Option {
+	return func(o *Options) {
+		o.output = output
+	}
+}
+
 func NewOptions(opts ...Option) *Options {
 	o := &Options{
 		runMode:    ui.CliMode,
 		promptMode: ui.ChatPromptMode,
 		renderer:   console.StderrRenderer(),
+		output:     os.Stdout,
 	}
 
 	for _, opt := range opts {
@@ -0,0 +1,131 @@
+package chat
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/options"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+	"github.com/coding-hui/ai-terminal/internal/util/term"
+)
+
+// transcriptQuitKeys close the transcript viewer.
+var transcriptQuitKeys = key.NewBinding(key.WithKeys("q", "esc", "ctrl+c"))
+
+// TranscriptViewer is a scrollable viewport showing a conversation transcript.
+type TranscriptViewer struct {
+	title    string
+	content  string
+	viewport viewport.Model
+	styles   console.Styles
+	ready    bool
+}
+
+// ShowTranscript writes the transcript to out. The transcript is rendered as Markdown
+// when out is a terminal, in a scrollable viewport if it is taller than the terminal,
+// and as plain text when the output is piped or cfg.Raw is set.
+func ShowTranscript(cfg *options.Config, out io.Writer, transcript *convo.Transcript) error {
+	if cfg.Raw || !term.IsOutputTTY() {
+		_, err := io.WriteString(out, transcript.Text())
+		return err
+	}
+
+	styles := console.StdoutStyles()
+	content := RenderTranscript(transcript, cfg.WordWrap, styles)
+
+	size := term.GetSize(os.Stdout.Fd())
+	if size == nil || lipgloss.Height(content) < int(size.Height) || !term.IsInputTTY() {
+		_, err := io.WriteString(out, content)
+		return err
+	}
+
+	viewer := &TranscriptViewer{title: transcript.Title, content: content, styles: styles}
+	if _, err := tea.NewProgram(viewer, tea.WithAltScreen(), tea.WithMouseCellMotion()).Run(); err != nil {
+		return errbook.Wrap("Couldn't start Bubble Tea program.", err)
+	}
+	return nil
+}
+
+// RenderTranscript renders every message of the transcript as Markdown under a header
+// naming its role.
+func RenderTranscript(transcript *convo.Transcript, wordWrap int, styles console.Styles) string {
+	glam, err := glamour.NewTermRenderer(
+		glamour.WithEnvironmentConfig(),
+		glamour.WithWordWrap(wordWrap),
+	)
+
+	var b strings.Builder
+	for _, m := range transcript.Messages {
+		header := styles.AssistantRole.Render(m.Heading())
+		if m.Role == "human" {
+			header = styles.UserRole.Render(m.Heading())
+		}
+		if details := m.Details(); details != "" {
+			header += " " + styles.Comment.Render(details)
+		}
+		b.WriteString("\n  " + header + "\n")
+
+		content := strings.TrimSpace(m.Content)
+		if err == nil {
+			if rendered, renderErr := glam.Render(content); renderErr == nil {
+				content = strings.TrimRight(rendered, "\n")
+			}
+		}
+		b.WriteString(strings.ReplaceAll(content, "\t", strings.Repeat(" ", tabWidth)))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Init is part of the Bubble Tea framework, the viewer waits for the window size.
+func (v *TranscriptViewer) Init() tea.Cmd {
+	return nil
+}
+
+// Update scrolls the viewport and closes the viewer with q, esc or ctrl+c.
+func (v *TranscriptViewer) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if key.Matches(msg, transcriptQuitKeys) {
+			return v, tea.Quit
+		}
+
+	case tea.WindowSizeMsg:
+		height := msg.Height - lipgloss.Height(v.footer())
+		if !v.ready {
+			v.viewport = viewport.New(msg.Width, height)
+			v.viewport.SetContent(v.content)
+			v.ready = true
+		} else {
+			v.viewport.Width, v.viewport.Height = msg.Width, height
+		}
+	}
+
+	var cmd tea.Cmd
+	v.viewport, cmd = v.viewport.Update(msg)
+	return v, cmd
+}
+
+// View renders the viewport above a footer with the title and the scroll position.
+func (v *TranscriptViewer) View() string {
+	if !v.ready {
+		return ""
+	}
+	return v.viewport.View() + "\n" + v.footer()
+}
+
+func (v *TranscriptViewer) footer() string {
+	return v.styles.Comment.Render(fmt.Sprintf(
+		"  %s · %3.f%% · ↑/↓ scroll · q quit", v.title, v.viewport.ScrollPercent()*100,
+	))
+}
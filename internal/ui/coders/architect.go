@@ -0,0 +1,88 @@
+package coders
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
+
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/ui/chat"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+)
+
+// architect runs the design prompt on the design model and feeds the resulting plan
+// to the edit prompt on the coding model, reporting the token usage of each phase.
+func (c *CommandExecutor) architect(ctx context.Context, input string) error {
+	if strings.TrimSpace(input) == "" {
+		return errbook.New("Please describe the change, e.g. /architect \"add a --dry-run flag\"")
+	}
+
+	designEngine, err := c.coder.designEngine()
+	if err != nil {
+		return errbook.Wrap("Failed to initialize the design model", err)
+	}
+	codingEngine, err := c.coder.codingEngine()
+	if err != nil {
+		return errbook.Wrap("Failed to initialize the coding model", err)
+	}
+
+	messages, err := c.prepareDesignCompletionMessages(ctx, input)
+	if err != nil {
+		return errbook.Wrap("Failed to prepare design completion messages", err)
+	}
+
+	if c.flag(FlagVerbose) {
+		return console.RenderChatMessages(messages)
+	}
+
+	console.RenderStep("Designing the change with %s", designEngine.GetModel().Name)
+
+	designChat := chat.NewChat(c.coder.cfg,
+		chat.WithContext(ctx),
+		chat.WithMessages(messages),
+		chat.WithEngine(designEngine),
+		chat.WithOutput(c.coder.messageOut()),
+	)
+	if err := designChat.Run(); err != nil {
+		return err
+	}
+
+	c.coder.addUsage(designChat.TokenUsage)
+
+	plan := strings.TrimSpace(designChat.GetOutput())
+	if plan == "" {
+		return errbook.New("The design model returned an empty plan")
+	}
+
+	c.coder.recordTurn(ctx, "/design", input, plan)
+
+	console.RenderStep("Implementing the plan with %s", codingEngine.GetModel().Name)
+
+	before := c.coder.usage
+	codingErr := c.coding(ctx, fmt.Sprintf(architectEditorPrompt, input, plan))
+
+	renderPhaseUsage("design", designEngine.GetModel().Name, designChat.TokenUsage)
+	renderPhaseUsage("coding", codingEngine.GetModel().Name, usageSince(c.coder.usage, before))
+
+	return codingErr
+}
+
+// renderPhaseUsage prints the token usage of one phase of a multi-model run.
+func renderPhaseUsage(phase, model string, usage llms.Usage) {
+	console.RenderComment(
+		"%s phase (%s): %d prompt + %d completion = %d tokens in %.2fs",
+		phase, model, usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens, usage.TotalTime.Seconds(),
+	)
+}
+
+// usageSince returns the usage added to the session usage since the before snapshot.
+func usageSince(usage, before llms.Usage) llms.Usage {
+	return llms.Usage{
+		PromptTokens:     usage.PromptTokens - before.PromptTokens,
+		CompletionTokens: usage.CompletionTokens - before.CompletionTokens,
+		TotalTokens:      usage.TotalTokens - before.TotalTokens,
+		TotalTime:        usage.TotalTime - before.TotalTime,
+	}
+}
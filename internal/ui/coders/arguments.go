@@ -0,0 +1,323 @@
+package coders
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/caarlos0/go-shellwords"
+	"github.com/elk-language/go-prompt"
+	"github.com/spf13/pflag"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+)
+
+// argMode is how the input after a command name is turned into its arguments.
+type argMode int
+
+const (
+	// argsText keeps the input verbatim, only flags in front of it are parsed.
+	// Prompts and shell commands use it, so quotes and -- inside them are kept.
+	argsText argMode = iota
+	// argsWords splits the input into shell words, flags may appear anywhere.
+	argsWords
+)
+
+// argCompleter returns the suggestions for the argument at index of a command.
+type argCompleter func(c *CommandExecutor, index int) []prompt.Suggest
+
+// commandSpec describes the arguments and flags of a REPL command.
+type commandSpec struct {
+	name  string
+	args  string
+	desc  string
+	mode  argMode
+	flags func(fs *pflag.FlagSet)
+	// complete suggests the values of the arguments, nil when they can't be completed
+	complete argCompleter
+}
+
+// builtinCommands are the built-in REPL commands in the order of /help.
+var builtinCommands = []*commandSpec{
+	{name: "/add", args: "<file patterns/URLs>", desc: "Add local files or URLs to chat context", mode: argsWords, complete: completeRepoFiles},
+	{name: "/read-only", args: "<file patterns/URLs>", desc: "Add files or URLs for reference only, the AI may not edit them", mode: argsWords, complete: completeRepoFiles},
+	{name: "/list", desc: "List files in chat context", mode: argsWords},
+	{name: "/remove", args: "<patterns>", desc: "Remove files from context", mode: argsWords, complete: completeLoadedFiles},
+	{name: "/ask", args: "<question>", desc: "Ask about code in context", flags: verboseFlag},
+	{name: "/drop", desc: "Clear all files from context", mode: argsWords},
+	{name: "/clear", desc: "Clear the chat history, files stay in context", mode: argsWords},
+	{name: "/history", desc: "Show the chat history replayed with requests", mode: argsWords},
+	{name: "/design", args: "<requirements>", desc: "Design system architecture and components", flags: verboseFlag},
+	{name: "/coding", args: "<instructions>", desc: "Code with AI (use for details)", flags: verboseFlag},
+	{name: "/architect", args: "<change description>", desc: "Design with the design model, then edit with the coding model", flags: verboseFlag},
+	{name: "/lint", args: "[command]", desc: "Run the lint command and let AI fix failures"},
+	{name: "/test", args: "[command]", desc: "Run the test command and let AI fix failures"},
+	{name: "/run", args: "<shell command>", desc: "Run a shell command and add its output to the next prompt"},
+	{name: "/edit", args: "[command]", desc: "Write a multi-line input in $EDITOR, sent with the command (/ask by default)", mode: argsWords},
+	{name: "/tokens", args: "[question]", desc: "Show how the files in context fit into the model's input budget"},
+	{name: "/commit", desc: "Commit changes to version control", mode: argsWords},
+	{name: "/undo", desc: "Revert last code changes", mode: argsWords},
+	{name: "/merge", args: "[--ff]", desc: "Squash (or fast-forward) the sandbox branch into the original branch", mode: argsWords, flags: func(fs *pflag.FlagSet) {
+		fs.Bool(FlagFastForward, false, "Fast-forward the original branch instead of squashing the sandbox commits")
+	}},
+	{name: "/discard", desc: "Drop the sandbox branch and all of its changes", mode: argsWords},
+	{name: "/diff", desc: "Show diffs of context files", mode: argsWords},
+	{name: "/apply", args: "<edit blocks>", desc: "Apply AI-generated code edits"},
+	{name: "/chat-model", args: "<model> <api>", desc: "Switch to a new chat mode", mode: argsWords, complete: completeModelAndAPI},
+	{name: "/format", args: "<diff|whole|udiff>", desc: "Switch the edit format used by /coding", mode: argsWords, complete: completeEditFormats},
+	{name: "/exit", desc: "Exit the terminal", mode: argsWords},
+	{name: "/help", args: "[command]", desc: "Show this help message, or the help of a command", mode: argsWords},
+}
+
+func init() {
+	// set here, completing the commands refers back to builtinCommands
+	for _, spec := range builtinCommands {
+		if spec.name == "/help" || spec.name == "/edit" {
+			spec.complete = completeCommands
+		}
+	}
+}
+
+func verboseFlag(fs *pflag.FlagSet) {
+	fs.Bool(FlagVerbose, false, "Print the messages sent to the model instead of sending them")
+}
+
+// usage returns the command line of the command with its arguments.
+func (s *commandSpec) usage() string {
+	if s.args == "" {
+		return s.name
+	}
+	return s.name + " " + s.args
+}
+
+// flagSet returns a new flag set with the flags of the command.
+func (s *commandSpec) flagSet() *pflag.FlagSet {
+	fs := pflag.NewFlagSet(s.name, pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Usage = func() {}
+	fs.BoolP("help", "h", false, "Show help for this command")
+	if s.flags != nil {
+		s.flags(fs)
+	}
+	return fs
+}
+
+// parse parses the flags of the input into fs and returns the input of the handler.
+// For shell word commands the remaining arguments are quoted again, so the handler
+// gets them back unchanged with splitArgs.
+func (s *commandSpec) parse(fs *pflag.FlagSet, input string) (string, error) {
+	if s.mode == argsWords {
+		args, err := splitArgs(input)
+		if err != nil {
+			return "", err
+		}
+		if err := fs.Parse(args); err != nil {
+			return "", err
+		}
+		return quoteArgs(fs.Args()), nil
+	}
+
+	flags, rest := leadingFlags(fs, input)
+	if err := fs.Parse(flags); err != nil {
+		return "", err
+	}
+	return rest, nil
+}
+
+// leadingFlags splits the flags in front of a free text input from the text.
+// A -- ends the flags, so text starting with a dash can be passed as well.
+func leadingFlags(fs *pflag.FlagSet, input string) (flags []string, rest string) {
+	rest = strings.TrimSpace(input)
+	for strings.HasPrefix(rest, "-") {
+		word, remaining := cutWord(rest)
+		if word == "-" {
+			// a list item, not a flag
+			break
+		}
+		rest = remaining
+		if word == "--" {
+			break
+		}
+		flags = append(flags, word)
+
+		// a flag with a separate value takes the next word along
+		name := strings.TrimLeft(word, "-")
+		if strings.Contains(name, "=") {
+			continue
+		}
+		flag := fs.Lookup(name)
+		if flag == nil && len(name) == 1 {
+			flag = fs.ShorthandLookup(name)
+		}
+		if flag != nil && flag.NoOptDefVal == "" && rest != "" {
+			var value string
+			value, rest = cutWord(rest)
+			flags = append(flags, value)
+		}
+	}
+	return flags, rest
+}
+
+// cutWord splits the first word from the input, the rest is trimmed.
+func cutWord(input string) (word, rest string) {
+	input = strings.TrimSpace(input)
+	i := strings.IndexFunc(input, unicode.IsSpace)
+	if i < 0 {
+		return input, ""
+	}
+	return input[:i], strings.TrimSpace(input[i:])
+}
+
+// splitArgs splits the input into shell words, honoring quotes and escapes.
+func splitArgs(input string) ([]string, error) {
+	parser := shellwords.NewParser()
+	args, err := parser.Parse(input)
+	if err != nil {
+		return nil, errbook.Wrap("Failed to parse the arguments, check the quotes", err)
+	}
+	if parser.Position >= 0 {
+		return nil, errbook.New("Unexpected %q in the arguments, quote it to pass it along", input[parser.Position:parser.Position+1])
+	}
+	return args, nil
+}
+
+// quoteArgs joins the arguments into a line that splitArgs splits into the same arguments.
+func quoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quoteArg(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func quoteArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\`$;&|<>()") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// commandSpec returns the spec of a registered command. Commands without one,
+// like the plugin commands, take free text.
+func (c *CommandExecutor) commandSpec(name string) *commandSpec {
+	for _, spec := range builtinCommands {
+		if spec.name == name {
+			return spec
+		}
+	}
+	for _, plugin := range c.plugins {
+		if "/"+plugin.Name == name {
+			return plugin.spec()
+		}
+	}
+	return &commandSpec{name: name}
+}
+
+// parseArgs parses the flags of the command into c.flags and returns the input of its handler.
+// help is true when the help of the command was asked for with --help.
+func (c *CommandExecutor) parseArgs(spec *commandSpec, input string) (args string, help bool, err error) {
+	fs := spec.flagSet()
+	c.flags = fs
+
+	args, err = spec.parse(fs, input)
+	if err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return "", true, nil
+		}
+		return "", false, errbook.Wrap(fmt.Sprintf("Invalid arguments, see %s --help", spec.name), err)
+	}
+
+	return args, c.flag("help"), nil
+}
+
+// flag reports whether the boolean flag name is set for the running command.
+func (c *CommandExecutor) flag(name string) bool {
+	if c.flags == nil {
+		return false
+	}
+	value, err := c.flags.GetBool(name)
+	return err == nil && value
+}
+
+// commandHelp renders the usage, description and flags of a command.
+func (c *CommandExecutor) commandHelp(spec *commandSpec) {
+	styles := console.StdoutStyles()
+	console.Render("Usage: %s", styles.Flag.Render(spec.usage()))
+	if spec.desc != "" {
+		console.Render("\n%s", spec.desc)
+	}
+	console.Render("\nFlags:\n%s", styles.FlagDesc.Render(strings.TrimRight(spec.flagSet().FlagUsages(), "\n")))
+}
+
+func completeRepoFiles(c *CommandExecutor, _ int) []prompt.Suggest {
+	files, _ := c.coder.repo.ListAllFiles()
+	suggestions := make([]prompt.Suggest, 0, len(files))
+	for _, file := range files {
+		suggestions = append(suggestions, prompt.Suggest{Text: quoteArg(file)})
+	}
+	return suggestions
+}
+
+func completeLoadedFiles(c *CommandExecutor, _ int) []prompt.Suggest {
+	var suggestions []prompt.Suggest
+	for _, lc := range c.coder.loadedContexts {
+		if lc.Type != convo.ContentTypeFile {
+			continue
+		}
+		suggestions = append(suggestions, prompt.Suggest{Text: quoteArg(c.coder.relPath(lc.FilePath)), Description: string(lc.Mode)})
+	}
+	return suggestions
+}
+
+func completeModelAndAPI(c *CommandExecutor, index int) []prompt.Suggest {
+	var suggestions []prompt.Suggest
+	switch index {
+	case 0:
+		for name, model := range c.coder.cfg.Models {
+			suggestions = append(suggestions, prompt.Suggest{Text: name, Description: model.API})
+		}
+	case 1:
+		for _, api := range c.coder.cfg.APIs {
+			suggestions = append(suggestions, prompt.Suggest{Text: api.Name})
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		return suggestions[i].Text < suggestions[j].Text
+	})
+	return suggestions
+}
+
+func completeEditFormats(_ *CommandExecutor, index int) []prompt.Suggest {
+	if index > 0 {
+		return nil
+	}
+	var suggestions []prompt.Suggest
+	for _, format := range SupportedEditFormats() {
+		suggestions = append(suggestions, prompt.Suggest{Text: format})
+	}
+	return suggestions
+}
+
+func completeCommands(c *CommandExecutor, index int) []prompt.Suggest {
+	if index > 0 {
+		return nil
+	}
+	return c.commandSuggestions()
+}
+
+// commandSuggestions returns the registered commands with their descriptions.
+func (c *CommandExecutor) commandSuggestions() []prompt.Suggest {
+	var suggestions []prompt.Suggest
+	for _, name := range getSupportedCommands() {
+		suggestions = append(suggestions, prompt.Suggest{Text: name, Description: c.commandSpec(name).desc})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		return suggestions[i].Text < suggestions[j].Text
+	})
+	return suggestions
+}
@@ -0,0 +1,161 @@
+package coders
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elk-language/go-prompt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/options"
+)
+
+func TestArguments(t *testing.T) {
+	t.Run("split and quote args", testSplitAndQuoteArgs)
+	t.Run("parse words", testParseWords)
+	t.Run("parse text", testParseText)
+	t.Run("help flag", testHelpFlag)
+	t.Run("execute quoted paths", testExecuteQuotedPaths)
+	t.Run("complete arguments", testCompleteArguments)
+}
+
+func specOf(t *testing.T, name string) *commandSpec {
+	t.Helper()
+	spec := (&CommandExecutor{}).commandSpec(name)
+	require.Equal(t, name, spec.name)
+	return spec
+}
+
+func testSplitAndQuoteArgs(t *testing.T) {
+	args, err := splitArgs(`main.go "docs/my notes.md" it\'s 'a b'`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.go", "docs/my notes.md", "it's", "a b"}, args)
+
+	for _, want := range [][]string{
+		{"plain", "with space", "it's", "", `back\slash`, "$HOME", "a;b"},
+	} {
+		got, err := splitArgs(quoteArgs(want))
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err = splitArgs(`"unterminated`)
+	var aiErr errbook.AiError
+	require.ErrorAs(t, err, &aiErr)
+	assert.Contains(t, aiErr.Reason(), "check the quotes")
+
+	_, err = splitArgs(`a.go | b.go`)
+	assert.ErrorContains(t, err, `Unexpected "|"`)
+}
+
+func testParseWords(t *testing.T) {
+	spec := specOf(t, "/merge")
+	fs := spec.flagSet()
+	input, err := spec.parse(fs, `--ff`)
+	require.NoError(t, err)
+	assert.Empty(t, input)
+	ff, _ := fs.GetBool(FlagFastForward)
+	assert.True(t, ff)
+
+	spec = specOf(t, "/add")
+	fs = spec.flagSet()
+	input, err = spec.parse(fs, `"my file.go" -- --odd-name.go`)
+	require.NoError(t, err)
+	assert.Equal(t, `'my file.go' --odd-name.go`, input)
+
+	_, err = spec.parse(spec.flagSet(), `main.go --verbose`)
+	assert.ErrorContains(t, err, "unknown flag: --verbose")
+}
+
+func testParseText(t *testing.T) {
+	spec := specOf(t, "/coding")
+
+	fs := spec.flagSet()
+	input, err := spec.parse(fs, `--verbose rename the --dry-run flag -- and don't "break" it`)
+	require.NoError(t, err)
+	assert.Equal(t, `rename the --dry-run flag -- and don't "break" it`, input)
+	verbose, _ := fs.GetBool(FlagVerbose)
+	assert.True(t, verbose)
+
+	input, err = spec.parse(spec.flagSet(), "-- --verbose is a flag\nexplain it")
+	require.NoError(t, err)
+	assert.Equal(t, "--verbose is a flag\nexplain it", input)
+
+	input, err = spec.parse(spec.flagSet(), "- add a flag\n- document it")
+	require.NoError(t, err)
+	assert.Equal(t, "- add a flag\n- document it", input)
+
+	_, err = spec.parse(spec.flagSet(), "--nope do it")
+	assert.ErrorContains(t, err, "unknown flag: --nope")
+}
+
+func testHelpFlag(t *testing.T) {
+	c := &CommandExecutor{}
+
+	_, help, err := c.parseArgs(specOf(t, "/add"), "main.go --help")
+	require.NoError(t, err)
+	assert.True(t, help)
+
+	_, help, err = c.parseArgs(specOf(t, "/ask"), "-h")
+	require.NoError(t, err)
+	assert.True(t, help)
+
+	input, help, err := c.parseArgs(specOf(t, "/ask"), "what does --help print?")
+	require.NoError(t, err)
+	assert.False(t, help)
+	assert.Equal(t, "what does --help print?", input)
+}
+
+func testExecuteQuotedPaths(t *testing.T) {
+	coder := newHistoryTestCoder(t, 0)
+	path := filepath.Join(coder.codeBasePath, "my notes.md")
+	require.NoError(t, os.WriteFile(path, []byte("notes\n"), 0o600))
+
+	c, err := NewCommandExecutor(coder)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Execute(context.Background(), `/add "my notes.md"`))
+	require.Len(t, coder.loadedContexts, 1)
+	assert.Equal(t, path, coder.loadedContexts[0].FilePath)
+
+	require.NoError(t, c.Execute(context.Background(), `/remove my\ notes.md`))
+	assert.Empty(t, coder.loadedContexts)
+
+	require.NoError(t, c.Execute(context.Background(), `/remove --help`))
+}
+
+func testCompleteArguments(t *testing.T) {
+	coder := newTestAutoCoder(t, &options.Config{
+		Models: map[string]options.Model{"gpt-4o": {API: "openai"}, "qwen": {API: "ollama"}},
+		APIs:   options.APIs{{Name: "openai"}, {Name: "ollama"}},
+	})
+	coder.loadedContexts = []*convo.LoadContext{
+		{Type: convo.ContentTypeFile, FilePath: filepath.Join(coder.codeBasePath, "main.go"), Mode: convo.ContextModeEditable},
+		{Type: convo.ContentTypeFile, FilePath: filepath.Join(coder.codeBasePath, "my notes.md"), Mode: convo.ContextModeReadOnly},
+		{Type: convo.ContentTypeURL, URL: "https://example.com"},
+	}
+	c := &CommandExecutor{coder: coder}
+
+	assert.Equal(t, []string{"main.go", "'my notes.md'"}, suggestionTexts(completeLoadedFiles(c, 0)))
+	assert.Equal(t, []string{"gpt-4o", "qwen"}, suggestionTexts(completeModelAndAPI(c, 0)))
+	assert.Equal(t, []string{"ollama", "openai"}, suggestionTexts(completeModelAndAPI(c, 1)))
+	assert.Empty(t, completeModelAndAPI(c, 2))
+	assert.Contains(t, suggestionTexts(flagSuggestions(specOf(t, "/merge"))), "--ff")
+
+	assert.Equal(t, 0, argIndex("", ""))
+	assert.Equal(t, 1, argIndex("gpt-4o ", ""))
+	assert.Equal(t, 1, argIndex("--verbose gpt-4o op", "op"))
+}
+
+func suggestionTexts(suggestions []prompt.Suggest) []string {
+	texts := make([]string, 0, len(suggestions))
+	for _, s := range suggestions {
+		texts = append(texts, s.Text)
+	}
+	return texts
+}
@@ -4,16 +4,22 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
+	"io"
+	"path/filepath"
 	"strings"
 
 	"github.com/coding-hui/common/version"
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
+	"github.com/erikgeiser/promptkit/confirmation"
 
 	"github.com/coding-hui/ai-terminal/internal/ai"
 	"github.com/coding-hui/ai-terminal/internal/convo"
 	"github.com/coding-hui/ai-terminal/internal/errbook"
 	"github.com/coding-hui/ai-terminal/internal/git"
 	"github.com/coding-hui/ai-terminal/internal/options"
+	"github.com/coding-hui/ai-terminal/internal/repomap"
 	"github.com/coding-hui/ai-terminal/internal/ui/console"
+	"github.com/coding-hui/ai-terminal/internal/util/genericclioptions"
 )
 
 //go:embed banner.txt
@@ -25,6 +31,28 @@ type AutoCoder struct {
 	loadedContexts       []*convo.LoadContext
 	engine               *ai.Engine
 	store                convo.Store
+	repoMap              *repomap.RepoMap
+	// summaries caches the summaries of files that don't fit the context window by content hash
+	summaries map[string]string
+
+	// usage accumulates the token usage of the responses generated for edits
+	usage llms.Usage
+	// lastReply is the last response generated for edits
+	lastReply string
+	// applied and failed collect the edits of the current run for the run report
+	applied, failed []PartialCodeBlock
+
+	// assumeYes answers every confirmation with yes, so the coder can run without a TTY
+	assumeYes bool
+	// useSandbox runs the session in a worktree on its own branch, sandbox is the active one
+	useSandbox bool
+	sandbox    *sandbox
+	// headless sessions generate responses without the chat UI, so several can run at once
+	headless bool
+	// outputFormat is the format of the run report printed for a prompt run
+	outputFormat string
+	// ioStreams are the streams of the session, Out receives the run report
+	ioStreams genericclioptions.IOStreams
 
 	versionInfo version.Info
 	cfg         *options.Config
@@ -45,6 +73,79 @@ func (a *AutoCoder) deleteContext(ctx context.Context, id uint64) error {
 	return a.store.DeleteContexts(ctx, id)
 }
 
+// messageOut returns the writer for the messages of the session. It is ErrOut when
+// Out is kept for the JSON run report.
+func (a *AutoCoder) messageOut() io.Writer {
+	if a.outputFormat == OutputJSON {
+		return a.ioStreams.ErrOut
+	}
+	return a.ioStreams.Out
+}
+
+// confirm asks the user to confirm, unless every confirmation is answered with yes.
+func (a *AutoCoder) confirm(defaultVal confirmation.Value, format string, args ...any) bool {
+	if a.assumeYes {
+		return true
+	}
+	return console.WaitForUserConfirm(defaultVal, format, args...)
+}
+
+// recordEdits adds the applied and failed edits to the edits of the current run.
+func (a *AutoCoder) recordEdits(applied, failed []PartialCodeBlock) {
+	a.applied = append(a.applied, applied...)
+	a.failed = append(a.failed, failed...)
+}
+
+// designEngine returns the engine for the configured design model, or the default engine.
+func (a *AutoCoder) designEngine() (*ai.Engine, error) {
+	return a.engine.ForModel(a.cfg.AutoCoder.DesignModel)
+}
+
+// codingEngine returns the engine for the configured coding model, or the default engine.
+func (a *AutoCoder) codingEngine() (*ai.Engine, error) {
+	return a.engine.ForModel(a.cfg.AutoCoder.CodingModel)
+}
+
+// addUsage adds the token usage of a generated response to the accumulated usage.
+func (a *AutoCoder) addUsage(usage llms.Usage) {
+	a.usage.PromptTokens += usage.PromptTokens
+	a.usage.CompletionTokens += usage.CompletionTokens
+	a.usage.TotalTokens += usage.TotalTokens
+	a.usage.TotalTime += usage.TotalTime
+}
+
+// renderRepoMap refreshes the repository map and returns the outline of the files
+// that are not in the chat, ranked by their relevance to the question and added files.
+func (a *AutoCoder) renderRepoMap(question, addedFiles string) string {
+	maxTokens := a.cfg.AutoCoder.GetMapTokens()
+	if maxTokens <= 0 || a.codeBasePath == "" {
+		return ""
+	}
+
+	if a.repoMap == nil {
+		a.repoMap = repomap.New(a.codeBasePath)
+	}
+	if err := a.repoMap.Refresh(); err != nil {
+		return ""
+	}
+
+	return a.repoMap.Render(maxTokens, a.chatFiles(), question+"\n"+addedFiles)
+}
+
+// chatFiles returns the paths of the files in the chat relative to the repository root.
+func (a *AutoCoder) chatFiles() []string {
+	chatFiles := make([]string, 0, len(a.loadedContexts))
+	for _, lc := range a.loadedContexts {
+		if lc.Type != convo.ContentTypeFile {
+			continue
+		}
+		if rel, err := filepath.Rel(a.codeBasePath, lc.FilePath); err == nil {
+			chatFiles = append(chatFiles, filepath.ToSlash(rel))
+		}
+	}
+	return chatFiles
+}
+
 func (a *AutoCoder) loadExistingContexts() error {
 	// Get current conversation details
 	details, err := convo.GetCurrentConversationID(context.Background(), a.cfg, a.store)
@@ -72,6 +173,9 @@ func (a *AutoCoder) loadExistingContexts() error {
 }
 
 func (a *AutoCoder) Run() error {
+	previous := console.SetOutput(a.messageOut())
+	defer console.SetOutput(previous)
+
 	codingCmd := strings.TrimSpace(a.prompt) != ""
 	if !codingCmd {
 		a.printWelcome()
@@ -82,19 +186,34 @@ func (a *AutoCoder) Run() error {
 		return err
 	}
 
-	cmdExecutor := NewCommandExecutor(a)
+	if a.useSandbox {
+		if err := a.startSandbox(); err != nil {
+			return err
+		}
+	}
+
+	cmdExecutor, err := NewCommandExecutor(a)
+	if err != nil {
+		return err
+	}
 
 	if codingCmd {
-		cmdExecutor.Executor(fmt.Sprintf("/coding %s", a.prompt))
-		return nil
+		return a.runPrompt(cmdExecutor)
+	}
+
+	history, err := console.LoadHistory(a.historyFile())
+	if err != nil {
+		console.RenderComment("Could not load the input history: %v", err)
+		history, _ = console.LoadHistory("")
 	}
 
-	cmdCompleter := NewCommandCompleter(a.repo)
+	cmdCompleter := NewCommandCompleter(cmdExecutor)
 	p := console.NewPrompt(
 		a.cfg.AutoCoder.PromptPrefix,
 		true,
 		cmdCompleter.Complete,
 		cmdExecutor.Executor,
+		console.WithHistory(history),
 	)
 
 	// Start the interactive REPL (Read-Eval-Print Loop) for command processing
@@ -104,7 +223,7 @@ func (a *AutoCoder) Run() error {
 }
 
 func (a *AutoCoder) printWelcome() {
-	fmt.Println(banner)
+	_, _ = fmt.Fprintln(a.messageOut(), banner)
 	console.RenderComment("")
 	console.RenderComment("Welcome to AutoCoder - Your AI Coding Assistant! (%s) [Model: %s]\n", a.versionInfo.GitVersion, a.cfg.CurrentModel.Name)
 
@@ -1,10 +1,13 @@
 package coders
 
 import (
+	"os"
+
 	"github.com/coding-hui/ai-terminal/internal/ai"
 	"github.com/coding-hui/ai-terminal/internal/convo"
 	"github.com/coding-hui/ai-terminal/internal/git"
 	"github.com/coding-hui/ai-terminal/internal/options"
+	"github.com/coding-hui/ai-terminal/internal/util/genericclioptions"
 
 	"github.com/coding-hui/common/version"
 )
@@ -53,10 +56,51 @@ func WithPrompt(prompt string) AutoCoderOption {
 	}
 }
 
+// WithAssumeYes answers every confirmation with yes.
+func WithAssumeYes(yes bool) AutoCoderOption {
+	return func(a *AutoCoder) {
+		a.assumeYes = yes
+	}
+}
+
+// WithSandbox runs the session in a git worktree on its own ai/<session> branch.
+func WithSandbox(sandbox bool) AutoCoderOption {
+	return func(a *AutoCoder) {
+		a.useSandbox = sandbox
+	}
+}
+
+// WithHeadless generates the responses without the chat UI and answers every confirmation with yes.
+func WithHeadless(headless bool) AutoCoderOption {
+	return func(a *AutoCoder) {
+		a.headless = headless
+		if headless {
+			a.assumeYes = true
+		}
+	}
+}
+
+// WithOutputFormat sets the format of the report printed after a prompt run, text or json.
+func WithOutputFormat(format string) AutoCoderOption {
+	return func(a *AutoCoder) {
+		a.outputFormat = format
+	}
+}
+
+// WithIOStreams sets the streams of the session, the run report is written to Out.
+// The standard streams are used by default.
+func WithIOStreams(ioStreams genericclioptions.IOStreams) AutoCoderOption {
+	return func(a *AutoCoder) {
+		a.ioStreams = ioStreams
+	}
+}
+
 func applyAutoCoderOptions(options ...AutoCoderOption) *AutoCoder {
 	ac := &AutoCoder{
 		versionInfo:    version.Get(),
 		loadedContexts: []*convo.LoadContext{},
+		summaries:      map[string]string{},
+		ioStreams:      genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr},
 	}
 
 	for _, option := range options {
@@ -0,0 +1,342 @@
+package coders
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
+	"gopkg.in/yaml.v3"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/git"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+)
+
+// maxBranchSlugLen bounds the task name part of the batch branches.
+const maxBranchSlugLen = 40
+
+var branchSlugRegexp = regexp.MustCompile(`[^a-z0-9]+`)
+
+// BatchFile is a task file of `ai coder batch`.
+type BatchFile struct {
+	// Parallel is the number of tasks run at once, every parallel task gets its own worktree.
+	Parallel int `yaml:"parallel"`
+	// Worktree runs every task in its own git worktree on its own branch.
+	Worktree bool `yaml:"worktree"`
+	// Tasks are the changes to apply, each one in an independent coder session.
+	Tasks []BatchTask `yaml:"tasks"`
+}
+
+// BatchTask is a single change of a batch file.
+type BatchTask struct {
+	// Name identifies the task in the summary and in its branch name.
+	Name string `yaml:"name"`
+	// Files and Globs are added to the chat, relative to the repository root.
+	Files []string `yaml:"files"`
+	Globs []string `yaml:"globs"`
+	// Instruction is the request sent with /coding.
+	Instruction string `yaml:"instruction"`
+	// Message is the commit message, the first line of the instruction when empty.
+	Message string `yaml:"message"`
+}
+
+// BatchResult is the outcome of a single batch task.
+type BatchResult struct {
+	Task     BatchTask
+	Applied  int
+	Failed   int
+	Commit   string
+	Branch   string
+	Usage    llms.Usage
+	Cost     float64
+	Duration time.Duration
+	Err      error
+}
+
+// Succeeded reports whether the task ran without errors and all of its edits were applied.
+func (r BatchResult) Succeeded() bool {
+	return r.Err == nil && r.Failed == 0
+}
+
+// LoadBatchFile reads and validates a task file.
+func LoadBatchFile(path string) (*BatchFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errbook.Wrap("Failed to read the task file", err)
+	}
+
+	batch := &BatchFile{}
+	if err := yaml.Unmarshal(data, batch); err != nil {
+		return nil, errbook.Wrap("Failed to parse the task file", err)
+	}
+
+	if len(batch.Tasks) == 0 {
+		return nil, errbook.New("No tasks found in %s", path)
+	}
+	for i := range batch.Tasks {
+		task := &batch.Tasks[i]
+		if strings.TrimSpace(task.Instruction) == "" {
+			return nil, errbook.New("Task %d in %s has no instruction", i+1, path)
+		}
+		if len(task.Files) == 0 && len(task.Globs) == 0 {
+			return nil, errbook.New("Task %d in %s lists no files or globs", i+1, path)
+		}
+		if task.Name == "" {
+			task.Name = fmt.Sprintf("task-%d", i+1)
+		}
+	}
+
+	return batch, nil
+}
+
+// RunBatch runs every task as an independent headless coder session. With worktrees
+// each task runs on its own branch in its own worktree, up to parallel at once.
+// Otherwise the tasks run one after another in the repository and commit onto the
+// current branch. The results are returned in the order of the tasks.
+func (a *AutoCoder) RunBatch(ctx context.Context, batch *BatchFile) []BatchResult {
+	results := make([]BatchResult, len(batch.Tasks))
+
+	parallel := batch.Parallel
+	if parallel < 1 || !batch.Worktree {
+		parallel = 1
+	}
+
+	// the sessions of parallel tasks would interleave their output, only the
+	// progress of every task is shown, one whole line at a time
+	log := &batchLog{out: a.messageOut(), total: len(batch.Tasks)}
+	if parallel > 1 {
+		previous := console.SetOutput(io.Discard)
+		defer console.SetOutput(previous)
+	}
+
+	stamp := time.Now().Format("20060102-150405")
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, task := range batch.Tasks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, task BatchTask) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			log.print(i, task, console.StdoutStyles().CommitStep, "➤ started")
+			if batch.Worktree {
+				results[i] = a.runTaskInWorktree(ctx, task, fmt.Sprintf("ai/batch-%s/%d-%s", stamp, i+1, branchSlug(task.Name)))
+			} else {
+				results[i] = a.runTask(ctx, task, a.codeBasePath)
+			}
+
+			switch r := results[i]; {
+			case r.Succeeded():
+				log.print(i, task, console.StdoutStyles().CommitSuccess, "✓ done")
+			case r.Err != nil:
+				log.print(i, task, console.StdoutStyles().Comment, "✗ failed: "+r.Err.Error())
+			default:
+				log.print(i, task, console.StdoutStyles().Comment, fmt.Sprintf("✗ failed: %d edits were not applied", r.Failed))
+			}
+		}(i, task)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// batchLog prints the progress of the batch tasks prefixed with the task they belong to.
+type batchLog struct {
+	mu    sync.Mutex
+	out   io.Writer
+	total int
+}
+
+func (l *batchLog) print(i int, task BatchTask, style lipgloss.Style, msg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = fmt.Fprintln(l.out, style.Render(fmt.Sprintf("[%d/%d] %s: %s", i+1, l.total, task.Name, msg)))
+}
+
+// runTaskInWorktree runs the task in a new worktree on a new branch started at HEAD.
+// The worktree is removed afterwards, the branch is kept when the task committed.
+func (a *AutoCoder) runTaskInWorktree(ctx context.Context, task BatchTask, branch string) BatchResult {
+	dir, err := os.MkdirTemp(a.cfg.DataStore.CachePath, "worktree-")
+	if err != nil {
+		return BatchResult{Task: task, Err: errbook.Wrap("Failed to create the worktree directory", err)}
+	}
+	// git creates the worktree directory itself
+	_ = os.Remove(dir)
+
+	if err := a.repo.AddWorktree(dir, branch, "HEAD"); err != nil {
+		return BatchResult{Task: task, Err: errbook.Wrap("Failed to create the worktree", err)}
+	}
+
+	result := a.runTask(ctx, task, dir)
+
+	if err := a.repo.RemoveWorktree(dir); err != nil {
+		console.RenderComment("Could not remove the worktree %s: %v", dir, err)
+	}
+	if result.Commit == "" {
+		if err := a.repo.DeleteBranch(branch); err != nil {
+			console.RenderComment("Could not delete the branch %s: %v", branch, err)
+		}
+		return result
+	}
+
+	result.Branch = branch
+	return result
+}
+
+// runTask runs a single task as a headless coder session in dir and commits its edits.
+func (a *AutoCoder) runTask(ctx context.Context, task BatchTask, dir string) BatchResult {
+	start := time.Now()
+	result := BatchResult{Task: task}
+
+	cfg := *a.cfg
+	// the session commits the edits itself, without a conversation to record them in
+	cfg.AutoCoder.AutoCommit = false
+	cfg.CacheWriteToID, cfg.CacheReadFromID = "", ""
+
+	// tasks may run at the same time, every session streams through its own engine
+	engine := a.engine
+	if engine != nil {
+		engine = engine.Copy()
+		engine.Config = &cfg
+	}
+
+	repo := git.New(git.WithDir(dir))
+	coder := NewAutoCoder(
+		WithConfig(&cfg),
+		WithEngine(engine),
+		WithRepo(repo),
+		WithCodeBasePath(dir),
+		WithPrompt(task.Instruction),
+		WithHeadless(true),
+	)
+
+	result.Err = runTaskSession(ctx, coder, task)
+	result.Applied, result.Failed = len(coder.applied), len(coder.failed)
+	result.Usage = coder.usage
+	if engine != nil {
+		if coding, err := coder.codingEngine(); err == nil {
+			result.Cost = coding.GetModel().Cost(result.Usage.PromptTokens, result.Usage.CompletionTokens)
+		}
+	}
+
+	if result.Err == nil && result.Applied > 0 {
+		result.Commit, result.Err = commitTask(repo, coder, task)
+	}
+
+	result.Duration = time.Since(start)
+	return result
+}
+
+// runTaskSession adds the files of the task to the session and runs its instruction once.
+func runTaskSession(ctx context.Context, coder *AutoCoder, task BatchTask) error {
+	contexts, err := taskContexts(coder.codeBasePath, task)
+	if err != nil {
+		return err
+	}
+	coder.loadedContexts = contexts
+
+	executor, err := newSessionExecutor(coder)
+	if err != nil {
+		return err
+	}
+
+	return executor.coding(ctx, task.Instruction)
+}
+
+// taskContexts returns the chat contexts of the files and globs of a task.
+func taskContexts(dir string, task BatchTask) ([]*convo.LoadContext, error) {
+	var contexts []*convo.LoadContext
+	seen := map[string]struct{}{}
+
+	for _, pattern := range append(append([]string{}, task.Files...), task.Globs...) {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, errbook.Wrap("Failed to glob files", err)
+		}
+		if len(matches) == 0 {
+			return nil, errbook.New("No files matched %s", pattern)
+		}
+
+		for _, path := range matches {
+			if info, err := os.Stat(path); err != nil || info.IsDir() {
+				continue
+			}
+			if _, ok := seen[path]; ok {
+				continue
+			}
+			seen[path] = struct{}{}
+			contexts = append(contexts, &convo.LoadContext{
+				Type:     convo.ContentTypeFile,
+				FilePath: path,
+				URL:      path,
+				Name:     filepath.Base(path),
+				Mode:     convo.ContextModeEditable,
+			})
+		}
+	}
+
+	return contexts, nil
+}
+
+// commitTask commits the files edited by the task and returns the commit SHA.
+func commitTask(repo *git.Command, coder *AutoCoder, task BatchTask) (string, error) {
+	var files []string
+	seen := map[string]struct{}{}
+	for _, block := range coder.applied {
+		path := coder.relPath(block.Path)
+		if _, ok := seen[path]; !ok {
+			seen[path] = struct{}{}
+			files = append(files, path)
+		}
+	}
+
+	if err := repo.AddFiles(files); err != nil {
+		return "", errbook.Wrap("Failed to add files to Git", err)
+	}
+	if _, err := repo.Commit(taskCommitMessage(task, coder.cfg.AutoCoder.CommitPrefix)); err != nil {
+		return "", errbook.Wrap("Failed to commit changes", err)
+	}
+
+	head, err := repo.HeadCommit()
+	if err != nil {
+		return "", errbook.Wrap("Failed to get the commit", err)
+	}
+	return head, nil
+}
+
+// taskCommitMessage returns the message of the task, or the first line of its instruction.
+func taskCommitMessage(task BatchTask, prefix string) string {
+	if message := strings.TrimSpace(task.Message); message != "" {
+		return message
+	}
+
+	message := firstNonEmptyLine(task.Instruction)
+	if prefix != "" {
+		message = fmt.Sprintf("%s: %s", prefix, message)
+	}
+	return message
+}
+
+// branchSlug turns a task name into a branch name component.
+func branchSlug(name string) string {
+	slug := strings.Trim(branchSlugRegexp.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(slug) > maxBranchSlugLen {
+		slug = strings.TrimRight(slug[:maxBranchSlugLen], "-")
+	}
+	if slug == "" {
+		return "task"
+	}
+	return slug
+}
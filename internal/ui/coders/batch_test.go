@@ -0,0 +1,219 @@
+package coders
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coding-hui/ai-terminal/internal/ai"
+	"github.com/coding-hui/ai-terminal/internal/convo/sqlite3"
+	"github.com/coding-hui/ai-terminal/internal/git"
+	"github.com/coding-hui/ai-terminal/internal/options"
+	"github.com/coding-hui/ai-terminal/internal/util/genericclioptions"
+)
+
+func TestBatch(t *testing.T) {
+	t.Run("load batch file", testLoadBatchFile)
+	t.Run("invalid batch file", testInvalidBatchFile)
+	t.Run("task contexts", testTaskContexts)
+	t.Run("task commit message", testTaskCommitMessage)
+	t.Run("branch slug", testBranchSlug)
+	t.Run("failed task cleans up its worktree", testFailedTaskWorktree)
+	t.Run("parallel tasks", testParallelBatch)
+}
+
+func writeBatchFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tasks.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func testLoadBatchFile(t *testing.T) {
+	batch, err := LoadBatchFile(writeBatchFile(t, `
+parallel: 2
+worktree: true
+tasks:
+  - name: logging
+    globs: ["internal/*.go"]
+    instruction: Use the console package.
+  - files: [main.go]
+    instruction: |
+      Add a version flag.
+      Print it on startup.
+`))
+	require.NoError(t, err)
+	assert.Equal(t, 2, batch.Parallel)
+	assert.True(t, batch.Worktree)
+	require.Len(t, batch.Tasks, 2)
+	assert.Equal(t, "logging", batch.Tasks[0].Name)
+	assert.Equal(t, []string{"internal/*.go"}, batch.Tasks[0].Globs)
+	assert.Equal(t, "task-2", batch.Tasks[1].Name)
+	assert.Equal(t, []string{"main.go"}, batch.Tasks[1].Files)
+}
+
+func testInvalidBatchFile(t *testing.T) {
+	_, err := LoadBatchFile(writeBatchFile(t, "tasks: []\n"))
+	assert.ErrorContains(t, err, "No tasks found")
+
+	_, err = LoadBatchFile(writeBatchFile(t, "tasks:\n  - files: [main.go]\n"))
+	assert.ErrorContains(t, err, "has no instruction")
+
+	_, err = LoadBatchFile(writeBatchFile(t, "tasks:\n  - instruction: do it\n"))
+	assert.ErrorContains(t, err, "lists no files or globs")
+}
+
+func testTaskContexts(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"main.go", "internal/a.go", "internal/b.go"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte("package x\n"), 0o600))
+	}
+
+	contexts, err := taskContexts(dir, BatchTask{Files: []string{"main.go", "internal/a.go"}, Globs: []string{"internal/*.go"}})
+	require.NoError(t, err)
+
+	var names []string
+	for _, lc := range contexts {
+		assert.False(t, lc.IsReadOnly())
+		names = append(names, strings.TrimPrefix(lc.FilePath, dir+string(filepath.Separator)))
+	}
+	assert.Equal(t, []string{"main.go", filepath.Join("internal", "a.go"), filepath.Join("internal", "b.go")}, names)
+
+	_, err = taskContexts(dir, BatchTask{Files: []string{"missing.go"}})
+	assert.ErrorContains(t, err, "No files matched missing.go")
+}
+
+func testTaskCommitMessage(t *testing.T) {
+	assert.Equal(t, "add a version flag", taskCommitMessage(BatchTask{Instruction: "\nadd a version flag\nprint it"}, ""))
+	assert.Equal(t, "feat: add a version flag", taskCommitMessage(BatchTask{Instruction: "add a version flag"}, "feat"))
+	assert.Equal(t, "chore: bump", taskCommitMessage(BatchTask{Instruction: "bump deps", Message: "chore: bump"}, "feat"))
+}
+
+func testBranchSlug(t *testing.T) {
+	assert.Equal(t, "structured-logging", branchSlug("Structured logging!"))
+	assert.Equal(t, "task", branchSlug("日本語"))
+	assert.LessOrEqual(t, len(branchSlug(strings.Repeat("very long name ", 10))), maxBranchSlugLen)
+}
+
+// initBatchRepo creates a git repository with an initial commit holding the files.
+func initBatchRepo(t *testing.T, files ...string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	for _, name := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte("package "+strings.TrimSuffix(name, ".go")+"\n"), 0o600))
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		require.NoError(t, cmd.Run())
+	}
+	return root
+}
+
+func testFailedTaskWorktree(t *testing.T) {
+	root := initBatchRepo(t)
+
+	cfg := &options.Config{DataStore: options.DataStore{CachePath: t.TempDir()}}
+	coder := NewAutoCoder(WithConfig(cfg), WithRepo(git.New(git.WithDir(root))), WithCodeBasePath(root))
+
+	result := coder.runTaskInWorktree(context.Background(), BatchTask{Name: "missing", Files: []string{"missing.go"}, Instruction: "edit"}, "ai/batch-test/1-missing")
+	assert.ErrorContains(t, result.Err, "No files matched missing.go")
+	assert.False(t, result.Succeeded())
+	assert.Empty(t, result.Branch)
+
+	entries, err := os.ReadDir(cfg.DataStore.CachePath)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	output, err := exec.Command("git", "-C", root, "branch", "--list", "ai/*").Output()
+	require.NoError(t, err)
+	assert.Empty(t, strings.TrimSpace(string(output)))
+}
+
+func testParallelBatch(t *testing.T) {
+	files := []string{"a.go", "b.go", "c.go", "d.go"}
+	root := initBatchRepo(t, files...)
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	// the model renames the package of the file named in the instruction
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var pkg string
+		for _, name := range files {
+			if strings.Contains(string(body), "rename package "+strings.TrimSuffix(name, ".go")) {
+				pkg = strings.TrimSuffix(name, ".go")
+			}
+		}
+		reply := fmt.Sprintf("```go\n%s.go\n<<<<<<< SEARCH\npackage %s\n=======\npackage %s_renamed\n>>>>>>> REPLACE\n```", pkg, pkg, pkg)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"id":"1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":%q},"finish_reason":"stop"}],"usage":{"prompt_tokens":1000,"completion_tokens":100,"total_tokens":1100}}`, reply)
+	}))
+	defer server.Close()
+
+	cfg := &options.Config{
+		Model:     "gpt-4o",
+		API:       ai.ModelTypeOpenAI,
+		Models:    map[string]options.Model{"gpt-4o": {Name: "gpt-4o", API: ai.ModelTypeOpenAI, MaxChars: 100000, InputPrice: 2.5, OutputPrice: 10}},
+		APIs:      options.APIs{{Name: ai.ModelTypeOpenAI, APIKey: "test", BaseURL: server.URL}},
+		DataStore: options.DataStore{CachePath: t.TempDir()},
+		AutoCoder: options.AutoCoder{CodingFences: []string{"```", "```"}},
+	}
+	store, err := sqlite3.NewSqliteStore(sqlite3.WithDBAddress(filepath.Join(t.TempDir(), "convo.db")))
+	require.NoError(t, err)
+	engine, err := ai.New(ai.WithConfig(cfg), ai.WithStore(store))
+	require.NoError(t, err)
+
+	batch := &BatchFile{Parallel: len(files), Worktree: true}
+	for _, name := range files {
+		pkg := strings.TrimSuffix(name, ".go")
+		batch.Tasks = append(batch.Tasks, BatchTask{Name: pkg, Files: []string{name}, Instruction: "rename package " + pkg})
+	}
+
+	var out bytes.Buffer
+	coder := NewAutoCoder(WithConfig(cfg), WithEngine(engine), WithRepo(git.New(git.WithDir(root))), WithCodeBasePath(root),
+		WithIOStreams(genericclioptions.IOStreams{In: os.Stdin, Out: &out, ErrOut: io.Discard}))
+	results := coder.RunBatch(context.Background(), batch)
+
+	// every line tells the task it belongs to, the sessions themselves are not shown
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2*len(files))
+	for _, line := range lines {
+		assert.Regexp(t, `\[\d/4\] [a-d]: `, line)
+	}
+
+	require.Len(t, results, len(files))
+	for i, r := range results {
+		require.NoError(t, r.Err, r.Task.Name)
+		assert.Equal(t, 1, r.Applied)
+		assert.InDelta(t, 0.0035, r.Cost, 1e-9)
+		require.NotEmpty(t, r.Branch)
+
+		content, err := exec.Command("git", "-C", root, "show", r.Branch+":"+files[i]).Output()
+		require.NoError(t, err)
+		assert.Equal(t, "package "+r.Task.Name+"_renamed\n", string(content))
+	}
+}
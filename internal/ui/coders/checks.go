@@ -0,0 +1,188 @@
+package coders
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/runner"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+)
+
+const (
+	checkKindLint = "lint"
+	checkKindTest = "test"
+
+	// checkOutputHeadLines and checkOutputTailLines bound the command output sent to the model.
+	checkOutputHeadLines = 40
+	checkOutputTailLines = 120
+)
+
+// lint runs the configured lint command, or the one given as input, and lets the model fix failures
+func (c *CommandExecutor) lint(ctx context.Context, input string) error {
+	return c.runCheckCommand(ctx, checkKindLint, input, c.coder.cfg.AutoCoder.LintCmd)
+}
+
+// test runs the configured test command, or the one given as input, and lets the model fix failures
+func (c *CommandExecutor) test(ctx context.Context, input string) error {
+	return c.runCheckCommand(ctx, checkKindTest, input, c.coder.cfg.AutoCoder.TestCmd)
+}
+
+func (c *CommandExecutor) runCheckCommand(ctx context.Context, kind, input, configured string) error {
+	cmdline := strings.TrimSpace(input)
+	if cmdline == "" {
+		cmdline = configured
+	}
+	if cmdline == "" {
+		return errbook.New("No %[1]s command configured. Set auto-coder.%[1]s-cmd or pass one: /%[1]s <command>", kind)
+	}
+
+	files, err := c.editor.GetModifiedFiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.checkAndFix(ctx, kind, cmdline, files)
+}
+
+// runChecks runs the configured lint and test commands after edits were applied.
+func (c *CommandExecutor) runChecks(ctx context.Context, files []string) error {
+	checks := []struct{ kind, cmdline string }{
+		{checkKindLint, c.coder.cfg.AutoCoder.LintCmd},
+		{checkKindTest, c.coder.cfg.AutoCoder.TestCmd},
+	}
+
+	for _, check := range checks {
+		if strings.TrimSpace(check.cmdline) == "" {
+			continue
+		}
+		if err := c.checkAndFix(ctx, check.kind, check.cmdline, files); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkAndFix runs cmdline and, as long as it fails, sends the trimmed output together
+// with the changed files to the model for a fix, up to the configured number of rounds.
+func (c *CommandExecutor) checkAndFix(ctx context.Context, kind, cmdline string, files []string) error {
+	maxRounds := c.coder.cfg.AutoCoder.GetMaxFixRounds()
+
+	for round := 0; ; round++ {
+		console.RenderStep("Running %s: %s", kind, cmdline)
+		output, err := runner.RunShell(c.coder.codeBasePath, cmdline)
+		if err == nil {
+			console.RenderSuccess("%s passed", kind)
+			return nil
+		}
+
+		trimmed := trimCheckOutput(output)
+		console.RenderComment("%s", trimmed)
+
+		if round >= maxRounds {
+			return errbook.Wrap(fmt.Sprintf("The %s command is still failing after %d fix rounds", kind, maxRounds), err)
+		}
+
+		console.RenderStep("The %s command failed, asking the model for a fix (round %d/%d)", kind, round+1, maxRounds)
+
+		prompt := fmt.Sprintf(checkFailedPrompt, cmdline, trimmed)
+		addedFiles, err := c.getCheckFilesContent(ctx, prompt, files)
+		if err != nil {
+			return err
+		}
+
+		messages, err := c.formatCodingMessages(ctx, prompt, addedFiles)
+		if err != nil {
+			return err
+		}
+
+		if err := c.editor.Execute(ctx, messages); err != nil {
+			return err
+		}
+
+		modified, err := c.editor.GetModifiedFiles(ctx)
+		if err != nil {
+			return err
+		}
+		files = appendUnique(files, modified...)
+	}
+}
+
+// getCheckFilesContent returns the loaded contexts plus every changed file that is not
+// loaded yet, fitted into the input budget of the coding model.
+func (c *CommandExecutor) getCheckFilesContent(ctx context.Context, prompt string, files []string) (string, error) {
+	engine, err := c.coder.codingEngine()
+	if err != nil {
+		return "", err
+	}
+
+	var changed []*convo.LoadContext
+	for _, file := range files {
+		absPath, err := absFilePath(c.coder.codeBasePath, file)
+		if err != nil {
+			return "", err
+		}
+		if c.isLoaded(absPath) {
+			continue
+		}
+		changed = append(changed, &convo.LoadContext{Type: convo.ContentTypeFile, FilePath: absPath})
+	}
+
+	report, err := c.buildContext(ctx, engine.GetModel().MaxChars, prompt, changed...)
+	if err != nil {
+		return "", err
+	}
+
+	return report.Text(), nil
+}
+
+// isLoaded reports whether the given absolute path or URL is already in the chat context.
+func (c *CommandExecutor) isLoaded(path string) bool {
+	return c.findLoaded(path) != nil
+}
+
+// findLoaded returns the context of the given absolute path or URL, or nil when it is not in the chat.
+func (c *CommandExecutor) findLoaded(path string) *convo.LoadContext {
+	for _, lc := range c.coder.loadedContexts {
+		if lc.FilePath == path || lc.URL == path {
+			return lc
+		}
+	}
+	return nil
+}
+
+// trimCheckOutput keeps the head and the tail of long command output,
+// which is where compilers and test runners report what went wrong.
+func trimCheckOutput(output string) string {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if len(lines) <= checkOutputHeadLines+checkOutputTailLines {
+		return strings.Join(lines, "\n")
+	}
+
+	omitted := len(lines) - checkOutputHeadLines - checkOutputTailLines
+	head := lines[:checkOutputHeadLines]
+	tail := lines[len(lines)-checkOutputTailLines:]
+
+	return strings.Join(head, "\n") +
+		fmt.Sprintf("\n... %d lines omitted ...\n", omitted) +
+		strings.Join(tail, "\n")
+}
+
+func appendUnique(items []string, more ...string) []string {
+	for _, m := range more {
+		found := false
+		for _, item := range items {
+			if item == m {
+				found = true
+				break
+			}
+		}
+		if !found {
+			items = append(items, m)
+		}
+	}
+	return items
+}
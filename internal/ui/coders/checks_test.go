@@ -0,0 +1,57 @@
+package coders
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coding-hui/ai-terminal/internal/options"
+)
+
+func TestChecks(t *testing.T) {
+	t.Run("trimCheckOutput", testTrimCheckOutput)
+	t.Run("appendUnique", testAppendUnique)
+	t.Run("runCheckCommand", testRunCheckCommand)
+}
+
+func testTrimCheckOutput(t *testing.T) {
+	short := "ok\n"
+	assert.Equal(t, "ok", trimCheckOutput(short))
+
+	var lines []string
+	for i := 0; i < checkOutputHeadLines+checkOutputTailLines+10; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	trimmed := trimCheckOutput(strings.Join(lines, "\n"))
+	assert.True(t, strings.HasPrefix(trimmed, "line 0\n"))
+	assert.Contains(t, trimmed, "... 10 lines omitted ...")
+	assert.True(t, strings.HasSuffix(trimmed, lines[len(lines)-1]))
+	assert.NotContains(t, trimmed, fmt.Sprintf("line %d\n", checkOutputHeadLines))
+}
+
+func testAppendUnique(t *testing.T) {
+	assert.Equal(t, []string{"a", "b", "c"}, appendUnique([]string{"a", "b"}, "b", "c", "a"))
+}
+
+func testRunCheckCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("bash is required")
+	}
+
+	cfg := &options.Config{AutoCoder: options.AutoCoder{MaxFixRounds: -1}}
+	executor := &CommandExecutor{coder: newTestAutoCoder(t, cfg)}
+	executor.editor = NewEditBlockCoder(executor.coder, fences[0])
+
+	ctx := context.Background()
+	require.NoError(t, executor.lint(ctx, "true"))
+	assert.Error(t, executor.test(ctx, "false"))
+	assert.Error(t, executor.lint(ctx, ""), "no lint command configured")
+
+	cfg.AutoCoder.TestCmd = "exit 0"
+	assert.NoError(t, executor.test(ctx, ""))
+}
@@ -4,9 +4,14 @@ package coders
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
 	"github.com/coding-hui/wecoding-sdk-go/services/ai/prompts"
+
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/ui/chat"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
 )
 
 // PartialCodeBlock represents a partial code block with its file path and original and updated text.
@@ -44,3 +49,77 @@ type Coder interface {
 	// Execute runs the code editor with the specified input messages.
 	Execute(ctx context.Context, messages []llms.ChatMessage) error
 }
+
+// NewCoder returns the Coder implementation for the given edit format.
+// An empty format falls back to the SEARCH/REPLACE block coder.
+func NewCoder(format string, coder *AutoCoder, fence []string) (Coder, error) {
+	switch format {
+	case "", EditFormatDiff:
+		return NewEditBlockCoder(coder, fence), nil
+	case EditFormatWhole:
+		return NewWholeFileCoder(coder, fence), nil
+	case EditFormatUDiff:
+		return NewUnifiedDiffCoder(coder, fence), nil
+	default:
+		return nil, errbook.New("Unsupported edit format %s, supported formats: %s",
+			format, strings.Join(SupportedEditFormats(), ", "))
+	}
+}
+
+// appliedFiles returns the distinct paths of the applied edits in the order they were applied.
+func appliedFiles(applied []PartialCodeBlock) []string {
+	seen := map[string]struct{}{}
+	files := make([]string, 0, len(applied))
+	for _, block := range applied {
+		if _, ok := seen[block.Path]; ok {
+			continue
+		}
+		seen[block.Path] = struct{}{}
+		files = append(files, block.Path)
+	}
+	return files
+}
+
+// generateResponse runs the chat completion for the given messages and asks the user
+// to confirm that the generated edits should be applied. It returns the raw model output.
+func generateResponse(ctx context.Context, coder *AutoCoder, messages []llms.ChatMessage) (string, error) {
+	console.RenderStep("Please wait while we design the code")
+
+	engine, err := coder.codingEngine()
+	if err != nil {
+		return "", err
+	}
+
+	if coder.headless {
+		out, err := engine.Generate(ctx, messages)
+		if err != nil {
+			return "", err
+		}
+		coder.addUsage(out.Usage)
+		coder.lastReply = out.Explanation
+		return out.Explanation, nil
+	}
+
+	chatModel := chat.NewChat(coder.cfg,
+		chat.WithContext(ctx),
+		chat.WithMessages(messages),
+		chat.WithEngine(engine),
+		// scripted runs leave the clipboard alone
+		chat.WithCopyToClipboard(!coder.assumeYes),
+		chat.WithOutput(coder.messageOut()),
+	)
+
+	if err := chatModel.Run(); err != nil {
+		return "", err
+	}
+	coder.addUsage(chatModel.TokenUsage)
+
+	output := chatModel.GetOutput()
+	coder.lastReply = output
+
+	if ok := coder.confirm(console.Yes, "Are you sure you want to apply these codes? (Y/n)"); !ok {
+		return output, errbook.NewUserErrorf("Apply edit cancelled!")
+	}
+
+	return output, nil
+}
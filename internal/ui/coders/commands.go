@@ -14,6 +14,7 @@ import (
 
 	"github.com/coding-hui/common/util/fileutil"
 	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
+	"github.com/spf13/pflag"
 
 	"github.com/coding-hui/ai-terminal/internal/cli/commit"
 	"github.com/coding-hui/ai-terminal/internal/convo"
@@ -39,32 +40,63 @@ func getSupportedCommands() []string {
 
 type CommandExecutor struct {
 	coder  *AutoCoder
-	editor *EditBlockCoder
-	flags  map[string]bool
+	editor Coder
+	// flags are the flags of the running command
+	flags *pflag.FlagSet
+	// shellOutputs are the shell commands attached to the next prompt
+	shellOutputs []shellOutput
+	// plugins are the user commands registered next to the built-in ones
+	plugins []*pluginCommand
 }
 
-func NewCommandExecutor(coder *AutoCoder) *CommandExecutor {
-	editor := NewEditBlockCoder(coder, fences[0])
-	cmds := &CommandExecutor{coder: coder, editor: editor}
+func NewCommandExecutor(coder *AutoCoder) (*CommandExecutor, error) {
+	cmds, err := newSessionExecutor(coder)
+	if err != nil {
+		return nil, err
+	}
 	cmds.registryCmds()
-	return cmds
+	return cmds, nil
+}
+
+// newSessionExecutor returns an executor that is not registered for the REPL commands,
+// so several sessions can run side by side.
+func newSessionExecutor(coder *AutoCoder) (*CommandExecutor, error) {
+	editor, err := NewCoder(coder.cfg.AutoCoder.EditFormat, coder, fences[0])
+	if err != nil {
+		return nil, errbook.Wrap("Failed to create coder", err)
+	}
+	return &CommandExecutor{coder: coder, editor: editor}, nil
 }
 
 func (c *CommandExecutor) registryCmds() {
 	supportCommands["/add"] = c.add
+	supportCommands["/read-only"] = c.readOnly
 	supportCommands["/list"] = c.list
 	supportCommands["/remove"] = c.remove
 	supportCommands["/ask"] = c.ask
 	supportCommands["/design"] = c.design
 	supportCommands["/drop"] = c.drop
 	supportCommands["/coding"] = c.coding
+	supportCommands["/architect"] = c.architect
 	supportCommands["/commit"] = c.commit
 	supportCommands["/undo"] = c.undo
+	supportCommands["/merge"] = c.merge
+	supportCommands["/discard"] = c.discard
 	supportCommands["/exit"] = c.exit
 	supportCommands["/diff"] = c.diff
 	supportCommands["/apply"] = c.apply
 	supportCommands["/chat-model"] = c.switchNewChatModel
+	supportCommands["/format"] = c.switchEditFormat
+	supportCommands["/lint"] = c.lint
+	supportCommands["/test"] = c.test
+	supportCommands["/tokens"] = c.tokens
+	supportCommands["/run"] = c.run
+	supportCommands["/edit"] = c.edit
+	supportCommands["/clear"] = c.clear
+	supportCommands["/history"] = c.history
 	supportCommands["/help"] = c.help
+
+	c.registerPlugins()
 }
 
 // isCommand detects if input is a command (prefixed with ! or /)
@@ -86,36 +118,81 @@ func (c *CommandExecutor) Executor(input string) {
 		return
 	}
 
+	if err := c.Execute(context.Background(), input); err != nil {
+		var aiErr errbook.AiError
+		if errors.As(err, &aiErr) && aiErr.Reason() != "" {
+			console.RenderError(err, "%s", aiErr.Reason())
+			return
+		}
+		console.RenderError(err, "Failed to execute command")
+	}
+}
+
+// Execute runs a single command line and returns the error of the command.
+func (c *CommandExecutor) Execute(ctx context.Context, input string) error {
+	input = strings.TrimSpace(input)
+	if !c.isCommand(input) {
+		return errbook.Wrap("Please use a command to interact with the system. Type / to see all available commands.", errbook.ErrInvalidArgument)
+	}
+
+	// Run shell commands
+	if strings.HasPrefix(input, shellPrefix) {
+		if err := c.shell(ctx, strings.TrimPrefix(input, shellPrefix)); err != nil {
+			return errbook.Wrap("Failed to run the shell command", err)
+		}
+		return nil
+	}
+
 	// Handle command execution
-	cmd, args := extractCmdArgs(input)
+	cmd, rest := cutWord(input)
 	fn, ok := supportCommands[cmd]
 	if !ok {
-		console.RenderError(
-			errbook.ErrInvalidArgument,
+		return errbook.Wrap(fmt.Sprintf(
 			"Unknown command: %s. Supported commands: %s. Type / to see all recommended commands.",
 			cmd, strings.Join(getSupportedCommands(), ", "),
-		)
-		return
+		), errbook.ErrInvalidArgument)
+	}
+
+	// Parse the flags of the command, the handler gets the remaining input
+	spec := c.commandSpec(cmd)
+	userInput, help, err := c.parseArgs(spec, rest)
+	if err != nil {
+		return err
+	}
+	if help {
+		c.commandHelp(spec)
+		return nil
 	}
 
-	// Parse command arguments
-	filteredInput := c.parseFlags(args)
-	userInput := strings.Join(filteredInput, " ")
+	// Add the files mentioned with @ to the chat for this request only,
+	// and the shell output attached with ! or /run in front of the question
+	var attached []shellOutput
+	if _, ok := mentionCommands[cmd]; ok {
+		question, cleanup, err := c.withMentions(userInput)
+		if err != nil {
+			return errbook.Wrap("Failed to resolve the mentioned files", err)
+		}
+		defer cleanup()
+		userInput, attached = c.withShellOutputs(question)
+	}
 
 	// Execute the recognized command
-	if err := fn(context.Background(), userInput); err != nil {
-		console.RenderError(err, "Failed to execute command %s", cmd)
+	if err := fn(ctx, userInput); err != nil {
+		c.shellOutputs = append(attached, c.shellOutputs...)
+		return errbook.Wrap(fmt.Sprintf("Failed to execute command %s", cmd), err)
 	}
+
+	return nil
 }
 
 // ask queries GPT to analyze or edit files in context
 func (c *CommandExecutor) ask(ctx context.Context, input string) error {
-	messages, err := c.prepareAskCompletionMessages(input)
+	messages, err := c.prepareAskCompletionMessages(ctx, input)
 	if err != nil {
 		return errbook.Wrap("Failed to prepare ask completion messages", err)
 	}
 
-	if c.flags[FlagVerbose] {
+	if c.flag(FlagVerbose) {
 		return console.RenderChatMessages(messages)
 	}
 
@@ -124,9 +201,15 @@ func (c *CommandExecutor) ask(ctx context.Context, input string) error {
 		chat.WithMessages(messages),
 		chat.WithEngine(c.coder.engine),
 		chat.WithCopyToClipboard(true),
+		chat.WithOutput(c.coder.messageOut()),
 	)
 
-	return chatModel.Run()
+	if err := chatModel.Run(); err != nil {
+		return err
+	}
+
+	c.coder.recordTurn(ctx, "/ask", input, chatModel.GetOutput())
+	return nil
 }
 
 // add registers files/URLs for GPT to analyze or edit
@@ -147,8 +230,26 @@ func (c *CommandExecutor) loadFileContent(path string) (string, error) {
 	return string(content), nil
 }
 
-func (c *CommandExecutor) add(_ context.Context, input string) (err error) {
-	files := strings.Fields(input)
+func (c *CommandExecutor) add(ctx context.Context, input string) error {
+	files, err := splitArgs(input)
+	if err != nil {
+		return err
+	}
+	return c.addFiles(ctx, files, convo.ContextModeEditable)
+}
+
+// readOnly adds files or URLs the model may read but not edit
+func (c *CommandExecutor) readOnly(ctx context.Context, input string) error {
+	files, err := splitArgs(input)
+	if err != nil {
+		return err
+	}
+	return c.addFiles(ctx, files, convo.ContextModeReadOnly)
+}
+
+// addFiles adds the files or URLs matched by the patterns to the chat in the given mode.
+// Files that are already in the chat switch to that mode.
+func (c *CommandExecutor) addFiles(_ context.Context, files []string, mode convo.ContextMode) (err error) {
 	if len(files) == 0 {
 		return errbook.New("Please provide at least one file or URL")
 	}
@@ -199,10 +300,16 @@ func (c *CommandExecutor) add(_ context.Context, input string) (err error) {
 		}
 
 		// Check if file already loaded
-		for _, lc := range c.coder.loadedContexts {
-			if lc.FilePath == absPath || lc.URL == absPath {
+		if lc := c.findLoaded(absPath); lc != nil {
+			if lc.IsReadOnly() == (mode == convo.ContextModeReadOnly) {
 				return errbook.New("File [%s] already exists", absPath)
 			}
+			lc.Mode = mode
+			if err := c.coder.saveContext(context.Background(), lc); err != nil {
+				return errbook.Wrap("Failed to persist file context", err)
+			}
+			console.Render("Switched [%s] to %s", absPath, mode)
+			continue
 		}
 
 		// Create new LoadContext
@@ -211,6 +318,7 @@ func (c *CommandExecutor) add(_ context.Context, input string) (err error) {
 			URL:     absPath,
 			Content: "", // Will be loaded on demand
 			Name:    filepath.Base(absPath),
+			Mode:    mode,
 		}
 		if rest.IsValidURL(absPath) {
 			lc.Type = convo.ContentTypeURL
@@ -225,7 +333,11 @@ func (c *CommandExecutor) add(_ context.Context, input string) (err error) {
 			return errbook.Wrap("Failed to persist file context", err)
 		}
 
-		console.Render("Added [%s]", absPath)
+		if mode == convo.ContextModeReadOnly {
+			console.Render("Added [%s] as read-only", absPath)
+		} else {
+			console.Render("Added [%s]", absPath)
+		}
 	}
 
 	return nil
@@ -247,7 +359,11 @@ func (c *CommandExecutor) list(_ context.Context, _ string) error {
 		if err != nil {
 			return errbook.Wrap("Failed to get relative path", err)
 		}
-		console.Render("%d.%s (%s)", no, relPath, lc.Type)
+		if lc.IsReadOnly() {
+			console.Render("%d.%s (%s, %s)", no, relPath, lc.Type, lc.Mode)
+		} else {
+			console.Render("%d.%s (%s)", no, relPath, lc.Type)
+		}
 		no++
 	}
 
@@ -256,7 +372,10 @@ func (c *CommandExecutor) list(_ context.Context, _ string) error {
 
 // remove deletes files from context to exclude from GPT analysis
 func (c *CommandExecutor) remove(_ context.Context, input string) error {
-	files := strings.Fields(input)
+	files, err := splitArgs(input)
+	if err != nil {
+		return err
+	}
 	if len(files) == 0 {
 		return errbook.New("Please provide at least one file")
 	}
@@ -310,34 +429,42 @@ func (c *CommandExecutor) drop(_ context.Context, _ string) error {
 }
 
 func (c *CommandExecutor) coding(ctx context.Context, input string) error {
-	addedFiles, err := c.getAddedFileContent()
+	engine, err := c.coder.codingEngine()
 	if err != nil {
 		return err
 	}
 
-	openFence, closeFence := c.editor.UpdateCodeFences(ctx, addedFiles)
+	report, err := c.buildContext(ctx, engine.GetModel().MaxChars, input)
+	if err != nil {
+		return err
+	}
 
-	console.RenderStep("Selected coder block fences %s %s", openFence, closeFence)
-	messages, err := c.editor.FormatMessages(map[string]any{
-		userQuestionKey: input,
-		addedFilesKey:   addedFiles,
-		openFenceKey:    openFence,
-		closeFenceKey:   closeFence,
-		lazyPromptKey:   lazyPrompt,
-	})
+	messages, err := c.formatCodingMessages(ctx, input, report.Text())
 	if err != nil {
 		return err
 	}
 
-	if c.flags[FlagVerbose] {
+	if c.flag(FlagVerbose) {
 		return console.RenderChatMessages(messages)
 	}
 
+	c.coder.lastReply = ""
 	err = c.editor.Execute(ctx, messages)
+	c.coder.recordTurn(ctx, "/coding", input, c.coder.lastReply)
 	if err != nil {
 		return err
 	}
 
+	modifiedFiles, err := c.editor.GetModifiedFiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Run the configured lint and test commands and let the model fix failures
+	if err := c.runChecks(ctx, modifiedFiles); err != nil {
+		return err
+	}
+
 	// Auto-commit if enabled in config
 	if c.coder.cfg.AutoCoder.AutoCommit {
 		if err := c.commit(ctx, ""); err != nil {
@@ -345,8 +472,13 @@ func (c *CommandExecutor) coding(ctx context.Context, input string) error {
 		}
 	}
 
+	// Headless sessions end after a single request, there is no context to extend
+	if c.coder.headless {
+		return nil
+	}
+
 	// Check for modified/new files and prompt to save to context
-	modifiedFiles, err := c.editor.GetModifiedFiles(ctx)
+	modifiedFiles, err = c.editor.GetModifiedFiles(ctx)
 	if err != nil {
 		return err
 	}
@@ -362,8 +494,8 @@ func (c *CommandExecutor) coding(ctx context.Context, input string) error {
 		}
 
 		if !found {
-			if console.WaitForUserConfirm(console.Yes, "Do you want to add modified file %s to context?", file) {
-				if err := c.add(ctx, file); err != nil {
+			if c.coder.confirm(console.Yes, "Do you want to add modified file %s to context?", file) {
+				if err := c.addFiles(ctx, []string{file}, convo.ContextModeEditable); err != nil {
 					console.RenderError(err, "Failed to add file %s to context", file)
 				}
 			}
@@ -373,6 +505,26 @@ func (c *CommandExecutor) coding(ctx context.Context, input string) error {
 	return nil
 }
 
+// formatCodingMessages selects the code fences for addedFiles and formats the edit prompt of the current coder.
+func (c *CommandExecutor) formatCodingMessages(ctx context.Context, question, addedFiles string) ([]llms.ChatMessage, error) {
+	openFence, closeFence := c.editor.UpdateCodeFences(ctx, addedFiles)
+
+	console.RenderStep("Selected coder block fences %s %s", openFence, closeFence)
+	messages, err := c.editor.FormatMessages(map[string]any{
+		userQuestionKey: question,
+		addedFilesKey:   addedFiles,
+		repoMapKey:      c.coder.renderRepoMap(question, addedFiles),
+		openFenceKey:    openFence,
+		closeFenceKey:   closeFence,
+		lazyPromptKey:   lazyPrompt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.coder.withHistory(ctx, messages), nil
+}
+
 func (c *CommandExecutor) undo(ctx context.Context, _ string) error {
 	// First check if there are any files in the chat
 	if len(c.coder.loadedContexts) == 0 {
@@ -388,7 +540,7 @@ func (c *CommandExecutor) undo(ctx context.Context, _ string) error {
 	}
 
 	// Confirm with user before undoing
-	if !console.WaitForUserConfirm(console.No, "Are you sure you want to undo the last changes?") {
+	if !c.coder.confirm(console.No, "Are you sure you want to undo the last changes?") {
 		console.Render("Undo canceled")
 		return nil
 	}
@@ -415,9 +567,9 @@ func (c *CommandExecutor) commit(ctx context.Context, _ string) error {
 
 	// Execute the commit CommandExecutor
 	ioStreams := genericclioptions.IOStreams{
-		In:     os.Stdin,
-		Out:    os.Stdout,
-		ErrOut: os.Stderr,
+		In:     c.coder.ioStreams.In,
+		Out:    c.coder.messageOut(),
+		ErrOut: c.coder.ioStreams.ErrOut,
 	}
 	commitCmd := commit.New(
 		commit.WithNoConfirm(true),
@@ -426,6 +578,7 @@ func (c *CommandExecutor) commit(ctx context.Context, _ string) error {
 		commit.WithConfig(c.coder.cfg),
 		commit.WithCommitPrefix(c.coder.cfg.AutoCoder.CommitPrefix), // Use configured commit prefix
 		commit.WithCommitLang(prompt.DefaultLanguage),
+		commit.WithWorkDir(c.coder.codeBasePath),
 	)
 	if err := commitCmd.AutoCommit(nil, nil); err != nil {
 		return errbook.Wrap("Failed to commit changes", err)
@@ -435,59 +588,78 @@ func (c *CommandExecutor) commit(ctx context.Context, _ string) error {
 }
 
 func (c *CommandExecutor) design(ctx context.Context, input string) error {
-	messages, err := c.prepareDesignCompletionMessages(input)
+	messages, err := c.prepareDesignCompletionMessages(ctx, input)
 	if err != nil {
 		return errbook.Wrap("Failed to prepare design completion messages", err)
 	}
 
-	if c.flags[FlagVerbose] {
+	if c.flag(FlagVerbose) {
 		return console.RenderChatMessages(messages)
 	}
 
+	engine, err := c.coder.designEngine()
+	if err != nil {
+		return err
+	}
+
 	chatModel := chat.NewChat(c.coder.cfg,
 		chat.WithContext(ctx),
 		chat.WithMessages(messages),
-		chat.WithEngine(c.coder.engine),
+		chat.WithEngine(engine),
 		chat.WithCopyToClipboard(true),
+		chat.WithOutput(c.coder.messageOut()),
 	)
 
-	return chatModel.Run()
+	if err := chatModel.Run(); err != nil {
+		return err
+	}
+
+	c.coder.recordTurn(ctx, "/design", input, chatModel.GetOutput())
+	return nil
 }
 
-func (c *CommandExecutor) help(_ context.Context, _ string) error {
+func (c *CommandExecutor) help(_ context.Context, input string) error {
+	args, err := splitArgs(input)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		name := "/" + strings.TrimPrefix(args[0], "/")
+		if _, ok := supportCommands[name]; !ok {
+			return errbook.New("Unknown command: %s", name)
+		}
+		c.commandHelp(c.commandSpec(name))
+		return nil
+	}
+
 	console.Render("Available commands:")
+	for _, spec := range builtinCommands {
+		c.renderCommandUsage(spec)
+	}
 
-	commands := []struct {
-		cmd  string
-		desc string
-	}{
-		{"/add <file patterns/URLs>", "Add local files or URLs to chat context"},
-		{"/list", "List files in chat context"},
-		{"/remove <patterns>", "Remove files from context"},
-		{"/ask <question>", "Ask about code in context"},
-		{"/drop", "Clear all files from context"},
-		{"/design <requirements>", "Design system architecture and components"},
-		{"/coding <instructions>", "Code with AI (use for details)"},
-		{"/commit", "Commit changes to version control"},
-		{"/undo", "Revert last code changes"},
-		{"/diff", "Show diffs of context files"},
-		{"/apply <edit blocks>", "Apply AI-generated code edits"},
-		{"/chat-model <model> <api>", "Switch to a new chat mode"},
-		{"/exit", "Exit the terminal"},
-		{"/help", "Show this help message"},
-	}
-
-	for _, cmd := range commands {
-		formatted := fmt.Sprintf("  %-44s", cmd.cmd)
-		console.Render(
-			"%s%s",
-			console.StdoutStyles().Flag.Render(formatted),
-			console.StdoutStyles().FlagDesc.Render(cmd.desc),
-		)
+	if len(c.plugins) > 0 {
+		console.Render("\nPlugin commands:")
+		for _, plugin := range c.plugins {
+			c.renderCommandUsage(plugin.spec())
+		}
 	}
+
+	console.RenderComment("\nMention @path, @glob, @url or @symbol:Name in /ask, /design or /coding to add it for that request only.")
+	console.RenderComment("Start a line with ! to run a shell command, e.g. !go test ./..., and add its output to the next prompt.")
+	console.RenderComment("End a line with \\ or press Alt+Enter to continue on a new line, Ctrl+R searches the input history.")
+	console.RenderComment("Run /<command> --help to show the arguments and flags of a command.")
 	return nil
 }
 
+func (c *CommandExecutor) renderCommandUsage(spec *commandSpec) {
+	formatted := fmt.Sprintf("  %-44s", spec.usage())
+	console.Render(
+		"%s%s",
+		console.StdoutStyles().Flag.Render(formatted),
+		console.StdoutStyles().FlagDesc.Render(spec.desc),
+	)
+}
+
 func (c *CommandExecutor) diff(_ context.Context, _ string) error {
 	// Stage all added files
 	filesToStage := make([]string, 0, len(c.coder.loadedContexts))
@@ -519,6 +691,9 @@ func (c *CommandExecutor) apply(ctx context.Context, codes string) error {
 		return errbook.New("Please provide edit blocks to apply")
 	}
 	openFence, closeFence := chooseExistingFence(codes)
+	if _, ok := c.editor.(*EditBlockCoder); !ok {
+		openFence, closeFence = chooseUsedFence(codes)
+	}
 
 	console.Render("Selected coder block fences %s %s", openFence, closeFence)
 
@@ -543,71 +718,61 @@ func (c *CommandExecutor) apply(ctx context.Context, codes string) error {
 }
 
 func (c *CommandExecutor) exit(_ context.Context, _ string) error {
+	if sb := c.coder.sandbox; sb != nil {
+		console.RenderComment("The sandbox is kept at %s, merge it later with: git merge %s", sb.dir, sb.branch)
+	}
 	fmt.Println("Bye!")
 	os.Exit(0)
 
 	return nil
 }
 
-func (c *CommandExecutor) prepareDesignCompletionMessages(userInput string) ([]llms.ChatMessage, error) {
-	addedFileMessages, err := c.getAddedFileContent()
+func (c *CommandExecutor) prepareDesignCompletionMessages(ctx context.Context, userInput string) ([]llms.ChatMessage, error) {
+	engine, err := c.coder.designEngine()
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := c.buildContext(ctx, engine.GetModel().MaxChars, userInput)
 	if err != nil {
 		return nil, err
 	}
+	addedFileMessages := report.Text()
 
 	messages, err := promptDesign.FormatMessages(map[string]any{
 		addedFilesKey:   addedFileMessages,
 		userQuestionKey: userInput,
+		repoMapKey:      c.coder.renderRepoMap(userInput, addedFileMessages),
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return messages, nil
+	return c.coder.withHistory(ctx, messages), nil
 }
 
-func (c *CommandExecutor) prepareAskCompletionMessages(userInput string) ([]llms.ChatMessage, error) {
-	addedFileMessages, err := c.getAddedFileContent()
+func (c *CommandExecutor) prepareAskCompletionMessages(ctx context.Context, userInput string) ([]llms.ChatMessage, error) {
+	report, err := c.buildContext(ctx, c.coder.engine.GetModel().MaxChars, userInput)
 	if err != nil {
 		return nil, err
 	}
+	addedFileMessages := report.Text()
 
 	messages, err := promptAsk.FormatMessages(map[string]any{
 		addedFilesKey:   addedFileMessages,
 		userQuestionKey: userInput,
+		repoMapKey:      c.coder.renderRepoMap(userInput, addedFileMessages),
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return messages, nil
+	return c.coder.withHistory(ctx, messages), nil
 }
 
-func (c *CommandExecutor) getAddedFileContent() (string, error) {
-	addedFiles := ""
-	if len(c.coder.loadedContexts) > 0 {
-		for _, lc := range c.coder.loadedContexts {
-			filePath := lc.FilePath
-			if lc.Type == convo.ContentTypeURL {
-				filePath = lc.URL
-			}
-			content, err := c.formatFileContent(filePath)
-			if err != nil {
-				return "", err
-			}
-			addedFiles += content
-		}
-	}
-
-	return addedFiles, nil
-}
-
-func (c *CommandExecutor) formatFileContent(filePath string) (string, error) {
-	content, err := c.loadFileContent(filePath)
-	if err != nil {
-		return "", err
-	}
-
+// formatLoadedContent wraps the already loaded content of a file or URL in code fences
+// headed by its relative path or URL.
+func (c *CommandExecutor) formatLoadedContent(filePath, content string) (string, error) {
 	// For remote URLs, use the full URL as the identifier
 	if rest.IsValidURL(filePath) {
 		name := rest.SanitizeURL(filePath)
@@ -627,7 +792,10 @@ func (c *CommandExecutor) formatFileContent(filePath string) (string, error) {
 }
 
 func (c *CommandExecutor) switchNewChatModel(_ context.Context, input string) error {
-	args := strings.Fields(input)
+	args, err := splitArgs(input)
+	if err != nil {
+		return err
+	}
 	if len(args) < 2 {
 		return errbook.New("Please provide both model and api parameters")
 	}
@@ -654,29 +822,33 @@ func (c *CommandExecutor) switchNewChatModel(_ context.Context, input string) er
 	return nil
 }
 
-func (c *CommandExecutor) parseFlags(args []string) (filteredArgs []string) {
-	c.flags = make(map[string]bool)
-
-	for _, arg := range args {
-		if strings.HasPrefix(arg, "--") {
-			c.flags[arg[2:]] = true
-			continue
+// switchEditFormat replaces the coder used by /coding with the one for the given edit format
+func (c *CommandExecutor) switchEditFormat(_ context.Context, input string) error {
+	args, err := splitArgs(input)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		current := c.coder.cfg.AutoCoder.EditFormat
+		if current == "" {
+			current = EditFormatDiff
 		}
-		filteredArgs = append(filteredArgs, arg)
+		console.Render("Current edit format: %s (available: %s)", current, strings.Join(SupportedEditFormats(), ", "))
+		return nil
 	}
 
-	return
-}
-
-func extractCmdArgs(input string) (string, []string) {
-	words := strings.Split(strings.TrimSpace(input), " ")
-	args := []string{}
-	for _, word := range words[1:] {
-		if len(word) > 0 {
-			args = append(args, word)
-		}
+	format := args[0]
+	editor, err := NewCoder(format, c.coder, fences[0])
+	if err != nil {
+		return err
 	}
-	return words[0], args
+
+	c.editor = editor
+	c.coder.cfg.AutoCoder.EditFormat = format
+
+	console.Render("Updated edit format to %s", format)
+
+	return nil
 }
 
 func absFilePath(basePath, matchedFile string) (abs string, err error) {
@@ -5,19 +5,16 @@ import (
 
 	"github.com/elk-language/go-prompt"
 	pstrings "github.com/elk-language/go-prompt/strings"
-
-	"github.com/coding-hui/ai-terminal/internal/git"
+	"github.com/spf13/pflag"
 )
 
 type CommandCompleter struct {
-	cmds []string
-	repo *git.Command
+	executor *CommandExecutor
 }
 
-func NewCommandCompleter(repo *git.Command) CommandCompleter {
+func NewCommandCompleter(executor *CommandExecutor) CommandCompleter {
 	return CommandCompleter{
-		cmds: getSupportedCommands(),
-		repo: repo,
+		executor: executor,
 	}
 }
 
@@ -26,34 +23,56 @@ func (c CommandCompleter) Complete(d prompt.Document) (suggestions []prompt.Sugg
 	w := d.GetWordBeforeCursor()
 	startIndex := endIndex - pstrings.RuneCount([]byte(w))
 
+	before := strings.TrimLeft(d.TextBeforeCursor(), " ")
+	cmd, args, hasArgs := strings.Cut(before, " ")
+
 	// if the input starts with "/", then we use the command completer
-	if strings.HasPrefix(w, "/") {
-		var completions []prompt.Suggest
-		for _, v := range c.cmds {
-			completions = append(completions, prompt.Suggest{Text: v})
-		}
-		return prompt.FilterHasPrefix(completions, w, true), startIndex, endIndex
+	if !hasArgs && strings.HasPrefix(w, "/") {
+		return prompt.FilterHasPrefix(c.executor.commandSuggestions(), w, true), startIndex, endIndex
 	}
 
 	// if the input starts with "@", then we use the file completer
 	if strings.HasPrefix(w, "@") {
-		files, _ := c.repo.ListAllFiles()
+		files, _ := c.executor.coder.repo.ListAllFiles()
 		var completions []prompt.Suggest
 		for _, v := range files {
-			completions = append(completions, prompt.Suggest{Text: v})
+			completions = append(completions, prompt.Suggest{Text: "@" + v})
 		}
-		w = strings.TrimPrefix(w, "@")
 		return prompt.FilterFuzzy(completions, w, true), startIndex, endIndex
 	}
 
-	// if the input starts with "--", then we use the flag completer
-	if strings.HasPrefix(w, "--") {
-		completions := []prompt.Suggest{
-			{Text: "--verbose"},
-			{Text: "--help"},
+	if !hasArgs || !strings.HasPrefix(cmd, "/") {
+		return prompt.FilterHasPrefix([]prompt.Suggest{}, w, true), startIndex, endIndex
+	}
+	spec := c.executor.commandSpec(cmd)
+
+	// if the input starts with "-", then we use the flags of the command
+	if strings.HasPrefix(w, "-") {
+		return prompt.FilterHasPrefix(flagSuggestions(spec), w, true), startIndex, endIndex
+	}
+
+	if spec.complete == nil {
+		return prompt.FilterHasPrefix([]prompt.Suggest{}, w, true), startIndex, endIndex
+	}
+	return prompt.FilterFuzzy(spec.complete(c.executor, argIndex(args, w)), w, true), startIndex, endIndex
+}
+
+// argIndex returns the index of the argument being typed, flags are not counted.
+func argIndex(args, word string) int {
+	index := 0
+	for _, arg := range strings.Fields(strings.TrimSuffix(args, word)) {
+		if !strings.HasPrefix(arg, "-") {
+			index++
 		}
-		return prompt.FilterContains(completions, w, true), startIndex, endIndex
 	}
+	return index
+}
 
-	return prompt.FilterHasPrefix([]prompt.Suggest{}, w, true), startIndex, endIndex
+// flagSuggestions returns the flags of the command.
+func flagSuggestions(spec *commandSpec) []prompt.Suggest {
+	var suggestions []prompt.Suggest
+	spec.flagSet().VisitAll(func(flag *pflag.Flag) {
+		suggestions = append(suggestions, prompt.Suggest{Text: "--" + flag.Name, Description: flag.Usage})
+	})
+	return suggestions
 }
@@ -0,0 +1,348 @@
+package coders
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/repomap"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+	"github.com/coding-hui/ai-terminal/internal/util/rest"
+)
+
+const (
+	contextFull       = "full"
+	contextSummarized = "summary"
+	contextOmitted    = "omitted"
+
+	// contextBudgetShare is the share of the model input budget the loaded files may use,
+	// the rest is left for the prompt, the repository map and the history.
+	contextBudgetShare = 0.75
+
+	// heuristicSummaryLines is the number of leading lines kept when no symbols can be extracted.
+	heuristicSummaryLines = 30
+
+	// charsPerToken is the rough ratio used to estimate token counts from characters.
+	charsPerToken = 4
+)
+
+var questionIdentRegexp = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_.\-/]{2,}`)
+
+// contextEntry is a loaded file or URL measured for a prompt.
+type contextEntry struct {
+	lc      *convo.LoadContext
+	name    string
+	path    string
+	content string
+	full    string
+	text    string
+	status  string
+	score   float64
+}
+
+// contextReport is the result of assembling the loaded contexts for a prompt.
+type contextReport struct {
+	entries []*contextEntry
+	budget  int
+}
+
+// Text returns the assembled content of every entry that made it into the prompt.
+func (r *contextReport) Text() string {
+	var sb strings.Builder
+	for _, e := range r.entries {
+		sb.WriteString(e.text)
+	}
+	return sb.String()
+}
+
+// Chars returns the number of characters used by the assembled content.
+func (r *contextReport) Chars() int {
+	total := 0
+	for _, e := range r.entries {
+		total += len(e.text)
+	}
+	return total
+}
+
+// buildContext loads every file and URL in the chat, plus the extra contexts, and fits them
+// into the maxChars input budget of the model, where zero means unlimited. Files are ranked
+// by their relevance to the question; the ones that don't fit are replaced with summaries,
+// or left out when even those don't fit.
+func (c *CommandExecutor) buildContext(ctx context.Context, maxChars int, question string, extra ...*convo.LoadContext) (*contextReport, error) {
+	report := &contextReport{budget: contextBudget(maxChars, question)}
+
+	contexts := append(append([]*convo.LoadContext{}, c.coder.loadedContexts...), extra...)
+	for _, lc := range contexts {
+		path := lc.FilePath
+		if lc.Type == convo.ContentTypeURL {
+			path = lc.URL
+		}
+
+		content, err := c.loadFileContent(path)
+		if err != nil {
+			return nil, err
+		}
+		full, err := c.formatLoadedContent(path, content)
+		if err != nil {
+			return nil, err
+		}
+
+		name := path
+		if !rest.IsValidURL(path) {
+			if rel, err := filepath.Rel(c.coder.codeBasePath, path); err == nil {
+				name = rel
+			}
+		}
+		if lc.IsReadOnly() {
+			full = fmt.Sprintf("\n%s is read-only, it is only added for reference. Don't edit it.", name) + full
+		}
+
+		report.entries = append(report.entries, &contextEntry{
+			lc:      lc,
+			name:    name,
+			path:    path,
+			content: content,
+			full:    full,
+			score:   relevance(name, content, question),
+		})
+	}
+
+	// Keep the most relevant files in full, the order in the prompt stays the load order
+	ranked := make([]*contextEntry, len(report.entries))
+	copy(ranked, report.entries)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return len(ranked[i].full) < len(ranked[j].full)
+	})
+
+	remaining := report.budget
+	for _, e := range ranked {
+		if report.budget <= 0 || len(e.full) <= remaining {
+			e.text, e.status = e.full, contextFull
+			remaining -= len(e.full)
+			continue
+		}
+
+		summary := c.summarizeContext(ctx, e)
+		if len(summary) <= remaining {
+			e.text, e.status = summary, contextSummarized
+			remaining -= len(summary)
+			continue
+		}
+
+		e.text = fmt.Sprintf("\n%s (left out, it does not fit into the context window)\n", e.name)
+		e.status = contextOmitted
+		remaining -= len(e.text)
+	}
+
+	return report, nil
+}
+
+// contextBudget returns the number of characters the loaded files may use out of
+// maxChars, or 0 when the model has no configured limit.
+func contextBudget(maxChars int, question string) int {
+	if maxChars <= 0 {
+		return 0
+	}
+
+	budget := int(float64(maxChars)*contextBudgetShare) - len(question)
+	if budget < 1 {
+		budget = 1
+	}
+	return budget
+}
+
+// relevance scores a loaded file against the question. Mentions of the file itself
+// weigh most, followed by identifiers of the question that occur in the content.
+func relevance(name, content, question string) float64 {
+	if question == "" {
+		return 0
+	}
+
+	score := 0.0
+	lowerQuestion := strings.ToLower(question)
+	base := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	if strings.Contains(lowerQuestion, strings.ToLower(name)) {
+		score += 20
+	} else if len(base) > 2 && strings.Contains(lowerQuestion, strings.ToLower(base)) {
+		score += 10
+	}
+
+	seen := map[string]struct{}{}
+	for _, ident := range questionIdentRegexp.FindAllString(question, -1) {
+		if _, ok := seen[ident]; ok {
+			continue
+		}
+		seen[ident] = struct{}{}
+		if strings.Contains(content, ident) {
+			score++
+		}
+	}
+
+	return score
+}
+
+// summarizeContext returns the summary of a loaded file, generated by the configured
+// summary model or by a heuristic outline. Summaries are cached per content hash.
+func (c *CommandExecutor) summarizeContext(ctx context.Context, e *contextEntry) string {
+	hash := sha256.Sum256([]byte(e.content))
+	key := hex.EncodeToString(hash[:])
+
+	summary, ok := c.coder.loadSummary(key)
+	if !ok {
+		summary = c.generateSummary(ctx, e)
+		c.coder.storeSummary(key, summary)
+	}
+
+	return fmt.Sprintf("\n%s (summary, ask to add it in full to see or edit its content)%s",
+		e.name, wrapFenceWithType(summary, e.path, c.coder.cfg.AutoCoder.GetDefaultFences()))
+}
+
+func (c *CommandExecutor) generateSummary(ctx context.Context, e *contextEntry) string {
+	if model := c.coder.cfg.AutoCoder.SummaryModel; model != "" {
+		summary, err := c.llmSummary(ctx, model, e)
+		if err == nil && strings.TrimSpace(summary) != "" {
+			return strings.TrimSpace(summary) + "\n"
+		}
+		console.RenderComment("Could not summarize %s with %s, using an outline instead: %v", e.name, model, err)
+	}
+
+	return heuristicSummary(e.path, e.content)
+}
+
+func (c *CommandExecutor) llmSummary(ctx context.Context, model string, e *contextEntry) (string, error) {
+	engine, err := c.coder.engine.ForModel(model)
+	if err != nil {
+		return "", err
+	}
+
+	console.RenderStep("Summarizing %s with %s", e.name, model)
+
+	out, err := engine.Generate(ctx, []llms.ChatMessage{
+		llms.SystemChatMessage{Content: summarizeFilePrompt},
+		llms.HumanChatMessage{Content: e.full},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return out.Explanation, nil
+}
+
+// heuristicSummary outlines the symbols of a source file, or keeps its first lines
+// when no symbols can be extracted.
+func heuristicSummary(path, content string) string {
+	if repomap.Supported(path) {
+		symbols, err := repomap.ExtractSymbols(path, []byte(content))
+		if err == nil && len(symbols) > 0 {
+			var sb strings.Builder
+			for _, sym := range symbols {
+				sb.WriteString(sym.Signature)
+				sb.WriteString("\n")
+			}
+			return sb.String()
+		}
+	}
+
+	lines := strings.Split(content, "\n")
+	if len(lines) <= heuristicSummaryLines {
+		return content
+	}
+	return strings.Join(lines[:heuristicSummaryLines], "\n") +
+		fmt.Sprintf("\n... %d more lines ...\n", len(lines)-heuristicSummaryLines)
+}
+
+// loadSummary returns a cached summary from memory or from the summary cache directory.
+func (a *AutoCoder) loadSummary(key string) (string, bool) {
+	if summary, ok := a.summaries[key]; ok {
+		return summary, true
+	}
+
+	dir := a.summaryCacheDir()
+	if dir == "" {
+		return "", false
+	}
+	data, err := os.ReadFile(filepath.Join(dir, key))
+	if err != nil {
+		return "", false
+	}
+
+	a.summaries[key] = string(data)
+	return string(data), true
+}
+
+// storeSummary caches a summary in memory and, best effort, on disk.
+func (a *AutoCoder) storeSummary(key, summary string) {
+	if a.summaries == nil {
+		a.summaries = map[string]string{}
+	}
+	a.summaries[key] = summary
+
+	dir := a.summaryCacheDir()
+	if dir == "" {
+		return
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil { //nolint:mnd
+		return
+	}
+	_ = os.WriteFile(filepath.Join(dir, key), []byte(summary), 0o600) //nolint:mnd
+}
+
+func (a *AutoCoder) summaryCacheDir() string {
+	if a.cfg == nil || a.cfg.DataStore.CachePath == "" {
+		return ""
+	}
+	mode := "outline"
+	if a.cfg.AutoCoder.SummaryModel != "" {
+		mode = a.cfg.AutoCoder.SummaryModel
+	}
+	return filepath.Join(a.cfg.DataStore.CachePath, "summaries", mode)
+}
+
+// tokens shows how the loaded files fit into the input budget of the coding model.
+func (c *CommandExecutor) tokens(ctx context.Context, input string) error {
+	engine, err := c.coder.codingEngine()
+	if err != nil {
+		return err
+	}
+
+	report, err := c.buildContext(ctx, engine.GetModel().MaxChars, input)
+	if err != nil {
+		return err
+	}
+
+	repoMap := c.coder.renderRepoMap(input, report.Text())
+
+	console.Render("Context of %s:", engine.GetModel().Name)
+	for _, e := range report.entries {
+		console.Render("  %-50s %8d chars  ~%6d tokens  %s", e.name, len(e.text), estimateTokens(len(e.text)), e.status)
+	}
+	if repoMap != "" {
+		console.Render("  %-50s %8d chars  ~%6d tokens", "repository map", len(repoMap), estimateTokens(len(repoMap)))
+	}
+
+	total := report.Chars() + len(repoMap)
+	if report.budget > 0 {
+		console.Render("Total: %d chars (~%d tokens) of %d chars available for files, model limit %d chars",
+			total, estimateTokens(total), report.budget, engine.GetModel().MaxChars)
+	} else {
+		console.Render("Total: %d chars (~%d tokens), the model has no input limit configured", total, estimateTokens(total))
+	}
+
+	return nil
+}
+
+func estimateTokens(chars int) int {
+	return (chars + charsPerToken - 1) / charsPerToken
+}
@@ -0,0 +1,121 @@
+package coders
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/options"
+)
+
+func TestContextBuilder(t *testing.T) {
+	t.Run("unlimited budget keeps everything", testBuildContextUnlimited)
+	t.Run("summarizes files that don't fit", testBuildContextSummarizes)
+	t.Run("extra files share the budget", testBuildContextExtra)
+	t.Run("summaries are cached per content", testSummaryCache)
+	t.Run("heuristic summary", testHeuristicSummary)
+}
+
+func newContextTestExecutor(t *testing.T, files map[string]string) *CommandExecutor {
+	t.Helper()
+
+	coder := newTestAutoCoder(t, &options.Config{DataStore: options.DataStore{CachePath: t.TempDir()}})
+	for name, content := range files {
+		path := filepath.Join(coder.codeBasePath, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		coder.loadedContexts = append(coder.loadedContexts, &convo.LoadContext{
+			Type:     convo.ContentTypeFile,
+			FilePath: path,
+			URL:      path,
+			Name:     name,
+		})
+	}
+
+	return &CommandExecutor{coder: coder, editor: NewEditBlockCoder(coder, fences[0])}
+}
+
+func testBuildContextUnlimited(t *testing.T) {
+	c := newContextTestExecutor(t, map[string]string{
+		"main.go": "package main\n\nfunc main() {}\n",
+	})
+
+	report, err := c.buildContext(context.Background(), 0, "")
+	require.NoError(t, err)
+	require.Len(t, report.entries, 1)
+	assert.Equal(t, contextFull, report.entries[0].status)
+	assert.Contains(t, report.Text(), "func main() {}")
+}
+
+func testBuildContextSummarizes(t *testing.T) {
+	big := "package store\n\n// Save persists things.\nfunc Save(key string) error {\n" +
+		strings.Repeat("\t_ = key\n", 200) + "\treturn nil\n}\n"
+	c := newContextTestExecutor(t, map[string]string{
+		"store.go": big,
+		"main.go":  "package main\n\nfunc main() { run() }\n",
+	})
+
+	report, err := c.buildContext(context.Background(), 600, "fix main")
+	require.NoError(t, err)
+
+	statuses := map[string]string{}
+	for _, e := range report.entries {
+		statuses[e.name] = e.status
+	}
+	assert.Equal(t, contextFull, statuses["main.go"])
+	assert.Equal(t, contextSummarized, statuses["store.go"])
+
+	text := report.Text()
+	assert.Contains(t, text, "func main() { run() }")
+	assert.Contains(t, text, "store.go (summary")
+	assert.Contains(t, text, "func Save(key string) error")
+	assert.NotContains(t, text, "_ = key")
+	assert.LessOrEqual(t, report.Chars(), contextBudget(600, "fix main"))
+}
+
+func testBuildContextExtra(t *testing.T) {
+	c := newContextTestExecutor(t, map[string]string{
+		"main.go": "package main\n\nfunc main() { run() }\n",
+	})
+
+	// a changed file that is not in the chat, as the lint and test fix rounds add them
+	big := "package store\n\nfunc Save(key string) error {\n" + strings.Repeat("\t_ = key\n", 200) + "\treturn nil\n}\n"
+	path := filepath.Join(c.coder.codeBasePath, "store.go")
+	require.NoError(t, os.WriteFile(path, []byte(big), 0o600))
+
+	report, err := c.buildContext(context.Background(), 600, "fix main", &convo.LoadContext{Type: convo.ContentTypeFile, FilePath: path})
+	require.NoError(t, err)
+	require.Len(t, report.entries, 2)
+	assert.Equal(t, contextFull, report.entries[0].status)
+	assert.Equal(t, contextSummarized, report.entries[1].status)
+	assert.Len(t, c.coder.loadedContexts, 1)
+	assert.LessOrEqual(t, report.Chars(), contextBudget(600, "fix main"))
+}
+
+func testSummaryCache(t *testing.T) {
+	c := newContextTestExecutor(t, nil)
+	c.coder.storeSummary("abc", "cached summary\n")
+
+	// a fresh coder with the same cache path finds the summary on disk
+	other := newTestAutoCoder(t, c.coder.cfg)
+	summary, ok := other.loadSummary("abc")
+	require.True(t, ok)
+	assert.Equal(t, "cached summary\n", summary)
+
+	_, ok = other.loadSummary("missing")
+	assert.False(t, ok)
+}
+
+func testHeuristicSummary(t *testing.T) {
+	assert.Equal(t, "func A()\ntype B struct\n", heuristicSummary("a.go", "package a\n\nfunc A() {}\n\ntype B struct{ x int }\n"))
+
+	long := strings.Repeat("line\n", 100)
+	summary := heuristicSummary("notes.txt", long)
+	assert.Contains(t, summary, "more lines")
+	assert.Less(t, len(summary), len(long))
+}
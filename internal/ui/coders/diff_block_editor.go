@@ -17,7 +17,6 @@ import (
 	"github.com/coding-hui/wecoding-sdk-go/services/ai/prompts"
 
 	"github.com/coding-hui/ai-terminal/internal/errbook"
-	"github.com/coding-hui/ai-terminal/internal/ui/chat"
 	"github.com/coding-hui/ai-terminal/internal/ui/console"
 )
 
@@ -39,9 +38,10 @@ type pathAndCode struct {
 }
 
 type EditBlockCoder struct {
-	coder                  *AutoCoder
-	fence                  []string
-	partialResponseContent string
+	coder *AutoCoder
+	fence []string
+	// applied holds the edits of the last Execute that were written to disk
+	applied []PartialCodeBlock
 }
 
 func NewEditBlockCoder(coder *AutoCoder, fence []string) *EditBlockCoder {
@@ -82,19 +82,8 @@ func (e *EditBlockCoder) GetEdits(_ context.Context, codes string, fences []stri
 	return edits, nil
 }
 
-func (e *EditBlockCoder) GetModifiedFiles(ctx context.Context) ([]string, error) {
-	openFence, closeFence := e.coder.determineBeatCodeFences(e.partialResponseContent)
-	edits, err := e.GetEdits(ctx, e.partialResponseContent, []string{openFence, closeFence})
-	if err != nil {
-		return nil, err
-	}
-
-	files := make([]string, 0, len(edits))
-	for _, edit := range edits {
-		files = append(files, edit.Path)
-	}
-
-	return files, nil
+func (e *EditBlockCoder) GetModifiedFiles(_ context.Context) ([]string, error) {
+	return appliedFiles(e.applied), nil
 }
 
 func (e *EditBlockCoder) UpdateCodeFences(_ context.Context, code string) (string, string) {
@@ -105,19 +94,23 @@ func (e *EditBlockCoder) UpdateCodeFences(_ context.Context, code string) (strin
 }
 
 func (e *EditBlockCoder) ApplyEdits(ctx context.Context, edits []PartialCodeBlock) error {
-	var failed []PartialCodeBlock
+	edits, rejected := e.coder.filterEditable(edits)
+	var applied, failed []PartialCodeBlock
 
 	for _, block := range edits {
 		if err := e.applyEdit(ctx, block); err != nil {
 			failed = append(failed, block)
+			continue
 		}
+		applied = append(applied, block)
 	}
+	e.applied = append(e.applied, applied...)
 
 	if len(failed) > 0 {
-		return e.handleFailedEdits(failed)
+		return withRejections(e.handleFailedEdits(applied, failed), applied, rejected)
 	}
 
-	return nil
+	return withRejections(nil, applied, rejected)
 }
 
 func (e *EditBlockCoder) applyEdit(_ context.Context, block PartialCodeBlock) error {
@@ -132,7 +125,7 @@ func (e *EditBlockCoder) applyEdit(_ context.Context, block PartialCodeBlock) er
 	}
 
 	if !fileExists {
-		if ok := console.WaitForUserConfirm(console.Yes, "Whether to create the %s file? (Y/n)", block.Path); ok {
+		if ok := e.coder.confirm(console.Yes, "Whether to create the %s file? (Y/n)", block.Path); ok {
 			if err := fileutil.WriteFile(absPath, []byte("")); err != nil {
 				return err
 			}
@@ -162,7 +155,9 @@ func (e *EditBlockCoder) applyEdit(_ context.Context, block PartialCodeBlock) er
 	return nil
 }
 
-func (e *EditBlockCoder) handleFailedEdits(failed []PartialCodeBlock) error {
+// handleFailedEdits renders why each failed block did not match and returns a
+// *FailedEditsError whose report can be sent back to the model.
+func (e *EditBlockCoder) handleFailedEdits(applied, failed []PartialCodeBlock) error {
 	blocks := "block"
 	if len(failed) > 1 {
 		blocks = "blocks"
@@ -176,8 +171,9 @@ func (e *EditBlockCoder) handleFailedEdits(failed []PartialCodeBlock) error {
 			return err
 		}
 
+		// a missing file is reported like any other mismatch
 		content, err := os.ReadFile(absPath)
-		if err != nil {
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
 			return err
 		}
 
@@ -207,48 +203,39 @@ The REPLACE lines are already in %s!
 The SEARCH section must exactly match an existing block of lines including all white  space, comments, indentation, docstrings, etc.
 `, block.Path)
 		}
-		console.Render("%s", errMsg)
 	}
+	console.Render("%s", errMsg)
 
-	return nil
+	return &FailedEditsError{
+		Applied: applied,
+		Failed:  failed,
+		Report:  errMsg + formatCurrentFiles(e.coder.codeBasePath, failed, e.fence),
+	}
 }
 
 func (e *EditBlockCoder) Execute(ctx context.Context, messages []llms.ChatMessage) error {
-	console.RenderStep("Please wait while we design the code")
-
-	chatModel := chat.NewChat(e.coder.cfg,
-		chat.WithContext(ctx),
-		chat.WithMessages(messages),
-		chat.WithEngine(e.coder.engine),
-		chat.WithCopyToClipboard(true),
-	)
-
-	if err := chatModel.Run(); err != nil {
+	e.applied = nil
+	output, err := generateResponse(ctx, e.coder, messages)
+	if err != nil {
 		return err
 	}
 
-	e.partialResponseContent = chatModel.GetOutput()
-
-	if ok := console.WaitForUserConfirm(console.Yes, "Are you sure you want to apply these codes? (Y/n)"); !ok {
-		return errbook.NewUserErrorf("Apply edit cancelled!")
-	}
+	return applyWithReflection(ctx, e.coder, messages, output, e.applyOutput)
+}
 
-	openFence, closeFence := e.coder.determineBeatCodeFences(e.partialResponseContent)
-	edits, err := e.GetEdits(ctx, e.partialResponseContent, []string{openFence, closeFence})
+// applyOutput parses the SEARCH/REPLACE blocks of a (reflected) model output and applies them.
+func (e *EditBlockCoder) applyOutput(ctx context.Context, output string) ([]PartialCodeBlock, error) {
+	openFence, closeFence := e.coder.determineBeatCodeFences(output)
+	edits, err := e.GetEdits(ctx, output, []string{openFence, closeFence})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if len(edits) <= 0 {
-		return errbook.New("No edits were made")
-	}
-
-	err = e.ApplyEdits(ctx, edits)
-	if err != nil {
-		return err
+		return nil, errbook.New("No edits were made")
 	}
 
-	return nil
+	return edits, e.ApplyEdits(ctx, edits)
 }
 
 func findOriginalUpdateBlocks(content string, fence []string) ([]PartialCodeBlock, error) {
@@ -0,0 +1,139 @@
+package coders
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/coding-hui/ai-terminal/internal/git"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+)
+
+// rejectedEdit is an edit that was refused before it touched the disk.
+type rejectedEdit struct {
+	block  PartialCodeBlock
+	reason string
+}
+
+// filterEditable splits the edits into the ones that may be applied and the ones that
+// are rejected, because they target read-only files, paths outside the repository
+// root or files matched by .gitignore. Every rejection is rendered.
+func (a *AutoCoder) filterEditable(edits []PartialCodeBlock) ([]PartialCodeBlock, []rejectedEdit) {
+	var (
+		allowed  []PartialCodeBlock
+		rejected []rejectedEdit
+		inRepo   []PartialCodeBlock
+	)
+
+	for _, block := range edits {
+		if reason := a.editRejection(block.Path); reason != "" {
+			rejected = append(rejected, rejectedEdit{block: block, reason: reason})
+			continue
+		}
+		inRepo = append(inRepo, block)
+	}
+
+	ignored := a.ignoredPaths(inRepo)
+	for _, block := range inRepo {
+		if _, ok := ignored[filepath.ToSlash(a.relPath(block.Path))]; ok {
+			rejected = append(rejected, rejectedEdit{block: block, reason: "it is ignored by .gitignore"})
+			continue
+		}
+		allowed = append(allowed, block)
+	}
+
+	for _, r := range rejected {
+		console.RenderComment("✗ Rejected edit to %s: %s", r.block.Path, r.reason)
+	}
+
+	return allowed, rejected
+}
+
+// editRejection returns why the path may not be edited, or "" when it may.
+func (a *AutoCoder) editRejection(path string) string {
+	absPath, err := absFilePath(a.codeBasePath, path)
+	if err != nil {
+		return err.Error()
+	}
+	absPath = filepath.Clean(absPath)
+
+	rel, err := filepath.Rel(filepath.Clean(a.codeBasePath), absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "it is outside of the repository root"
+	}
+
+	for _, lc := range a.loadedContexts {
+		if lc.IsReadOnly() && filepath.Clean(lc.FilePath) == absPath {
+			return "it was added as read-only"
+		}
+	}
+
+	return ""
+}
+
+// ignoredPaths returns the relative paths of the edits that are matched by .gitignore.
+func (a *AutoCoder) ignoredPaths(edits []PartialCodeBlock) map[string]struct{} {
+	ignored := map[string]struct{}{}
+	if a.repo == nil || len(edits) == 0 {
+		return ignored
+	}
+
+	paths := make([]string, 0, len(edits))
+	for _, block := range edits {
+		paths = append(paths, a.relPath(block.Path))
+	}
+
+	// the paths are relative to the root, which may not be where the repo command runs;
+	// outside a git repository nothing can be ignored
+	files, err := git.New(git.WithDir(a.codeBasePath)).IgnoredFiles(paths)
+	if err != nil {
+		return ignored
+	}
+	for _, f := range files {
+		ignored[filepath.ToSlash(f)] = struct{}{}
+	}
+
+	return ignored
+}
+
+// relPath returns the path of an edit relative to the repository root.
+func (a *AutoCoder) relPath(path string) string {
+	absPath, err := absFilePath(a.codeBasePath, path)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(a.codeBasePath, absPath)
+	if err != nil {
+		return path
+	}
+	return rel
+}
+
+// withRejections merges the rejected edits into the result of applying the other edits,
+// so they end up in the edit summary and in the report sent back to the model.
+func withRejections(err error, applied []PartialCodeBlock, rejected []rejectedEdit) error {
+	if len(rejected) == 0 {
+		return err
+	}
+
+	report := fmt.Sprintf("# %d edits were rejected!\n\n", len(rejected))
+	var failed []PartialCodeBlock
+	for _, r := range rejected {
+		report += fmt.Sprintf("- %s: %s. Don't edit it.\n", r.block.Path, r.reason)
+		failed = append(failed, r.block)
+	}
+
+	failedErr, ok := err.(*FailedEditsError) //nolint:errorlint
+	if !ok {
+		if err != nil {
+			return err
+		}
+		return &FailedEditsError{Applied: applied, Failed: failed, Report: report}
+	}
+
+	return &FailedEditsError{
+		Applied: failedErr.Applied,
+		Failed:  append(failed, failedErr.Failed...),
+		Report:  report + "\n" + failedErr.Report,
+	}
+}
@@ -0,0 +1,106 @@
+package coders
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/git"
+	"github.com/coding-hui/ai-terminal/internal/options"
+)
+
+func TestEditGuard(t *testing.T) {
+	t.Run("outside repository root", testRejectOutsideRoot)
+	t.Run("read-only files", testRejectReadOnly)
+	t.Run("gitignored files", testRejectGitIgnored)
+	t.Run("withRejections", testWithRejections)
+}
+
+func testRejectOutsideRoot(t *testing.T) {
+	coder := newTestAutoCoder(t, &options.Config{})
+
+	allowed, rejected := coder.filterEditable([]PartialCodeBlock{
+		{Path: "main.go"},
+		{Path: "../other/main.go"},
+		{Path: filepath.Join(filepath.Dir(coder.codeBasePath), "abs.go")},
+	})
+
+	require.Len(t, allowed, 1)
+	assert.Equal(t, "main.go", allowed[0].Path)
+	require.Len(t, rejected, 2)
+	for _, r := range rejected {
+		assert.Equal(t, "it is outside of the repository root", r.reason)
+	}
+}
+
+func testRejectReadOnly(t *testing.T) {
+	coder := newTestAutoCoder(t, &options.Config{})
+	coder.loadedContexts = []*convo.LoadContext{
+		{Type: convo.ContentTypeFile, FilePath: filepath.Join(coder.codeBasePath, "api.go"), Mode: convo.ContextModeReadOnly},
+		{Type: convo.ContentTypeFile, FilePath: filepath.Join(coder.codeBasePath, "impl.go")},
+	}
+
+	allowed, rejected := coder.filterEditable([]PartialCodeBlock{{Path: "api.go"}, {Path: "impl.go"}})
+
+	require.Len(t, allowed, 1)
+	assert.Equal(t, "impl.go", allowed[0].Path)
+	require.Len(t, rejected, 1)
+	assert.Equal(t, "api.go", rejected[0].block.Path)
+	assert.Equal(t, "it was added as read-only", rejected[0].reason)
+}
+
+func testRejectGitIgnored(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	coder := newTestAutoCoder(t, &options.Config{})
+	cmd := exec.Command("git", "init", "-q")
+	cmd.Dir = coder.codeBasePath
+	require.NoError(t, cmd.Run())
+	require.NoError(t, os.WriteFile(filepath.Join(coder.codeBasePath, ".gitignore"), []byte("dist/\n*.log\n"), 0o600))
+	coder.repo = git.New()
+
+	allowed, rejected := coder.filterEditable([]PartialCodeBlock{
+		{Path: "main.go"},
+		{Path: "dist/bundle.js"},
+		{Path: "debug.log"},
+	})
+
+	require.Len(t, allowed, 1)
+	assert.Equal(t, "main.go", allowed[0].Path)
+	require.Len(t, rejected, 2)
+	for _, r := range rejected {
+		assert.Equal(t, "it is ignored by .gitignore", r.reason)
+	}
+}
+
+func testWithRejections(t *testing.T) {
+	rejected := []rejectedEdit{{block: PartialCodeBlock{Path: "api.go"}, reason: "it was added as read-only"}}
+	applied := []PartialCodeBlock{{Path: "impl.go"}}
+
+	assert.NoError(t, withRejections(nil, applied, nil))
+
+	err := withRejections(nil, applied, rejected)
+	var failedErr *FailedEditsError
+	require.True(t, errors.As(err, &failedErr))
+	assert.Equal(t, applied, failedErr.Applied)
+	assert.Equal(t, []PartialCodeBlock{{Path: "api.go"}}, failedErr.Failed)
+	assert.Contains(t, failedErr.Report, "# 1 edits were rejected!")
+	assert.Contains(t, failedErr.Report, "- api.go: it was added as read-only. Don't edit it.")
+
+	err = withRejections(&FailedEditsError{
+		Applied: applied,
+		Failed:  []PartialCodeBlock{{Path: "other.go"}},
+		Report:  "# 1 SEARCH/REPLACE block failed to match!",
+	}, nil, rejected)
+	require.True(t, errors.As(err, &failedErr))
+	assert.Len(t, failedErr.Failed, 2)
+	assert.Contains(t, failedErr.Report, "SEARCH/REPLACE block failed to match")
+}
@@ -71,6 +71,22 @@ func chooseExistingFence(rawContent string) (open string, close string) {
 	return chooseBestFence(rawContent)
 }
 
+// chooseUsedFence returns the first supported fence pair whose open and close fences
+// both appear in the rawContent, falling back to the default fences.
+func chooseUsedFence(rawContent string) (open string, close string) {
+	for _, fence := range fences {
+		openIndex := strings.Index(rawContent, fence[0])
+		if openIndex == -1 {
+			continue
+		}
+		if strings.Contains(rawContent[openIndex+len(fence[0]):], fence[1]) {
+			return fence[0], fence[1]
+		}
+	}
+
+	return defaultBestFence()
+}
+
 func chooseBestFence(rawContent string) (open string, close string) {
 	for _, fence := range fences {
 		if strings.Contains(rawContent, fence[0]) || strings.Contains(rawContent, fence[1]) {
@@ -0,0 +1,251 @@
+package coders
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+)
+
+const (
+	// historySummaryPrefix marks the message that summarizes the older turns.
+	historySummaryPrefix = "Summary of the earlier conversation:\n"
+
+	// historyExcerptLen bounds every turn in a heuristic summary.
+	historyExcerptLen = 200
+)
+
+// historyID returns the convo store ID of the coder history of the current conversation.
+func (a *AutoCoder) historyID() string {
+	if a.cfg.CacheWriteToID == "" {
+		return ""
+	}
+	return convo.CoderHistoryID(a.cfg.CacheWriteToID)
+}
+
+// historyBudget returns the number of characters the replayed history may use.
+func (a *AutoCoder) historyBudget() int {
+	return a.cfg.AutoCoder.GetHistoryTokens() * charsPerToken
+}
+
+// loadHistory returns the stored coder history of the current conversation.
+func (a *AutoCoder) loadHistory(ctx context.Context) ([]llms.ChatMessage, error) {
+	id := a.historyID()
+	if id == "" || a.store == nil {
+		return nil, nil
+	}
+	return a.store.Messages(ctx, id)
+}
+
+// withHistory inserts the most recent turns of the coder history that fit the history
+// budget right before the user question, which is the last of the prompt messages.
+func (a *AutoCoder) withHistory(ctx context.Context, messages []llms.ChatMessage) []llms.ChatMessage {
+	budget := a.historyBudget()
+	if budget <= 0 || len(messages) == 0 {
+		return messages
+	}
+
+	history, err := a.loadHistory(ctx)
+	if err != nil {
+		console.RenderComment("Could not load the chat history: %v", err)
+		return messages
+	}
+
+	replay := fitHistory(history, budget)
+	if len(replay) == 0 {
+		return messages
+	}
+
+	result := make([]llms.ChatMessage, 0, len(messages)+len(replay))
+	result = append(result, messages[:len(messages)-1]...)
+	result = append(result, replay...)
+	return append(result, messages[len(messages)-1])
+}
+
+// fitHistory returns the longest tail of complete turns that fits into budget characters.
+// A leading summary message is kept whenever it fits as well.
+func fitHistory(history []llms.ChatMessage, budget int) []llms.ChatMessage {
+	var summary llms.ChatMessage
+	if len(history) > 0 && isHistorySummary(history[0]) {
+		summary, history = history[0], history[1:]
+	}
+
+	used, start := 0, len(history)
+	for i := len(history) - 1; i >= 0; i-- {
+		size := len(history[i].GetContent())
+		if used+size > budget {
+			break
+		}
+		used += size
+		start = i
+	}
+	// never start the replay with an assistant reply without its request
+	for start < len(history) && history[start].GetType() != llms.ChatMessageTypeHuman {
+		used -= len(history[start].GetContent())
+		start++
+	}
+
+	replay := history[start:]
+	if summary != nil && used+len(summary.GetContent()) <= budget {
+		replay = append([]llms.ChatMessage{summary}, replay...)
+	}
+	return replay
+}
+
+// recordTurn appends a request and its reply to the coder history. When the history
+// grows beyond twice the budget, the older turns are folded into a single summary.
+func (a *AutoCoder) recordTurn(ctx context.Context, command, request, reply string) {
+	budget := a.historyBudget()
+	id := a.historyID()
+	if budget <= 0 || id == "" || a.store == nil || strings.TrimSpace(reply) == "" {
+		return
+	}
+
+	history, err := a.loadHistory(ctx)
+	if err != nil {
+		console.RenderComment("Could not load the chat history: %v", err)
+		return
+	}
+
+	history = append(history,
+		llms.HumanChatMessage{Content: fmt.Sprintf("%s %s", command, strings.TrimSpace(request))},
+		llms.AIChatMessage{Content: strings.TrimSpace(reply)},
+	)
+	if historyChars(history) > 2*budget {
+		history = a.compactHistory(ctx, history, budget)
+	}
+
+	if err := a.store.SetMessages(ctx, id, history); err != nil {
+		console.RenderComment("Could not save the chat history: %v", err)
+	}
+}
+
+// compactHistory keeps the turns that fit into half of the budget and replaces
+// the older ones, together with a previous summary, with a new summary message.
+func (a *AutoCoder) compactHistory(ctx context.Context, history []llms.ChatMessage, budget int) []llms.ChatMessage {
+	recent := fitHistory(history, budget/2)
+	if len(recent) > 0 && isHistorySummary(recent[0]) {
+		recent = recent[1:]
+	}
+	older := history[:len(history)-len(recent)]
+	if len(older) == 0 {
+		return history
+	}
+
+	summary := a.summarizeHistory(ctx, older)
+	if len(summary) > budget/2 {
+		summary = summary[len(summary)-budget/2:]
+	}
+
+	return append([]llms.ChatMessage{llms.SystemChatMessage{Content: historySummaryPrefix + summary}}, recent...)
+}
+
+// summarizeHistory summarizes turns with the summary model, or by listing an excerpt
+// of every request and reply when no summary model is configured.
+func (a *AutoCoder) summarizeHistory(ctx context.Context, turns []llms.ChatMessage) string {
+	if model := a.cfg.AutoCoder.SummaryModel; model != "" && a.engine != nil {
+		engine, err := a.engine.ForModel(model)
+		if err == nil {
+			out, err := engine.Generate(ctx, []llms.ChatMessage{
+				llms.SystemChatMessage{Content: summarizeHistoryPrompt},
+				llms.HumanChatMessage{Content: formatHistory(turns)},
+			})
+			if err == nil && strings.TrimSpace(out.Explanation) != "" {
+				return strings.TrimSpace(out.Explanation)
+			}
+		}
+	}
+
+	var sb strings.Builder
+	for _, msg := range turns {
+		content := msg.GetContent()
+		if isHistorySummary(msg) {
+			sb.WriteString(strings.TrimPrefix(content, historySummaryPrefix))
+			sb.WriteString("\n")
+			continue
+		}
+		excerpt := strings.Join(strings.Fields(content), " ")
+		if len(excerpt) > historyExcerptLen {
+			excerpt = excerpt[:historyExcerptLen] + "..."
+		}
+		fmt.Fprintf(&sb, "- %s: %s\n", historyRole(msg), excerpt)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func isHistorySummary(msg llms.ChatMessage) bool {
+	return msg.GetType() == llms.ChatMessageTypeSystem && strings.HasPrefix(msg.GetContent(), historySummaryPrefix)
+}
+
+func historyChars(history []llms.ChatMessage) int {
+	total := 0
+	for _, msg := range history {
+		total += len(msg.GetContent())
+	}
+	return total
+}
+
+func historyRole(msg llms.ChatMessage) string {
+	switch msg.GetType() {
+	case llms.ChatMessageTypeHuman:
+		return "user"
+	case llms.ChatMessageTypeAI:
+		return "assistant"
+	default:
+		return string(msg.GetType())
+	}
+}
+
+func formatHistory(history []llms.ChatMessage) string {
+	var sb strings.Builder
+	for _, msg := range history {
+		fmt.Fprintf(&sb, "%s:\n%s\n\n", strings.ToUpper(historyRole(msg)), msg.GetContent())
+	}
+	return sb.String()
+}
+
+// clear resets the chat history of the session, the files in the chat are kept.
+func (c *CommandExecutor) clear(ctx context.Context, _ string) error {
+	id := c.coder.historyID()
+	if id == "" {
+		return errbook.New("No conversation is active")
+	}
+
+	if err := c.coder.store.InvalidateMessages(ctx, id); err != nil {
+		return errbook.Wrap("Failed to clear the chat history", err)
+	}
+
+	console.Render("Cleared the chat history, %d files stay in the chat", len(c.coder.loadedContexts))
+	return nil
+}
+
+// history shows the stored chat history of the session.
+func (c *CommandExecutor) history(ctx context.Context, _ string) error {
+	history, err := c.coder.loadHistory(ctx)
+	if err != nil {
+		return errbook.Wrap("Failed to load the chat history", err)
+	}
+
+	if len(history) == 0 {
+		console.Render("The chat history is empty")
+		return nil
+	}
+
+	for _, msg := range history {
+		if isHistorySummary(msg) {
+			console.RenderStep("summary")
+			console.Render("%s", strings.TrimPrefix(msg.GetContent(), historySummaryPrefix))
+			continue
+		}
+		console.RenderStep("%s", historyRole(msg))
+		console.Render("%s", msg.GetContent())
+	}
+
+	console.RenderComment("%d chars stored, up to %d chars are replayed", historyChars(history), c.coder.historyBudget())
+	return nil
+}
@@ -0,0 +1,120 @@
+package coders
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/convo/sqlite3"
+	"github.com/coding-hui/ai-terminal/internal/options"
+)
+
+func TestHistory(t *testing.T) {
+	t.Run("fitHistory", testFitHistory)
+	t.Run("recordAndReplay", testRecordAndReplay)
+	t.Run("compaction", testHistoryCompaction)
+	t.Run("clear keeps files", testClearHistory)
+}
+
+func newHistoryTestCoder(t *testing.T, historyTokens int) *AutoCoder {
+	t.Helper()
+
+	cfg := &options.Config{
+		AutoCoder:      options.AutoCoder{HistoryTokens: historyTokens},
+		CacheWriteToID: convo.NewConversationID(),
+	}
+	store, err := sqlite3.NewSqliteStore(
+		sqlite3.WithContext(context.Background()),
+		sqlite3.WithDataPath(t.TempDir()),
+	)
+	require.NoError(t, err)
+	return NewAutoCoder(WithConfig(cfg), WithCodeBasePath(t.TempDir()), WithStore(store))
+}
+
+func testFitHistory(t *testing.T) {
+	history := []llms.ChatMessage{
+		llms.SystemChatMessage{Content: historySummaryPrefix + "old"},
+		llms.HumanChatMessage{Content: "first question"},
+		llms.AIChatMessage{Content: "first answer"},
+		llms.HumanChatMessage{Content: "second"},
+		llms.AIChatMessage{Content: "reply"},
+	}
+
+	assert.Len(t, fitHistory(history, 1000), 5)
+
+	// only the last turn fits, the summary doesn't
+	replay := fitHistory(history, 12)
+	require.Len(t, replay, 2)
+	assert.Equal(t, "second", replay[0].GetContent())
+
+	// an answer without its question is never replayed
+	replay = fitHistory(history[1:], 17)
+	require.Len(t, replay, 2)
+	assert.Equal(t, llms.ChatMessageTypeHuman, replay[0].GetType())
+
+	assert.Empty(t, fitHistory(history, 3))
+}
+
+func testRecordAndReplay(t *testing.T) {
+	ctx := context.Background()
+	coder := newHistoryTestCoder(t, 0)
+
+	coder.recordTurn(ctx, "/ask", "what does main do?", "it prints hello")
+	coder.recordTurn(ctx, "/coding", "print bye", "")
+
+	history, err := coder.loadHistory(ctx)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "/ask what does main do?", history[0].GetContent())
+
+	messages := coder.withHistory(ctx, []llms.ChatMessage{
+		llms.SystemChatMessage{Content: "system"},
+		llms.HumanChatMessage{Content: "new question"},
+	})
+	require.Len(t, messages, 4)
+	assert.Equal(t, "system", messages[0].GetContent())
+	assert.Equal(t, "it prints hello", messages[2].GetContent())
+	assert.Equal(t, "new question", messages[3].GetContent())
+
+	disabled := newHistoryTestCoder(t, -1)
+	disabled.recordTurn(ctx, "/ask", "q", "a")
+	history, err = disabled.loadHistory(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+}
+
+func testHistoryCompaction(t *testing.T) {
+	ctx := context.Background()
+	coder := newHistoryTestCoder(t, 25) // 100 chars
+
+	for i := 0; i < 6; i++ {
+		coder.recordTurn(ctx, "/ask", strings.Repeat("q", 20), strings.Repeat("a", 20))
+	}
+
+	history, err := coder.loadHistory(ctx)
+	require.NoError(t, err)
+	require.True(t, isHistorySummary(history[0]))
+	assert.Contains(t, history[0].GetContent(), "- assistant: aaa")
+	assert.LessOrEqual(t, historyChars(history), 2*coder.historyBudget()+len(historySummaryPrefix))
+	assert.Equal(t, llms.ChatMessageTypeAI, history[len(history)-1].GetType())
+}
+
+func testClearHistory(t *testing.T) {
+	ctx := context.Background()
+	coder := newHistoryTestCoder(t, 0)
+	coder.loadedContexts = []*convo.LoadContext{{Name: "main.go"}}
+	c := &CommandExecutor{coder: coder}
+
+	coder.recordTurn(ctx, "/ask", "q", "a")
+	require.NoError(t, c.clear(ctx, ""))
+
+	history, err := coder.loadHistory(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+	assert.Len(t, coder.loadedContexts, 1)
+}
@@ -0,0 +1,91 @@
+package coders
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/runner"
+	"github.com/coding-hui/ai-terminal/internal/system"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+)
+
+// defaultEditCommand sends the input written with /edit when it doesn't start with a command.
+const defaultEditCommand = "/ask"
+
+// historyFile returns the file the REPL input history of the repository is kept in.
+// A sandbox shares the history of the repository it was started from.
+func (a *AutoCoder) historyFile() string {
+	if a.cfg == nil || a.cfg.DataStore.CachePath == "" {
+		return ""
+	}
+
+	root := a.codeBasePath
+	if a.sandbox != nil {
+		root = a.sandbox.root
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+
+	sum := sha1.Sum([]byte(root))
+	name := filepath.Base(root) + "-" + hex.EncodeToString(sum[:])[:12]
+	return filepath.Join(a.cfg.DataStore.CachePath, "history", name)
+}
+
+// edit opens $EDITOR to write a multi-line input and runs it. Input that doesn't start
+// with a command is sent with the given command, /ask by default.
+func (c *CommandExecutor) edit(ctx context.Context, input string) error {
+	args, err := splitArgs(input)
+	if err != nil {
+		return err
+	}
+	command := defaultEditCommand
+	if len(args) > 0 {
+		command = "/" + strings.TrimPrefix(args[0], "/")
+		if _, ok := supportCommands[command]; !ok {
+			return errbook.New("Unknown command: %s", command)
+		}
+	}
+
+	text, err := editInput(system.GetEditor())
+	if err != nil {
+		return err
+	}
+	if text == "" {
+		console.Render("Nothing was written, edit canceled")
+		return nil
+	}
+
+	if !c.isCommand(text) {
+		text = command + " " + text
+	}
+	return c.Execute(ctx, text)
+}
+
+// editInput lets the user write an input in editor and returns it trimmed.
+func editInput(editor string) (string, error) {
+	f, err := os.CreateTemp("", "ai-terminal-input-*.md")
+	if err != nil {
+		return "", errbook.Wrap("Failed to create the input file", err)
+	}
+	path := f.Name()
+	_ = f.Close()
+	defer os.Remove(path)
+
+	cmd := runner.PrepareEditSettingsCommand(editor, path)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", errbook.Wrap("Failed to run the editor "+editor, err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", errbook.Wrap("Failed to read the input file", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
@@ -0,0 +1,63 @@
+package coders
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coding-hui/ai-terminal/internal/options"
+)
+
+func TestInput(t *testing.T) {
+	t.Run("history file per repository", testHistoryFile)
+	t.Run("edit runs the written input", testEdit)
+}
+
+func testHistoryFile(t *testing.T) {
+	cache := t.TempDir()
+	a := newTestAutoCoder(t, &options.Config{DataStore: options.DataStore{CachePath: cache}})
+	b := newTestAutoCoder(t, &options.Config{DataStore: options.DataStore{CachePath: cache}})
+
+	assert.Equal(t, filepath.Join(cache, "history"), filepath.Dir(a.historyFile()))
+	assert.NotEqual(t, a.historyFile(), b.historyFile())
+
+	file := a.historyFile()
+	a.sandbox = &sandbox{root: a.codeBasePath}
+	a.codeBasePath = t.TempDir()
+	assert.Equal(t, file, a.historyFile())
+
+	assert.Empty(t, newTestAutoCoder(t, &options.Config{}).historyFile())
+}
+
+func writeEditor(t *testing.T, content string) {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "input"), []byte(content), 0o600))
+	editor := filepath.Join(dir, "editor")
+	require.NoError(t, os.WriteFile(editor, []byte("#!/bin/sh\ncp \""+filepath.Join(dir, "input")+"\" \"$1\"\n"), 0o700))
+	t.Setenv("EDITOR", editor)
+}
+
+func testEdit(t *testing.T) {
+	c := newShellTestExecutor(t)
+
+	writeEditor(t, "echo one\necho two\n")
+	require.NoError(t, c.Execute(context.Background(), "/edit /run"))
+	require.Len(t, c.shellOutputs, 1)
+	assert.Equal(t, "one\ntwo\n", c.shellOutputs[0].output)
+
+	writeEditor(t, "/run echo three")
+	require.NoError(t, c.Execute(context.Background(), "/edit"))
+	require.Len(t, c.shellOutputs, 2)
+	assert.Equal(t, "three\n", c.shellOutputs[1].output)
+
+	writeEditor(t, "  \n")
+	require.NoError(t, c.Execute(context.Background(), "/edit"))
+	assert.Len(t, c.shellOutputs, 2)
+
+	assert.Error(t, c.Execute(context.Background(), "/edit /nope"))
+}
@@ -0,0 +1,150 @@
+package coders
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/repomap"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+	"github.com/coding-hui/ai-terminal/internal/util/rest"
+)
+
+const (
+	mentionPrefix       = "@"
+	symbolMentionPrefix = "symbol:"
+)
+
+// mentionCommands are the commands whose input may reference files with @mentions.
+var mentionCommands = map[string]struct{}{
+	"/ask":       {},
+	"/design":    {},
+	"/coding":    {},
+	"/architect": {},
+}
+
+// parseMentions splits the @mentions from the input. The mentions are returned without
+// their @ prefix, the question keeps the mentioned names so it still reads naturally.
+func parseMentions(input string) (question string, mentions []string) {
+	fields := strings.Fields(input)
+	for i, field := range fields {
+		if !strings.HasPrefix(field, mentionPrefix) || len(field) == len(mentionPrefix) {
+			continue
+		}
+		mention := strings.TrimRight(strings.TrimPrefix(field, mentionPrefix), ",;:?!)")
+		if mention == "" {
+			continue
+		}
+		mentions = append(mentions, mention)
+		fields[i] = mention + field[len(mentionPrefix)+len(mention):]
+	}
+	return strings.Join(fields, " "), mentions
+}
+
+// withMentions adds the files, URLs and symbols mentioned in the input to the chat as
+// read-only contexts for a single turn. They are not persisted, the returned function
+// removes them again once the turn is over.
+func (c *CommandExecutor) withMentions(input string) (string, func(), error) {
+	question, mentions := parseMentions(input)
+	if len(mentions) == 0 {
+		return input, func() {}, nil
+	}
+
+	var added []*convo.LoadContext
+	cleanup := func() {
+		kept := c.coder.loadedContexts[:0]
+		for _, lc := range c.coder.loadedContexts {
+			drop := false
+			for _, a := range added {
+				if lc == a {
+					drop = true
+					break
+				}
+			}
+			if !drop {
+				kept = append(kept, lc)
+			}
+		}
+		c.coder.loadedContexts = kept
+	}
+
+	for _, mention := range mentions {
+		paths, err := c.resolveMention(mention)
+		if err != nil {
+			cleanup()
+			return "", nil, err
+		}
+
+		for _, path := range paths {
+			if c.isLoaded(path) {
+				continue
+			}
+			lc := &convo.LoadContext{
+				Type:     convo.ContentTypeFile,
+				FilePath: path,
+				URL:      path,
+				Name:     filepath.Base(path),
+				Mode:     convo.ContextModeReadOnly,
+			}
+			if rest.IsValidURL(path) {
+				lc.Type, lc.FilePath = convo.ContentTypeURL, ""
+			}
+			c.coder.loadedContexts = append(c.coder.loadedContexts, lc)
+			added = append(added, lc)
+		}
+	}
+
+	if len(added) > 0 {
+		console.RenderStep("Added %d mentioned files for this request", len(added))
+	}
+
+	return question, cleanup, nil
+}
+
+// resolveMention returns the absolute paths or URLs a single mention refers to.
+func (c *CommandExecutor) resolveMention(mention string) ([]string, error) {
+	if rest.IsValidURL(mention) {
+		return []string{mention}, nil
+	}
+
+	if strings.HasPrefix(mention, symbolMentionPrefix) {
+		return c.resolveSymbol(strings.TrimPrefix(mention, symbolMentionPrefix))
+	}
+
+	matches, err := filepath.Glob(filepath.Join(c.coder.codeBasePath, mention))
+	if err != nil {
+		return nil, errbook.Wrap("Failed to glob files", err)
+	}
+	if len(matches) == 0 {
+		return nil, errbook.New("No files matched @%s", mention)
+	}
+
+	return matches, nil
+}
+
+// resolveSymbol finds the Go files that declare the named top-level symbol.
+// Methods can be given as Type.Method.
+func (c *CommandExecutor) resolveSymbol(name string) ([]string, error) {
+	if name == "" {
+		return nil, errbook.New("Please name the symbol, e.g. @symbol:NewEngine")
+	}
+
+	if c.coder.repoMap == nil {
+		c.coder.repoMap = repomap.New(c.coder.codeBasePath)
+	}
+	if err := c.coder.repoMap.Refresh(); err != nil {
+		return nil, errbook.Wrap("Failed to index the repository", err)
+	}
+
+	files := c.coder.repoMap.FindSymbol(name)
+	if len(files) == 0 {
+		return nil, errbook.New("Symbol %s is not defined in this repository", name)
+	}
+
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, filepath.Join(c.coder.codeBasePath, f))
+	}
+	return paths, nil
+}
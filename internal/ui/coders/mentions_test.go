@@ -0,0 +1,98 @@
+package coders
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/options"
+	"github.com/coding-hui/ai-terminal/internal/repomap"
+)
+
+func TestMentions(t *testing.T) {
+	t.Run("parseMentions", testParseMentions)
+	t.Run("withMentions", testWithMentions)
+	t.Run("symbol mentions", testSymbolMentions)
+	t.Run("unknown mention", testUnknownMention)
+}
+
+func testParseMentions(t *testing.T) {
+	question, mentions := parseMentions("explain @main.go, and @internal/*.go please @ user@example.com")
+	assert.Equal(t, []string{"main.go", "internal/*.go"}, mentions)
+	assert.Equal(t, "explain main.go, and internal/*.go please @ user@example.com", question)
+
+	question, mentions = parseMentions("fix @symbol:Engine.Run?")
+	assert.Equal(t, []string{"symbol:Engine.Run"}, mentions)
+	assert.Equal(t, "fix symbol:Engine.Run?", question)
+}
+
+func newMentionsTestExecutor(t *testing.T) *CommandExecutor {
+	t.Helper()
+
+	coder := newTestAutoCoder(t, &options.Config{})
+	files := map[string]string{
+		"main.go":        "package main\n\nfunc main() {}\n",
+		"engine.go":      "package main\n\ntype Engine struct{}\n\nfunc (e *Engine) Run() {}\n",
+		"docs/readme.md": "# readme\n",
+	}
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(coder.codeBasePath, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		paths = append(paths, name)
+	}
+	coder.repoMap = repomap.New(coder.codeBasePath, repomap.WithFileLister(func() ([]string, error) {
+		return paths, nil
+	}))
+
+	return &CommandExecutor{coder: coder}
+}
+
+func testWithMentions(t *testing.T) {
+	c := newMentionsTestExecutor(t)
+	persisted := &convo.LoadContext{Type: convo.ContentTypeFile, FilePath: filepath.Join(c.coder.codeBasePath, "main.go")}
+	c.coder.loadedContexts = []*convo.LoadContext{persisted}
+
+	question, cleanup, err := c.withMentions("compare @*.go with @docs/readme.md")
+	require.NoError(t, err)
+	assert.Equal(t, "compare *.go with docs/readme.md", question)
+
+	// main.go was already added and is not added twice
+	require.Len(t, c.coder.loadedContexts, 3)
+	assert.False(t, persisted.IsReadOnly())
+	assert.True(t, c.findLoaded(filepath.Join(c.coder.codeBasePath, "engine.go")).IsReadOnly())
+
+	cleanup()
+	require.Len(t, c.coder.loadedContexts, 1)
+	assert.Same(t, persisted, c.coder.loadedContexts[0])
+}
+
+func testSymbolMentions(t *testing.T) {
+	c := newMentionsTestExecutor(t)
+
+	paths, err := c.resolveMention("symbol:Engine.Run")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(c.coder.codeBasePath, "engine.go")}, paths)
+
+	paths, err = c.resolveMention("symbol:main")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(c.coder.codeBasePath, "main.go")}, paths)
+
+	_, err = c.resolveMention("symbol:Missing")
+	assert.Error(t, err)
+	_, err = c.resolveMention("symbol:Main.Run")
+	assert.Error(t, err)
+}
+
+func testUnknownMention(t *testing.T) {
+	c := newMentionsTestExecutor(t)
+
+	_, _, err := c.withMentions("look at @main.go and @nope.go")
+	require.Error(t, err)
+	assert.Empty(t, c.coder.loadedContexts)
+}
@@ -0,0 +1,348 @@
+package coders
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/options"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+)
+
+const (
+	// pluginDirName is the directory next to the config file that holds the plugin commands.
+	pluginDirName = "commands"
+
+	// pluginHeaderLines bounds how many leading lines of a script are read for its header.
+	pluginHeaderLines = 30
+
+	// pluginEnvPrefix prefixes the environment variables passed to plugin scripts.
+	pluginEnvPrefix = "AI_TERMINAL_"
+)
+
+// pluginCommand is a slash command defined by the user. It is either a YAML prompt macro,
+// whose rendered prompt is sent like /ask or /coding, or an executable script.
+type pluginCommand struct {
+	Name        string      `yaml:"name"`
+	Description string      `yaml:"description"`
+	Args        []pluginArg `yaml:"args"`
+	// Prompt is a Go template with .args, .input, .files and .diff
+	Prompt string `yaml:"prompt"`
+	// Apply sends the prompt with /coding, or applies the script output as edit blocks
+	Apply bool `yaml:"apply"`
+
+	// script is the executable of a script command, empty for prompt macros
+	script string
+}
+
+// pluginArg is a declared argument of a plugin command.
+type pluginArg struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Required    bool   `yaml:"required"`
+	Default     string `yaml:"default"`
+}
+
+// pluginDir returns the directory the plugin commands are loaded from.
+func pluginDir(cfg *options.Config) string {
+	if cfg == nil || cfg.SettingsPath == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(cfg.SettingsPath), pluginDirName)
+}
+
+// loadPlugins loads the plugin commands in dir, sorted by name. Files that can't be
+// loaded are reported as errors without stopping the others from loading.
+func loadPlugins(dir string) ([]*pluginCommand, []error) {
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, []error{err}
+	}
+
+	var (
+		plugins []*pluginCommand
+		errs    []error
+	)
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+
+		var plugin *pluginCommand
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml":
+			plugin, err = loadPromptPlugin(path)
+		default:
+			plugin, err = loadScriptPlugin(path)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.Name(), err))
+			continue
+		}
+		if plugin != nil {
+			plugins = append(plugins, plugin)
+		}
+	}
+
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Name < plugins[j].Name
+	})
+	return plugins, errs
+}
+
+func loadPromptPlugin(path string) (*pluginCommand, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	plugin := &pluginCommand{}
+	if err := yaml.Unmarshal(data, plugin); err != nil {
+		return nil, err
+	}
+	if plugin.Name == "" {
+		plugin.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if strings.TrimSpace(plugin.Prompt) == "" {
+		return nil, fmt.Errorf("prompt is empty")
+	}
+	if _, err := template.New(plugin.Name).Option("missingkey=zero").Parse(plugin.Prompt); err != nil {
+		return nil, err
+	}
+
+	return plugin, nil
+}
+
+// loadScriptPlugin loads an executable script. Its leading comment lines may declare
+// the command with `description:`, `arg:` (name, optional `?` suffix and description)
+// and `apply: true` entries. Files that are not executable are skipped.
+func loadScriptPlugin(path string) (*pluginCommand, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode()&0o111 == 0 {
+		return nil, nil
+	}
+
+	plugin := &pluginCommand{
+		Name:   strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		script: path,
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck
+
+	scanner := bufio.NewScanner(f)
+	for i := 0; i < pluginHeaderLines && scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "//") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimLeft(line, "#/ "), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.TrimSpace(key) {
+		case "description":
+			plugin.Description = value
+		case "apply":
+			plugin.Apply, _ = strconv.ParseBool(value)
+		case "arg":
+			name, desc, _ := strings.Cut(value, " ")
+			arg := pluginArg{Name: strings.TrimSuffix(name, "?"), Description: strings.TrimSpace(desc), Required: !strings.HasSuffix(name, "?")}
+			plugin.Args = append(plugin.Args, arg)
+		}
+	}
+
+	return plugin, scanner.Err()
+}
+
+// spec returns the spec of the command, plugins take free text and only --help.
+func (p *pluginCommand) spec() *commandSpec {
+	var args []string
+	for _, arg := range p.Args {
+		if arg.Required {
+			args = append(args, "<"+arg.Name+">")
+		} else {
+			args = append(args, "["+arg.Name+"]")
+		}
+	}
+	return &commandSpec{name: "/" + p.Name, args: strings.Join(args, " "), desc: p.Description}
+}
+
+// words splits the input into shell words. Free text with unbalanced quotes, like an
+// apostrophe in a question, falls back to the plain words.
+func (p *pluginCommand) words(input string) []string {
+	words, err := splitArgs(input)
+	if err != nil {
+		return strings.Fields(input)
+	}
+	return words
+}
+
+// bindArgs assigns the words of the input to the declared arguments in order.
+// The last argument takes the rest of the input.
+func (p *pluginCommand) bindArgs(input string) (map[string]string, error) {
+	words := p.words(input)
+	args := make(map[string]string, len(p.Args))
+
+	for i, arg := range p.Args {
+		switch {
+		case i >= len(words):
+			if arg.Required {
+				return nil, errbook.New("Missing argument %s, usage: %s", arg.Name, p.spec().usage())
+			}
+			args[arg.Name] = arg.Default
+		case i == len(p.Args)-1:
+			args[arg.Name] = strings.Join(words[i:], " ")
+		default:
+			args[arg.Name] = words[i]
+		}
+	}
+
+	return args, nil
+}
+
+// run executes the plugin with the given input.
+func (p *pluginCommand) run(ctx context.Context, c *CommandExecutor, input string) error {
+	args, err := p.bindArgs(input)
+	if err != nil {
+		return err
+	}
+
+	if p.script != "" {
+		output, err := p.runScript(ctx, c, args, input)
+		if err != nil {
+			return errbook.Wrap(fmt.Sprintf("Plugin /%s failed: %s", p.Name, strings.TrimSpace(output)), err)
+		}
+		if p.Apply {
+			return c.apply(ctx, output)
+		}
+		fmt.Print(output)
+		return nil
+	}
+
+	prompt, err := p.renderPrompt(ctx, c, args, input)
+	if err != nil {
+		return err
+	}
+	if p.Apply {
+		return c.coding(ctx, prompt)
+	}
+	return c.ask(ctx, prompt)
+}
+
+// renderPrompt renders the prompt template of a macro.
+func (p *pluginCommand) renderPrompt(ctx context.Context, c *CommandExecutor, args map[string]string, input string) (string, error) {
+	tmpl, err := template.New(p.Name).Option("missingkey=zero").Parse(p.Prompt)
+	if err != nil {
+		return "", errbook.Wrap(fmt.Sprintf("Invalid prompt of plugin /%s", p.Name), err)
+	}
+
+	data := map[string]any{
+		"args":  args,
+		"input": input,
+		"files": "",
+		"diff":  "",
+	}
+	if strings.Contains(p.Prompt, ".files") {
+		report, err := c.buildContext(ctx, 0, input)
+		if err != nil {
+			return "", err
+		}
+		data["files"] = report.Text()
+	}
+	if strings.Contains(p.Prompt, ".diff") && c.coder.repo != nil {
+		// a repository without commits has no diff yet
+		data["diff"], _ = c.coder.repo.WorkingDiff()
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", errbook.Wrap(fmt.Sprintf("Failed to render the prompt of plugin /%s", p.Name), err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// runScript runs the script in the repository root. The words of the input are passed
+// as arguments, the declared arguments, the root and the files in the chat as
+// AI_TERMINAL_* environment variables.
+func (p *pluginCommand) runScript(ctx context.Context, c *CommandExecutor, args map[string]string, input string) (string, error) {
+	cmd := exec.CommandContext(ctx, p.script, p.words(input)...)
+	cmd.Dir = c.coder.codeBasePath
+
+	var files []string
+	for _, lc := range c.coder.loadedContexts {
+		if lc.Type == convo.ContentTypeFile {
+			files = append(files, lc.FilePath)
+		}
+	}
+
+	cmd.Env = append(os.Environ(),
+		pluginEnvPrefix+"ROOT="+c.coder.codeBasePath,
+		pluginEnvPrefix+"FILES="+strings.Join(files, "\n"),
+		pluginEnvPrefix+"INPUT="+input,
+	)
+	for name, value := range args {
+		cmd.Env = append(cmd.Env, pluginEnvPrefix+"ARG_"+envName(name)+"="+value)
+	}
+
+	console.RenderStep("Running plugin /%s", p.Name)
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+func envName(name string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name))
+}
+
+// registerPlugins loads the plugin commands and registers the ones that don't shadow
+// a built-in command.
+func (c *CommandExecutor) registerPlugins() {
+	plugins, errs := loadPlugins(pluginDir(c.coder.cfg))
+	for _, err := range errs {
+		console.RenderComment("Skipped plugin command %v", err)
+	}
+
+	for _, plugin := range plugins {
+		name := "/" + plugin.Name
+		if _, ok := supportCommands[name]; ok {
+			console.RenderComment("Skipped plugin command %s, it shadows a built-in command", name)
+			continue
+		}
+		supportCommands[name] = func(ctx context.Context, input string) error {
+			return plugin.run(ctx, c, input)
+		}
+		c.plugins = append(c.plugins, plugin)
+	}
+}
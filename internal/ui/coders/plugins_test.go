@@ -0,0 +1,134 @@
+package coders
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlugins(t *testing.T) {
+	t.Run("load plugins", testLoadPlugins)
+	t.Run("invalid plugins are skipped", testInvalidPlugins)
+	t.Run("bind args", testBindArgs)
+	t.Run("render prompt", testRenderPrompt)
+	t.Run("run script", testRunScriptPlugin)
+}
+
+func writePlugin(t *testing.T, dir, name, content string, perm os.FileMode) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), perm))
+}
+
+func testLoadPlugins(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "review.yaml", `
+description: Review the changes
+args:
+  - name: focus
+    default: bugs
+prompt: Review this diff for {{ .args.focus }}:\n{{ .diff }}
+`, 0o600)
+	writePlugin(t, dir, "fmt.sh", `#!/bin/sh
+# description: Format a file
+# arg: path the file to format
+# arg: style? the style to use
+# apply: true
+echo formatted
+`, 0o700)
+	writePlugin(t, dir, "notes.txt", "not a command", 0o600)
+
+	plugins, errs := loadPlugins(dir)
+	require.Empty(t, errs)
+	require.Len(t, plugins, 2)
+
+	script := plugins[0]
+	assert.Equal(t, "fmt", script.Name)
+	assert.Equal(t, "Format a file", script.Description)
+	assert.True(t, script.Apply)
+	assert.Equal(t, []pluginArg{
+		{Name: "path", Description: "the file to format", Required: true},
+		{Name: "style", Description: "the style to use"},
+	}, script.Args)
+	assert.Equal(t, "/fmt <path> [style]", script.spec().usage())
+
+	macro := plugins[1]
+	assert.Equal(t, "review", macro.Name)
+	assert.Equal(t, "Review the changes", macro.Description)
+	assert.False(t, macro.Apply)
+	assert.Empty(t, macro.script)
+}
+
+func testInvalidPlugins(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "empty.yaml", "description: no prompt\n", 0o600)
+	writePlugin(t, dir, "broken.yml", "prompt: '{{ .args.x '\n", 0o600)
+	writePlugin(t, dir, "ok.yaml", "name: fine\nprompt: hello\n", 0o600)
+
+	plugins, errs := loadPlugins(dir)
+	assert.Len(t, errs, 2)
+	require.Len(t, plugins, 1)
+	assert.Equal(t, "fine", plugins[0].Name)
+
+	plugins, errs = loadPlugins(filepath.Join(dir, "missing"))
+	assert.Empty(t, plugins)
+	assert.Empty(t, errs)
+}
+
+func testBindArgs(t *testing.T) {
+	plugin := &pluginCommand{
+		Name: "explain",
+		Args: []pluginArg{
+			{Name: "file", Required: true},
+			{Name: "question", Default: "what does it do?"},
+		},
+	}
+
+	args, err := plugin.bindArgs("main.go why is it so long")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"file": "main.go", "question": "why is it so long"}, args)
+
+	args, err = plugin.bindArgs("main.go")
+	require.NoError(t, err)
+	assert.Equal(t, "what does it do?", args["question"])
+
+	_, err = plugin.bindArgs("")
+	assert.ErrorContains(t, err, "Missing argument file, usage: /explain <file> [question]")
+}
+
+func testRenderPrompt(t *testing.T) {
+	c := newContextTestExecutor(t, map[string]string{
+		"main.go": "package main\n\nfunc main() {}\n",
+	})
+	plugin := &pluginCommand{
+		Name:   "doc",
+		Prompt: "Document {{ .args.symbol }} ({{ .input }}).\n{{ .files }}",
+	}
+
+	prompt, err := plugin.renderPrompt(context.Background(), c, map[string]string{"symbol": "main"}, "main please")
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "Document main (main please).")
+	assert.Contains(t, prompt, "func main() {}")
+}
+
+func testRunScriptPlugin(t *testing.T) {
+	c := newContextTestExecutor(t, map[string]string{"main.go": "package main\n"})
+
+	dir := t.TempDir()
+	writePlugin(t, dir, "echo.sh", `#!/bin/sh
+# arg: name
+echo "$1 $AI_TERMINAL_ARG_NAME $(basename "$AI_TERMINAL_FILES") $(basename "$PWD")"
+`, 0o700)
+	plugins, errs := loadPlugins(dir)
+	require.Empty(t, errs)
+	require.Len(t, plugins, 1)
+
+	args, err := plugins[0].bindArgs("world")
+	require.NoError(t, err)
+	output, err := plugins[0].runScript(context.Background(), c, args, "world")
+	require.NoError(t, err)
+	assert.Equal(t, "world world main.go "+filepath.Base(c.coder.codeBasePath)+"\n", output)
+}
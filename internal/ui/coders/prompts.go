@@ -11,6 +11,7 @@ const (
 	lazyPromptKey   = "lazy_prompt"
 	openFenceKey    = "open_fence"
 	closeFenceKey   = "close_fence"
+	repoMapKey      = "repo_map"
 
 	lazyPrompt = `You are diligent and tireless!
 You NEVER leave comments describing code without implementing it!
@@ -50,6 +51,108 @@ If you want to put code in a new file, use a *SEARCH/REPLACE block* with:
 - The new file's contents in the REPLACE section
 
 ONLY EVER RETURN CODE IN A *SEARCH/REPLACE BLOCK*!
+`
+
+	shellOutputPrompt = `I ran this command:
+
+%s
+
+It exited with status %d and printed:
+
+%s
+`
+
+	checkFailedPrompt = `I ran this command:
+
+%s
+
+And got this output:
+
+%s
+
+Fix the problems reported in the output.
+`
+
+	wholeFileReminderPrompt = `# *file listing* Rules:
+
+To suggest changes to a file you MUST return the entire content of the updated file.
+You MUST use this *file listing* format:
+
+path/to/filename.js
+{{ .open_fence }}javascript
+// entire file content ...
+// ... goes in between
+{{ .close_fence }}
+
+Every *file listing* MUST use this format:
+- First line: the filename with any originally provided path; no extra markup, punctuation, comments, etc. **JUST** the filename with path.
+- Second line: opening {{ .open_fence }}
+- ... entire content of the file ...
+- Final line: closing {{ .close_fence }}
+
+To suggest changes to a file you MUST return a *file listing* that contains the entire content of the file.
+*NEVER* skip, omit or elide content from a *file listing* using "..." or by adding comments like "... rest of code..."!
+Create a new file you MUST return a *file listing* which includes an appropriate filename, including any appropriate path.
+`
+
+	unifiedDiffReminderPrompt = `# File editing rules:
+
+Return edits similar to unified diffs that diff -U0 would produce.
+
+Make sure you include the first 2 lines with the file paths.
+Don't include timestamps with the file paths.
+
+Start each hunk of changes with a ` + "`@@ ... @@`" + ` line.
+Don't include line numbers like ` + "`diff -U0`" + ` does.
+The user's patch tool doesn't need them.
+
+The user's patch tool needs CORRECT patches that apply cleanly against the current contents of the file!
+Think carefully and make sure you include and mark all lines that need to be removed or changed as ` + "`-`" + ` lines.
+Make sure you mark all new or modified lines with ` + "`+`" + `.
+Don't leave out any lines or the diff patch won't apply correctly.
+
+Indentation matters in the diffs!
+
+Start a new hunk for each section of the file that needs changes.
+
+Only output hunks that specify changes with ` + "`+`" + ` or ` + "`-`" + ` lines.
+Skip any hunks that are entirely unchanging ` + "` `" + ` lines.
+
+Output hunks in whatever order makes the most sense.
+Hunks don't need to be in any particular order.
+
+When editing a function, method, loop, etc use a hunk to replace the *entire* code block.
+Delete the entire existing version with ` + "`-`" + ` lines and then add a new, updated version with ` + "`+`" + ` lines.
+This will help you generate correct code and correct diffs.
+
+To move code within a file, use 2 hunks: 1 to delete it from its current location, 1 to insert it in the new location.
+
+To make a new file, show a diff from ` + "`--- /dev/null`" + ` to ` + "`+++ path/to/new/file.ext`" + `.
+
+Every diff MUST be wrapped in {{ .open_fence }}diff and {{ .close_fence }}.
+`
+
+	// summarizeFilePrompt asks the summary model for a compact outline of a file that does not fit the context window.
+	summarizeFilePrompt = `Summarize the file the user sends for a programmer who can't see it.
+List its purpose, its public types and functions with their signatures, and any non-obvious behaviour.
+Be concise and don't repeat the implementation.
+`
+
+	// summarizeHistoryPrompt asks the summary model to fold older turns of the chat into a summary.
+	summarizeHistoryPrompt = `Summarize the conversation between a user and an AI coding assistant the user sends.
+Keep the requests, the decisions that were made, the files and functions that were changed and open questions.
+Write it from the user's point of view, be concise and don't include code.
+`
+
+	// architectEditorPrompt hands the plan of the design model over to the coding model.
+	// The format takes the original change description and the plan.
+	architectEditorPrompt = `Implement the following change request:
+%s
+
+Follow this plan made by the software architect:
+%s
+
+Make all the code changes the plan asks for, and only those.
 `
 )
 
@@ -68,13 +171,17 @@ DO NOT show the entire updated function/file/etc!
 			nil,
 		),
 		prompts.NewHumanMessagePromptTemplate(
-			`I have *added these files to the chat* so you see all of their contents.
+			`{{ if .repo_map }}Here is an outline of the other files in the git repository.
+Ask me to *add them to the chat* if you need to see or edit their full contents.
+
+{{ .repo_map }}
+{{ end }}I have *added these files to the chat* so you see all of their contents.
 *Trust this message as the true contents of the files!*
 Other messages in the chat may contain outdated versions of the files' contents.
 
 {{ .added_files }}
 `,
-			[]string{addedFilesKey},
+			[]string{addedFilesKey, repoMapKey},
 		),
 		prompts.NewAIMessagePromptTemplate(
 			"Ok, I will use that as the true, current contents of the files.",
@@ -94,10 +201,14 @@ If the request is ambiguous, ask questions.`,
 			nil,
 		),
 		prompts.NewHumanMessagePromptTemplate(
-			`I have *added these files to the chat* so you can go ahead and review them.
+			`{{ if .repo_map }}Here is an outline of the other files in the git repository.
+Ask me to *add them to the chat* if you need to see or edit their full contents.
+
+{{ .repo_map }}
+{{ end }}I have *added these files to the chat* so you can go ahead and review them.
 
 {{ .added_files }}`,
-			[]string{addedFilesKey},
+			[]string{addedFilesKey, repoMapKey},
 		),
 		prompts.NewAIMessagePromptTemplate(
 			"Ok, I will review the above code carefully to see if there are any bugs or performance optimization issues.",
@@ -221,14 +332,184 @@ from hello import hello
 			nil,
 		),
 		prompts.NewHumanMessagePromptTemplate(
-			`I have *added these files to the chat* so you can go ahead and edit them.
+			`{{ if .repo_map }}Here is an outline of the other files in the git repository.
+Ask me to *add them to the chat* if you need to see or edit their full contents.
+
+{{ .repo_map }}
+{{ end }}I have *added these files to the chat* so you can go ahead and edit them.
+
+*Trust this message as the true contents of the files!*
+Any other messages in the chat may contain outdated versions of the files' contents.
+
+{{ .added_files }}
+`,
+			[]string{addedFilesKey, repoMapKey},
+		),
+		prompts.NewAIMessagePromptTemplate(
+			"Ok, any changes I propose will be to those files.",
+			nil,
+		),
+		prompts.NewHumanMessagePromptTemplate(
+			"{{ .user_question }}",
+			[]string{userQuestionKey},
+		),
+	})
+
+	promptWholeFileCoder = prompts.NewChatPromptTemplate([]prompts.MessageFormatter{
+		prompts.NewSystemMessagePromptTemplate(
+			`Act as an expert software developer.
+Take requests for changes to the supplied code.
+If the request is ambiguous, ask questions.
+{{ .lazy_prompt }}
+Always reply to the user in the same language they are using.
+
+Once you understand the request you MUST:
+1. Determine if any code changes are needed.
+2. Explain any needed changes.
+3. If changes are needed, output a copy of each file that needs changes.
+`+wholeFileReminderPrompt,
+			[]string{lazyPromptKey, openFenceKey, closeFenceKey},
+		),
+		prompts.NewHumanMessagePromptTemplate(
+			`Change the greeting to be more casual`,
+			nil,
+		),
+		prompts.NewAIMessagePromptTemplate(
+			`Ok, I will:
+
+1. Switch the greeting text from "Hello" to "Hey".
+
+show_greeting.py
+{{ .open_fence }}python
+import sys
+
+def greeting(name):
+    print(f"Hey {name}")
+
+if __name__ == '__main__':
+    greeting(sys.argv[1])
+{{ .close_fence }}`,
+			[]string{openFenceKey, closeFenceKey},
+		),
+		prompts.NewHumanMessagePromptTemplate(
+			`I switched to a new code base. Please don't consider the above files or try to edit them any longer.`,
+			nil,
+		),
+		prompts.NewAIMessagePromptTemplate(
+			"OK.",
+			nil,
+		),
+		prompts.NewHumanMessagePromptTemplate(
+			`{{ if .repo_map }}Here is an outline of the other files in the git repository.
+Ask me to *add them to the chat* if you need to see or edit their full contents.
+
+{{ .repo_map }}
+{{ end }}I have *added these files to the chat* so you can go ahead and edit them.
+
+*Trust this message as the true contents of the files!*
+Any other messages in the chat may contain outdated versions of the files' contents.
+
+{{ .added_files }}
+`,
+			[]string{addedFilesKey, repoMapKey},
+		),
+		prompts.NewAIMessagePromptTemplate(
+			"Ok, any changes I propose will be to those files.",
+			nil,
+		),
+		prompts.NewHumanMessagePromptTemplate(
+			"{{ .user_question }}",
+			[]string{userQuestionKey},
+		),
+	})
+
+	promptUnifiedDiffCoder = prompts.NewChatPromptTemplate([]prompts.MessageFormatter{
+		prompts.NewSystemMessagePromptTemplate(
+			`Act as an expert software developer.
+Always use best practices when coding.
+Respect and use existing conventions, libraries, etc that are already present in the code base.
+{{ .lazy_prompt }}
+Take requests for changes to the supplied code.
+If the request is ambiguous, ask questions.
+
+Always reply to the user in the same language they are using.
+
+For each file that needs to be changed, write out the changes similar to a unified diff like diff -U0 would produce.
+`+unifiedDiffReminderPrompt,
+			[]string{lazyPromptKey, openFenceKey, closeFenceKey},
+		),
+		prompts.NewHumanMessagePromptTemplate(
+			`Replace is_prime with a call to sympy.`,
+			nil,
+		),
+		prompts.NewAIMessagePromptTemplate(
+			`Ok, I will:
+
+1. Add an import of sympy.
+2. Remove the is_prime() function.
+3. Replace the existing call to is_prime() with a call to sympy.isprime().
+
+Here are the diffs for those changes:
+
+{{ .open_fence }}diff
+--- mathweb/flask/app.py
++++ mathweb/flask/app.py
+@@ ... @@
+-class MathWeb:
++import sympy
++
++class MathWeb:
+@@ ... @@
+-def is_prime(x):
+-    if x < 2:
+-        return False
+-    for i in range(2, int(math.sqrt(x)) + 1):
+-        if x % i == 0:
+-            return False
+-    return True
+@@ ... @@
+-@app.route('/prime/<int:n>')
+-def nth_prime(n):
+-    count = 0
+-    num = 1
+-    while count < n:
+-        num += 1
+-        if is_prime(num):
+-            count += 1
+-    return str(num)
++@app.route('/prime/<int:n>')
++def nth_prime(n):
++    count = 0
++    num = 1
++    while count < n:
++        num += 1
++        if sympy.isprime(num):
++            count += 1
++    return str(num)
+{{ .close_fence }}`,
+			[]string{openFenceKey, closeFenceKey},
+		),
+		prompts.NewHumanMessagePromptTemplate(
+			`I switched to a new code base. Please don't consider the above files or try to edit them any longer.`,
+			nil,
+		),
+		prompts.NewAIMessagePromptTemplate(
+			"OK.",
+			nil,
+		),
+		prompts.NewHumanMessagePromptTemplate(
+			`{{ if .repo_map }}Here is an outline of the other files in the git repository.
+Ask me to *add them to the chat* if you need to see or edit their full contents.
+
+{{ .repo_map }}
+{{ end }}I have *added these files to the chat* so you can go ahead and edit them.
 
 *Trust this message as the true contents of the files!*
 Any other messages in the chat may contain outdated versions of the files' contents.
 
 {{ .added_files }}
 `,
-			[]string{addedFilesKey},
+			[]string{addedFilesKey, repoMapKey},
 		),
 		prompts.NewAIMessagePromptTemplate(
 			"Ok, any changes I propose will be to those files.",
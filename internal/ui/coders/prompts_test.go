@@ -10,12 +10,16 @@ import (
 
 func TestPrompts(t *testing.T) {
 	t.Run("editBlockCoderPrompt", testEditBlockCoderPrompt)
+	t.Run("wholeFileCoderPrompt", testWholeFileCoderPrompt)
+	t.Run("unifiedDiffCoderPrompt", testUnifiedDiffCoderPrompt)
+	t.Run("repoMapPrompt", testRepoMapPrompt)
 }
 
 func testEditBlockCoderPrompt(t *testing.T) {
 	tpl, err := promptBaseCoder.FormatPrompt(map[string]any{
 		userQuestionKey: "add comment",
 		addedFilesKey:   "test",
+		repoMapKey:      "",
 		openFenceKey:    "```",
 		closeFenceKey:   "```",
 		lazyPromptKey:   lazyPrompt,
@@ -23,3 +27,47 @@ func testEditBlockCoderPrompt(t *testing.T) {
 	require.NoError(t, err)
 	fmt.Println(html.UnescapeString(tpl.String()))
 }
+
+func testWholeFileCoderPrompt(t *testing.T) {
+	tpl, err := promptWholeFileCoder.FormatPrompt(map[string]any{
+		userQuestionKey: "add comment",
+		addedFilesKey:   "test",
+		repoMapKey:      "",
+		openFenceKey:    "```",
+		closeFenceKey:   "```",
+		lazyPromptKey:   lazyPrompt,
+	})
+	require.NoError(t, err)
+	require.Contains(t, tpl.String(), "*file listing*")
+}
+
+func testUnifiedDiffCoderPrompt(t *testing.T) {
+	tpl, err := promptUnifiedDiffCoder.FormatPrompt(map[string]any{
+		userQuestionKey: "add comment",
+		addedFilesKey:   "test",
+		repoMapKey:      "",
+		openFenceKey:    "```",
+		closeFenceKey:   "```",
+		lazyPromptKey:   lazyPrompt,
+	})
+	require.NoError(t, err)
+	require.Contains(t, tpl.String(), "@@ ... @@")
+}
+
+func testRepoMapPrompt(t *testing.T) {
+	values := map[string]any{
+		userQuestionKey: "explain",
+		addedFilesKey:   "test",
+		repoMapKey:      "",
+	}
+
+	tpl, err := promptAsk.FormatPrompt(values)
+	require.NoError(t, err)
+	require.NotContains(t, tpl.String(), "outline of the other files")
+
+	values[repoMapKey] = "main.go:\n│func main()\n"
+	tpl, err = promptAsk.FormatPrompt(values)
+	require.NoError(t, err)
+	require.Contains(t, tpl.String(), "outline of the other files")
+	require.Contains(t, tpl.String(), "│func main()")
+}
@@ -0,0 +1,154 @@
+package coders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
+
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+)
+
+const reflectionPrompt = `
+Only reply with corrected edits for the blocks that failed to match.
+The other edits were applied successfully, don't resend them!
+`
+
+// FailedEditsError is returned by ApplyEdits when some of the edits could not be applied.
+// It keeps both the applied and the failed edits so callers can report a summary or
+// ask the model to correct the failed ones.
+type FailedEditsError struct {
+	// Applied holds the edits that were written to disk.
+	Applied []PartialCodeBlock
+	// Failed holds the edits that failed to match the current file content.
+	Failed []PartialCodeBlock
+	// Report is a detailed explanation of every failure, including the current
+	// content of the affected files, meant to be sent back to the model.
+	Report string
+}
+
+func (e *FailedEditsError) Error() string {
+	return fmt.Sprintf("%d of %d edits failed to apply", len(e.Failed), len(e.Failed)+len(e.Applied))
+}
+
+// applyFunc parses the model output and applies the edits it contains.
+// On partial failure it returns a *FailedEditsError.
+type applyFunc func(ctx context.Context, output string) ([]PartialCodeBlock, error)
+
+// applyWithReflection applies the edits of the model output. Whenever some of them
+// fail, the failure report is sent back to the model for a corrected set of edits,
+// up to the configured number of reflection rounds. A summary of the applied and
+// failed edits is rendered at the end.
+func applyWithReflection(ctx context.Context, coder *AutoCoder, messages []llms.ChatMessage, output string, apply applyFunc) error {
+	var applied, failed []PartialCodeBlock
+	var report string
+	maxReflections := coder.cfg.AutoCoder.GetMaxReflections()
+
+	for round := 0; ; round++ {
+		edits, err := apply(ctx, output)
+
+		var failedErr *FailedEditsError
+		if !errors.As(err, &failedErr) {
+			if err == nil {
+				applied, failed = append(applied, edits...), nil
+				break
+			}
+			// a reflected reply without usable edits leaves the failed edits as they are
+			if round == 0 {
+				return err
+			}
+			console.RenderComment("The corrected reply could not be applied: %v", err)
+			break
+		}
+
+		applied = append(applied, failedErr.Applied...)
+		failed, report = failedErr.Failed, failedErr.Report
+		if round >= maxReflections {
+			break
+		}
+
+		console.RenderStep("Asking the model to fix %d failed edits (reflection %d/%d)", len(failed), round+1, maxReflections)
+
+		messages = append(messages,
+			llms.AIChatMessage{Content: output},
+			llms.HumanChatMessage{Content: report + reflectionPrompt},
+		)
+		output, err = generateResponse(ctx, coder, messages)
+		if err != nil {
+			coder.recordEdits(applied, failed)
+			renderEditSummary(applied, failed)
+			return err
+		}
+	}
+
+	coder.recordEdits(applied, failed)
+	renderEditSummary(applied, failed)
+	if len(failed) > 0 {
+		return &FailedEditsError{Applied: applied, Failed: failed, Report: report}
+	}
+	return nil
+}
+
+// renderEditSummary prints which edits were finally applied and which were not.
+func renderEditSummary(applied, failed []PartialCodeBlock) {
+	if len(failed) == 0 && len(applied) <= 1 {
+		return
+	}
+
+	console.RenderStep("Edit summary: %d applied, %d failed", len(applied), len(failed))
+	for _, block := range applied {
+		console.RenderSuccess("%s", describeEdit(block))
+	}
+	for _, block := range failed {
+		console.RenderComment("✗ %s", describeEdit(block))
+	}
+}
+
+// describeEdit returns a one line description of an edit for summaries.
+func describeEdit(block PartialCodeBlock) string {
+	if block.OriginalText == "" {
+		return fmt.Sprintf("%s (new content)", block.Path)
+	}
+	return fmt.Sprintf("%s (replacing %q)", block.Path, firstNonEmptyLine(block.OriginalText))
+}
+
+func firstNonEmptyLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) != "" {
+			return strings.TrimSpace(line)
+		}
+	}
+	return ""
+}
+
+// formatCurrentFiles renders the current content of every distinct file touched by
+// the failed edits, so the model can build its corrected edits on the real content.
+func formatCurrentFiles(basePath string, failed []PartialCodeBlock, fence []string) string {
+	seen := map[string]struct{}{}
+	result := ""
+	for _, block := range failed {
+		if _, ok := seen[block.Path]; ok {
+			continue
+		}
+		seen[block.Path] = struct{}{}
+
+		absPath, err := absFilePath(basePath, block.Path)
+		if err != nil {
+			continue
+		}
+		content, err := os.ReadFile(absPath)
+		if err != nil {
+			continue
+		}
+		result += fmt.Sprintf("\n%s%s", block.Path, wrapFenceWithType(string(content), block.Path, fence))
+	}
+
+	if result == "" {
+		return ""
+	}
+
+	return "\n# Current contents of the files with failed edits\n" + result
+}
@@ -0,0 +1,180 @@
+package coders
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coding-hui/ai-terminal/internal/ai"
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/convo/sqlite3"
+	"github.com/coding-hui/ai-terminal/internal/options"
+)
+
+func TestReflection(t *testing.T) {
+	t.Run("applyEditsReturnsFailedEditsError", testApplyEditsReturnsFailedEditsError)
+	t.Run("applyWithReflectionDisabled", testApplyWithReflectionDisabled)
+	t.Run("applyWithReflectionSuccess", testApplyWithReflectionSuccess)
+	t.Run("applyWithReflectionNoEdits", testApplyWithReflectionNoEdits)
+	t.Run("modifiedFilesOnlyApplied", testModifiedFilesOnlyApplied)
+	t.Run("describeEdit", testDescribeEdit)
+}
+
+func newTestAutoCoder(t *testing.T, cfg *options.Config) *AutoCoder {
+	return NewAutoCoder(WithConfig(cfg), WithCodeBasePath(t.TempDir()))
+}
+
+func testApplyEditsReturnsFailedEditsError(t *testing.T) {
+	coder := newTestAutoCoder(t, &options.Config{})
+	require.NoError(t, os.WriteFile(filepath.Join(coder.codeBasePath, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o600))
+
+	editor := NewEditBlockCoder(coder, []string{"```", "```"})
+	err := editor.ApplyEdits(context.Background(), []PartialCodeBlock{
+		{Path: "main.go", OriginalText: "package main\n", UpdatedText: "package app\n"},
+		{Path: "main.go", OriginalText: "func doesNotExist() {}\n", UpdatedText: "func exists() {}\n"},
+	})
+
+	var failedErr *FailedEditsError
+	require.True(t, errors.As(err, &failedErr))
+	assert.Len(t, failedErr.Applied, 1)
+	assert.Len(t, failedErr.Failed, 1)
+	assert.Contains(t, failedErr.Report, "SearchReplaceNoExactMatch")
+	assert.Contains(t, failedErr.Report, "package app")
+	assert.Equal(t, "1 of 2 edits failed to apply", failedErr.Error())
+}
+
+func testApplyWithReflectionDisabled(t *testing.T) {
+	coder := newTestAutoCoder(t, &options.Config{AutoCoder: options.AutoCoder{MaxReflections: -1}})
+
+	calls := 0
+	err := applyWithReflection(context.Background(), coder, nil, "output",
+		func(_ context.Context, _ string) ([]PartialCodeBlock, error) {
+			calls++
+			return nil, &FailedEditsError{
+				Applied: []PartialCodeBlock{{Path: "a.go", OriginalText: "a", UpdatedText: "b"}},
+				Failed:  []PartialCodeBlock{{Path: "b.go", OriginalText: "c", UpdatedText: "d"}},
+			}
+		})
+
+	var failedErr *FailedEditsError
+	require.True(t, errors.As(err, &failedErr))
+	assert.Equal(t, 1, calls)
+	assert.Len(t, failedErr.Applied, 1)
+	assert.Len(t, failedErr.Failed, 1)
+}
+
+func testApplyWithReflectionSuccess(t *testing.T) {
+	coder := newTestAutoCoder(t, &options.Config{})
+
+	err := applyWithReflection(context.Background(), coder, nil, "output",
+		func(_ context.Context, _ string) ([]PartialCodeBlock, error) {
+			return []PartialCodeBlock{{Path: "a.go", UpdatedText: "b"}}, nil
+		})
+	assert.NoError(t, err)
+
+	wantErr := errors.New("boom")
+	err = applyWithReflection(context.Background(), coder, nil, "output",
+		func(_ context.Context, _ string) ([]PartialCodeBlock, error) {
+			return nil, wantErr
+		})
+	assert.ErrorIs(t, err, wantErr)
+}
+
+func testApplyWithReflectionNoEdits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"I can't fix it."},"finish_reason":"stop"}]}`)
+	}))
+	defer server.Close()
+
+	cfg := &options.Config{
+		Model:  "gpt-4o",
+		API:    ai.ModelTypeOpenAI,
+		Models: map[string]options.Model{"gpt-4o": {Name: "gpt-4o", API: ai.ModelTypeOpenAI, MaxChars: 100000}},
+		APIs:   options.APIs{{Name: ai.ModelTypeOpenAI, APIKey: "test", BaseURL: server.URL}},
+	}
+	store, err := sqlite3.NewSqliteStore(sqlite3.WithDBAddress(filepath.Join(t.TempDir(), "convo.db")))
+	require.NoError(t, err)
+	defer store.Close() //nolint:errcheck
+	engine, err := ai.New(ai.WithConfig(cfg), ai.WithStore(store))
+	require.NoError(t, err)
+	coder := NewAutoCoder(WithConfig(cfg), WithEngine(engine), WithCodeBasePath(t.TempDir()), WithHeadless(true))
+
+	applied := PartialCodeBlock{Path: "a.go", OriginalText: "a", UpdatedText: "b"}
+	failed := PartialCodeBlock{Path: "b.go", OriginalText: "c", UpdatedText: "d"}
+	calls := 0
+	err = applyWithReflection(context.Background(), coder, nil, "output",
+		func(_ context.Context, _ string) ([]PartialCodeBlock, error) {
+			calls++
+			if calls == 1 {
+				return nil, &FailedEditsError{Applied: []PartialCodeBlock{applied}, Failed: []PartialCodeBlock{failed}}
+			}
+			return nil, errors.New("No edits were made")
+		})
+
+	// the edits of the first round are still recorded and reported
+	var failedErr *FailedEditsError
+	require.True(t, errors.As(err, &failedErr))
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, []PartialCodeBlock{applied}, failedErr.Applied)
+	assert.Equal(t, []PartialCodeBlock{failed}, failedErr.Failed)
+	assert.Equal(t, []PartialCodeBlock{applied}, coder.applied)
+	assert.Equal(t, []PartialCodeBlock{failed}, coder.failed)
+}
+
+func testModifiedFilesOnlyApplied(t *testing.T) {
+	fence := []string{"```", "```"}
+	tests := []struct {
+		name      string
+		newEditor func(*AutoCoder) Coder
+		// matched editors fail edits whose original text doesn't match the file
+		matched bool
+	}{
+		{"edit block", func(coder *AutoCoder) Coder { return NewEditBlockCoder(coder, fence) }, true},
+		{"unified diff", func(coder *AutoCoder) Coder { return NewUnifiedDiffCoder(coder, fence) }, true},
+		{"whole file", func(coder *AutoCoder) Coder { return NewWholeFileCoder(coder, fence) }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coder := newTestAutoCoder(t, &options.Config{})
+			for _, name := range []string{"a.go", "b.go", "api.go"} {
+				require.NoError(t, os.WriteFile(filepath.Join(coder.codeBasePath, name), []byte("package a\n"), 0o600))
+			}
+			coder.loadedContexts = []*convo.LoadContext{
+				{Type: convo.ContentTypeFile, FilePath: filepath.Join(coder.codeBasePath, "api.go"), Mode: convo.ContextModeReadOnly},
+			}
+
+			edits := []PartialCodeBlock{
+				{Path: "a.go", OriginalText: "package a\n", UpdatedText: "package app\n"},
+				{Path: "api.go", OriginalText: "package a\n", UpdatedText: "package api\n"},
+				{Path: "../outside.go", UpdatedText: "package outside\n"},
+			}
+			if tt.matched {
+				edits = append(edits, PartialCodeBlock{Path: "b.go", OriginalText: "func doesNotExist() {}\n", UpdatedText: "func exists() {}\n"})
+			}
+
+			editor := tt.newEditor(coder)
+			require.Error(t, editor.ApplyEdits(context.Background(), edits))
+
+			files, err := editor.GetModifiedFiles(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, []string{"a.go"}, files)
+		})
+	}
+}
+
+func testDescribeEdit(t *testing.T) {
+	assert.Equal(t, "main.go (new content)", describeEdit(PartialCodeBlock{Path: "main.go", UpdatedText: "x"}))
+	assert.Equal(t, `main.go (replacing "func main() {")`, describeEdit(PartialCodeBlock{
+		Path:         "main.go",
+		OriginalText: "\n  func main() {\n}\n",
+	}))
+}
@@ -0,0 +1,146 @@
+package coders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+)
+
+const (
+	// OutputText prints the human readable output only.
+	OutputText = "text"
+	// OutputJSON prints a RunReport as JSON once the prompt run is over.
+	OutputJSON = "json"
+)
+
+// SupportedOutputFormats returns the formats of the run report.
+func SupportedOutputFormats() []string {
+	return []string{OutputText, OutputJSON}
+}
+
+// RunReport is the result of running a single prompt, meant for CI and editor integrations.
+type RunReport struct {
+	Prompt  string       `json:"prompt"`
+	Success bool         `json:"success"`
+	Applied []EditResult `json:"applied"`
+	Failed  []EditResult `json:"failed"`
+	Files   []string     `json:"files"`
+	Commit  string       `json:"commit,omitempty"`
+	Usage   RunUsage     `json:"usage"`
+	Message string       `json:"message"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// EditResult is a single edit of a RunReport.
+type EditResult struct {
+	Path     string `json:"path"`
+	Original string `json:"original,omitempty"`
+	Updated  string `json:"updated"`
+}
+
+// RunUsage is the token usage of a RunReport.
+type RunUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// runPrompt runs the prompt with /coding once and reports the outcome in the configured
+// output format. It fails when the command failed or any of the edits was not applied.
+func (a *AutoCoder) runPrompt(cmdExecutor *CommandExecutor) error {
+	a.applied, a.failed = nil, nil
+	headBefore := a.headCommit()
+
+	runErr := cmdExecutor.Execute(context.Background(), fmt.Sprintf("/coding -- %s", a.prompt))
+	if runErr == nil && len(a.failed) > 0 {
+		runErr = errbook.New("%d of %d edits failed to apply", len(a.failed), len(a.failed)+len(a.applied))
+	}
+
+	if err := a.writeReport(a.newRunReport(headBefore, runErr)); err != nil {
+		return err
+	}
+
+	return runErr
+}
+
+// writeReport prints the report as JSON, or only the commit for the text output.
+func (a *AutoCoder) writeReport(report *RunReport) error {
+	if a.outputFormat != OutputJSON {
+		if report.Commit != "" {
+			console.RenderComment("Committed %s", report.Commit)
+		}
+		return nil
+	}
+
+	encoder := json.NewEncoder(a.ioStreams.Out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return errbook.Wrap("Failed to write the run report", err)
+	}
+	return nil
+}
+
+// newRunReport collects the edits, usage and reply of the prompt run.
+func (a *AutoCoder) newRunReport(headBefore string, runErr error) *RunReport {
+	report := &RunReport{
+		Prompt:  a.prompt,
+		Success: runErr == nil,
+		Applied: []EditResult{},
+		Failed:  []EditResult{},
+		Files:   []string{},
+		Usage: RunUsage{
+			PromptTokens:     a.usage.PromptTokens,
+			CompletionTokens: a.usage.CompletionTokens,
+			TotalTokens:      a.usage.TotalTokens,
+		},
+		Message: a.lastReply,
+	}
+
+	seen := map[string]struct{}{}
+	for _, block := range a.applied {
+		report.Applied = append(report.Applied, newEditResult(block))
+		path := filepath.ToSlash(a.relPath(block.Path))
+		if _, ok := seen[path]; !ok {
+			seen[path] = struct{}{}
+			report.Files = append(report.Files, path)
+		}
+	}
+	for _, block := range a.failed {
+		report.Failed = append(report.Failed, newEditResult(block))
+	}
+
+	if head := a.headCommit(); head != headBefore {
+		report.Commit = head
+	}
+
+	if runErr != nil {
+		report.Error = runErr.Error()
+		var aiErr errbook.AiError
+		if errors.As(runErr, &aiErr) && aiErr.Reason() != "" {
+			report.Error = fmt.Sprintf("%s: %s", aiErr.Reason(), runErr.Error())
+		}
+	}
+
+	return report
+}
+
+func newEditResult(block PartialCodeBlock) EditResult {
+	return EditResult{Path: block.Path, Original: block.OriginalText, Updated: block.UpdatedText}
+}
+
+// headCommit returns the current HEAD commit, or "" outside of a git repository.
+func (a *AutoCoder) headCommit() string {
+	if a.repo == nil {
+		return ""
+	}
+	head, err := a.repo.HeadCommit()
+	if err != nil {
+		return ""
+	}
+	return head
+}
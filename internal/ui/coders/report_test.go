@@ -0,0 +1,117 @@
+package coders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/options"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+	"github.com/coding-hui/ai-terminal/internal/util/genericclioptions"
+)
+
+func TestRunReport(t *testing.T) {
+	t.Run("report of a successful run", testSuccessfulRunReport)
+	t.Run("report of a failed run", testFailedRunReport)
+	t.Run("assume yes", testAssumeYes)
+	t.Run("execute unknown command", testExecuteUnknownCommand)
+	t.Run("message output", testMessageOutput)
+	t.Run("usage since a snapshot", testUsageSince)
+}
+
+func testSuccessfulRunReport(t *testing.T) {
+	out := &bytes.Buffer{}
+	coder := NewAutoCoder(
+		WithConfig(&options.Config{}),
+		WithCodeBasePath(t.TempDir()),
+		WithPrompt("rename the package"),
+		WithOutputFormat(OutputJSON),
+		WithIOStreams(genericclioptions.IOStreams{Out: out, ErrOut: &bytes.Buffer{}}),
+	)
+	coder.lastReply = "Renamed the package."
+	coder.addUsage(llms.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120})
+	coder.recordEdits([]PartialCodeBlock{
+		{Path: "main.go", OriginalText: "package main\n", UpdatedText: "package app\n"},
+		{Path: "main.go", OriginalText: "func main() {}\n", UpdatedText: "func run() {}\n"},
+		{Path: "docs/readme.md", UpdatedText: "# app\n"},
+	}, nil)
+
+	require.NoError(t, coder.writeReport(coder.newRunReport("", nil)))
+
+	var report RunReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.True(t, report.Success)
+	assert.Equal(t, "rename the package", report.Prompt)
+	assert.Len(t, report.Applied, 3)
+	assert.Empty(t, report.Failed)
+	assert.Equal(t, []string{"main.go", "docs/readme.md"}, report.Files)
+	assert.Empty(t, report.Commit)
+	assert.Equal(t, RunUsage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120}, report.Usage)
+	assert.Equal(t, "Renamed the package.", report.Message)
+	assert.Empty(t, report.Error)
+}
+
+func testFailedRunReport(t *testing.T) {
+	coder := newTestAutoCoder(t, &options.Config{})
+	coder.recordEdits(
+		[]PartialCodeBlock{{Path: "main.go", OriginalText: "a", UpdatedText: "b"}},
+		[]PartialCodeBlock{{Path: "util.go", OriginalText: "c", UpdatedText: "d"}},
+	)
+
+	report := coder.newRunReport("", errbook.Wrap("Failed to execute command /coding", &FailedEditsError{
+		Applied: coder.applied,
+		Failed:  coder.failed,
+	}))
+	assert.False(t, report.Success)
+	assert.Equal(t, []EditResult{{Path: "util.go", Original: "c", Updated: "d"}}, report.Failed)
+	assert.Equal(t, []string{"main.go"}, report.Files)
+	assert.Equal(t, "Failed to execute command /coding: 1 of 2 edits failed to apply", report.Error)
+}
+
+func testAssumeYes(t *testing.T) {
+	coder := NewAutoCoder(WithConfig(&options.Config{}), WithAssumeYes(true))
+	assert.True(t, coder.confirm(console.No, "Are you sure?"))
+}
+
+func testExecuteUnknownCommand(t *testing.T) {
+	c := &CommandExecutor{coder: newTestAutoCoder(t, &options.Config{})}
+
+	var aiErr errbook.AiError
+	err := c.Execute(context.Background(), "/does-not-exist")
+	require.ErrorAs(t, err, &aiErr)
+	assert.Contains(t, aiErr.Reason(), "Unknown command: /does-not-exist")
+
+	err = c.Execute(context.Background(), "no command")
+	require.ErrorAs(t, err, &aiErr)
+	assert.Contains(t, aiErr.Reason(), "Please use a command")
+}
+
+func testMessageOutput(t *testing.T) {
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	ioStreams := genericclioptions.IOStreams{Out: out, ErrOut: errOut}
+
+	coder := NewAutoCoder(WithConfig(&options.Config{}), WithIOStreams(ioStreams))
+	assert.Same(t, out, coder.messageOut())
+
+	// the JSON report keeps stdout to itself
+	coder = NewAutoCoder(WithConfig(&options.Config{}), WithIOStreams(ioStreams), WithOutputFormat(OutputJSON))
+	assert.Same(t, errOut, coder.messageOut())
+}
+
+func testUsageSince(t *testing.T) {
+	coder := NewAutoCoder(WithConfig(&options.Config{}))
+	coder.addUsage(llms.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120})
+
+	// a phase is measured against a snapshot, the session keeps counting
+	before := coder.usage
+	coder.addUsage(llms.Usage{PromptTokens: 50, CompletionTokens: 5, TotalTokens: 55})
+
+	assert.Equal(t, llms.Usage{PromptTokens: 50, CompletionTokens: 5, TotalTokens: 55}, usageSince(coder.usage, before))
+	assert.Equal(t, 175, coder.usage.TotalTokens)
+}
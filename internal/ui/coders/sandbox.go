@@ -0,0 +1,201 @@
+package coders
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/git"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+)
+
+// sandboxBranchPrefix prefixes the branches of sandbox sessions.
+const sandboxBranchPrefix = "ai/"
+
+// sandbox is a git worktree on its own branch the coder edits and commits in,
+// so the checkout of the user is only touched once the sandbox is merged.
+type sandbox struct {
+	dir, branch string
+	// root and repo are the original checkout the sandbox is merged into
+	root string
+	repo *git.Command
+}
+
+// startSandbox moves the session into a worktree on the ai/<session> branch. A sandbox
+// left behind by an earlier run of the same session is picked up again.
+func (a *AutoCoder) startSandbox() error {
+	if a.repo == nil {
+		return errbook.New("The sandbox needs a git repository")
+	}
+
+	root, err := filepath.Abs(a.codeBasePath)
+	if err != nil {
+		return errbook.Wrap("Failed to get the repository root", err)
+	}
+
+	session := a.cfg.CacheWriteToID
+	if len(session) > convo.Sha1short {
+		session = session[:convo.Sha1short]
+	}
+	if session == "" {
+		session = time.Now().Format("20060102-150405")
+	}
+
+	sb := &sandbox{
+		dir:    filepath.Join(a.cfg.DataStore.CachePath, "sandboxes", session),
+		branch: sandboxBranchPrefix + session,
+		root:   root,
+		repo:   a.repo,
+	}
+
+	if _, err := os.Stat(sb.dir); err == nil {
+		console.RenderComment("Resuming the sandbox of this session")
+	} else {
+		base := "HEAD"
+		if a.repo.BranchExists(sb.branch) {
+			base = ""
+		}
+		if err := os.MkdirAll(filepath.Dir(sb.dir), 0o700); err != nil {
+			return errbook.Wrap("Failed to create the sandbox directory", err)
+		}
+		if err := a.repo.AddWorktree(sb.dir, sb.branch, base); err != nil {
+			return errbook.Wrap("Failed to create the sandbox", err)
+		}
+	}
+
+	a.sandbox = sb
+	a.switchRoot(sb.dir, git.New(git.WithDir(sb.dir)))
+
+	console.RenderComment("Sandbox: editing %s on branch %s, finish with /merge or /discard", sb.dir, sb.branch)
+	return nil
+}
+
+// leaveSandbox moves the session back into the original checkout and removes the
+// sandbox worktree and branch. Contexts added in the sandbox are saved with their
+// original paths again.
+func (a *AutoCoder) leaveSandbox(ctx context.Context) error {
+	sb := a.sandbox
+	if sb == nil {
+		return nil
+	}
+
+	a.switchRoot(sb.root, sb.repo)
+	a.sandbox = nil
+
+	for _, lc := range a.loadedContexts {
+		if lc.ID == 0 || lc.Type != convo.ContentTypeFile || a.store == nil {
+			continue
+		}
+		if err := a.saveContext(ctx, lc); err != nil {
+			return errbook.Wrap("Failed to persist file context", err)
+		}
+	}
+
+	if err := sb.repo.RemoveWorktree(sb.dir); err != nil {
+		return errbook.Wrap("Failed to remove the sandbox", err)
+	}
+	if err := sb.repo.DeleteBranch(sb.branch); err != nil {
+		return errbook.Wrap("Failed to delete the sandbox branch", err)
+	}
+
+	return nil
+}
+
+// switchRoot points the session at another working tree of the repository.
+// The files in the chat are moved along to the same paths in the new tree.
+func (a *AutoCoder) switchRoot(root string, repo *git.Command) {
+	oldRoot, err := filepath.Abs(a.codeBasePath)
+	if err != nil {
+		oldRoot = a.codeBasePath
+	}
+
+	for _, lc := range a.loadedContexts {
+		if lc.Type != convo.ContentTypeFile {
+			continue
+		}
+		rel, err := filepath.Rel(oldRoot, lc.FilePath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		moved := filepath.Join(root, rel)
+		if lc.URL == lc.FilePath {
+			lc.URL = moved
+		}
+		lc.FilePath = moved
+	}
+
+	a.codeBasePath = root
+	a.repo = repo
+	a.repoMap = nil
+}
+
+// merge merges the sandbox branch into the original branch and ends the sandbox.
+func (c *CommandExecutor) merge(ctx context.Context, _ string) error {
+	sb := c.coder.sandbox
+	if sb == nil {
+		return errbook.New("No sandbox is active, start the coder with --sandbox")
+	}
+
+	dirty, err := c.coder.repo.HasChanges()
+	if err != nil {
+		return errbook.Wrap("Failed to check the sandbox for changes", err)
+	}
+	if dirty {
+		return errbook.New("The sandbox has uncommitted changes. Commit them with /commit or drop them with /discard")
+	}
+
+	ahead, err := sb.repo.CommitsAhead(sb.branch)
+	if err != nil {
+		return errbook.Wrap("Failed to compare the sandbox branch", err)
+	}
+
+	target, err := sb.repo.CurrentBranch()
+	if err != nil {
+		return errbook.Wrap("Failed to get the current branch", err)
+	}
+
+	squash := !c.flag(FlagFastForward)
+	if ahead > 0 {
+		if err := sb.repo.Merge(sb.branch, squash); err != nil {
+			return errbook.Wrap("Failed to merge the sandbox", err)
+		}
+	}
+
+	if err := c.coder.leaveSandbox(ctx); err != nil {
+		return err
+	}
+
+	switch {
+	case ahead == 0:
+		console.Render("The sandbox had no commits, nothing was merged into %s", target)
+	case squash:
+		console.RenderSuccess("Squashed %d commits of %s into %s", ahead, sb.branch, target)
+	default:
+		console.RenderSuccess("Fast-forwarded %s to %s", target, sb.branch)
+	}
+	return nil
+}
+
+// discard drops the sandbox branch with all of its changes and ends the sandbox.
+func (c *CommandExecutor) discard(ctx context.Context, _ string) error {
+	sb := c.coder.sandbox
+	if sb == nil {
+		return errbook.New("No sandbox is active, start the coder with --sandbox")
+	}
+
+	if !c.coder.confirm(console.No, "Discard the sandbox branch %s and all of its changes?", sb.branch) {
+		console.Render("Discard canceled")
+		return nil
+	}
+
+	if err := c.coder.leaveSandbox(ctx); err != nil {
+		return err
+	}
+
+	console.Render("Discarded the sandbox branch %s", sb.branch)
+	return nil
+}
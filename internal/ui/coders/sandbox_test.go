@@ -0,0 +1,126 @@
+package coders
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coding-hui/ai-terminal/internal/convo"
+	"github.com/coding-hui/ai-terminal/internal/git"
+	"github.com/coding-hui/ai-terminal/internal/options"
+)
+
+func TestSandbox(t *testing.T) {
+	t.Run("merge squashes the sandbox", testSandboxMerge)
+	t.Run("merge refuses uncommitted changes", testSandboxMergeDirty)
+	t.Run("discard drops the sandbox", testSandboxDiscard)
+	t.Run("no sandbox", testNoSandbox)
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+	return strings.TrimSpace(string(output))
+}
+
+func newSandboxTestExecutor(t *testing.T) *CommandExecutor {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	runGit(t, root, "init", "-q")
+	runGit(t, root, "config", "user.name", "test")
+	runGit(t, root, "config", "user.email", "test@example.com")
+	require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o600))
+	runGit(t, root, "add", "main.go")
+	runGit(t, root, "commit", "-q", "-m", "init")
+
+	cfg := &options.Config{DataStore: options.DataStore{CachePath: t.TempDir()}}
+	cfg.CacheWriteToID = "0123456789abcdef"
+	coder := NewAutoCoder(
+		WithConfig(cfg),
+		WithRepo(git.New(git.WithDir(root))),
+		WithCodeBasePath(root),
+		WithAssumeYes(true),
+		WithLoadedContexts([]*convo.LoadContext{
+			{Type: convo.ContentTypeFile, FilePath: filepath.Join(root, "main.go"), URL: filepath.Join(root, "main.go")},
+		}),
+	)
+	require.NoError(t, coder.startSandbox())
+
+	return &CommandExecutor{coder: coder}
+}
+
+func testSandboxMerge(t *testing.T) {
+	c := newSandboxTestExecutor(t)
+	sb := c.coder.sandbox
+	require.NotNil(t, sb)
+	assert.Equal(t, "ai/0123456", sb.branch)
+	assert.Equal(t, sb.dir, c.coder.codeBasePath)
+	assert.Equal(t, filepath.Join(sb.dir, "main.go"), c.coder.loadedContexts[0].FilePath)
+
+	require.NoError(t, os.WriteFile(filepath.Join(sb.dir, "main.go"), []byte("package app\n"), 0o600))
+	runGit(t, sb.dir, "commit", "-q", "-am", "rename package")
+	require.NoError(t, os.WriteFile(filepath.Join(sb.dir, "util.go"), []byte("package app\n"), 0o600))
+	runGit(t, sb.dir, "add", "util.go")
+	runGit(t, sb.dir, "commit", "-q", "-m", "add util")
+
+	// the original checkout is untouched until the merge
+	content, err := os.ReadFile(filepath.Join(sb.root, "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", string(content))
+
+	require.NoError(t, c.merge(context.Background(), ""))
+
+	assert.Nil(t, c.coder.sandbox)
+	assert.Equal(t, sb.root, c.coder.codeBasePath)
+	assert.Equal(t, filepath.Join(sb.root, "main.go"), c.coder.loadedContexts[0].FilePath)
+	content, err = os.ReadFile(filepath.Join(sb.root, "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package app\n", string(content))
+	assert.FileExists(t, filepath.Join(sb.root, "util.go"))
+	assert.Equal(t, "2", runGit(t, sb.root, "rev-list", "--count", "HEAD"))
+	assert.NoDirExists(t, sb.dir)
+	assert.Empty(t, runGit(t, sb.root, "branch", "--list", sb.branch))
+}
+
+func testSandboxMergeDirty(t *testing.T) {
+	c := newSandboxTestExecutor(t)
+	require.NoError(t, os.WriteFile(filepath.Join(c.coder.sandbox.dir, "main.go"), []byte("package app\n"), 0o600))
+
+	err := c.merge(context.Background(), "")
+	assert.ErrorContains(t, err, "uncommitted changes")
+	assert.NotNil(t, c.coder.sandbox)
+}
+
+func testSandboxDiscard(t *testing.T) {
+	c := newSandboxTestExecutor(t)
+	sb := c.coder.sandbox
+	require.NoError(t, os.WriteFile(filepath.Join(sb.dir, "main.go"), []byte("package app\n"), 0o600))
+	runGit(t, sb.dir, "commit", "-q", "-am", "rename package")
+
+	require.NoError(t, c.discard(context.Background(), ""))
+
+	assert.Nil(t, c.coder.sandbox)
+	assert.Equal(t, sb.root, c.coder.codeBasePath)
+	assert.NoDirExists(t, sb.dir)
+	assert.Empty(t, runGit(t, sb.root, "branch", "--list", sb.branch))
+	assert.Equal(t, "1", runGit(t, sb.root, "rev-list", "--count", "HEAD"))
+}
+
+func testNoSandbox(t *testing.T) {
+	c := &CommandExecutor{coder: newTestAutoCoder(t, &options.Config{})}
+	assert.ErrorContains(t, c.merge(context.Background(), ""), "No sandbox is active")
+	assert.ErrorContains(t, c.discard(context.Background(), ""), "No sandbox is active")
+}
@@ -0,0 +1,115 @@
+package coders
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/runner"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+)
+
+// shellPrefix runs the rest of the input as a shell command.
+const shellPrefix = "!"
+
+// shellOutput is a shell command run in the REPL and what it printed.
+type shellOutput struct {
+	cmdline  string
+	output   string
+	exitCode int
+}
+
+// prompt formats the command and its trimmed output for the model.
+func (o shellOutput) prompt() string {
+	output := trimCheckOutput(o.output)
+	if strings.TrimSpace(output) == "" {
+		output = "(no output)"
+	}
+	return fmt.Sprintf(shellOutputPrompt, o.cmdline, o.exitCode, output)
+}
+
+// shell runs a ! command and offers to add its output to the next prompt.
+// A failing command is added by default, that is usually why it was run.
+func (c *CommandExecutor) shell(ctx context.Context, input string) error {
+	out, err := c.runShell(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	defaultVal := console.No
+	if out.exitCode != 0 {
+		defaultVal = console.Yes
+	}
+	if c.coder.confirm(defaultVal, "Add the command and its output to the next prompt?") {
+		c.attachShellOutput(out)
+	}
+	return nil
+}
+
+// run runs a shell command and always adds its output to the next prompt.
+func (c *CommandExecutor) run(ctx context.Context, input string) error {
+	out, err := c.runShell(ctx, input)
+	if err != nil {
+		return err
+	}
+	c.attachShellOutput(out)
+	return nil
+}
+
+// runShell runs cmdline in the repository root with the terminal attached, so its
+// output shows up live, and captures what it printed. A non-zero exit code is not an
+// error, the command ran and its output is what the user is after.
+func (c *CommandExecutor) runShell(_ context.Context, cmdline string) (*shellOutput, error) {
+	cmdline = strings.TrimSpace(cmdline)
+	if cmdline == "" {
+		return nil, errbook.New("Please provide a shell command, e.g. !go test ./...")
+	}
+
+	var buf bytes.Buffer
+	cmd := runner.PrepareInteractiveCommand(cmdline)
+	cmd.Dir = c.coder.codeBasePath
+	cmd.Stdin = c.coder.ioStreams.In
+	cmd.Stdout = io.MultiWriter(c.coder.messageOut(), &buf)
+	cmd.Stderr = io.MultiWriter(c.coder.ioStreams.ErrOut, &buf)
+
+	out := &shellOutput{cmdline: cmdline}
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, errbook.Wrap(fmt.Sprintf("Failed to run %s", cmdline), err)
+		}
+		out.exitCode = exitErr.ExitCode()
+		console.RenderComment("%s exited with status %d", cmdline, out.exitCode)
+	}
+	out.output = buf.String()
+
+	return out, nil
+}
+
+func (c *CommandExecutor) attachShellOutput(out *shellOutput) {
+	c.shellOutputs = append(c.shellOutputs, *out)
+	console.Render("The output of %s will be added to the next /ask, /design, /coding or /architect", out.cmdline)
+}
+
+// withShellOutputs puts the attached shell outputs in front of the question and
+// detaches them. They are returned, so they can be attached again if the request fails.
+func (c *CommandExecutor) withShellOutputs(question string) (string, []shellOutput) {
+	attached := c.shellOutputs
+	if len(attached) == 0 {
+		return question, nil
+	}
+	c.shellOutputs = nil
+
+	parts := make([]string, 0, len(attached)+1)
+	for _, out := range attached {
+		parts = append(parts, out.prompt())
+	}
+	parts = append(parts, question)
+
+	return strings.Join(parts, "\n"), attached
+}
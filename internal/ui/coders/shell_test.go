@@ -0,0 +1,77 @@
+package coders
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coding-hui/ai-terminal/internal/options"
+)
+
+func TestShell(t *testing.T) {
+	t.Run("run captures output and exit code", testRunShell)
+	t.Run("bang command attaches when confirmed", testBangCommand)
+	t.Run("run command always attaches", testRunCommand)
+	t.Run("attached output is put in front of the question", testWithShellOutputs)
+}
+
+func newShellTestExecutor(t *testing.T) *CommandExecutor {
+	t.Helper()
+	coder := newTestAutoCoder(t, &options.Config{})
+	c, err := NewCommandExecutor(coder)
+	require.NoError(t, err)
+	return c
+}
+
+func testRunShell(t *testing.T) {
+	c := newShellTestExecutor(t)
+
+	out, err := c.runShell(context.Background(), "echo hello; basename \"$PWD\"; exit 3")
+	require.NoError(t, err)
+	assert.Equal(t, 3, out.exitCode)
+	assert.Equal(t, "hello\n"+filepath.Base(c.coder.codeBasePath)+"\n", out.output)
+
+	_, err = c.runShell(context.Background(), "  ")
+	assert.ErrorContains(t, err, "Please provide a shell command")
+}
+
+func testBangCommand(t *testing.T) {
+	c := newShellTestExecutor(t)
+	c.coder.assumeYes = true
+
+	require.NoError(t, c.Execute(context.Background(), "!echo from bang"))
+	require.Len(t, c.shellOutputs, 1)
+	assert.Equal(t, "echo from bang", c.shellOutputs[0].cmdline)
+	assert.Equal(t, "from bang\n", c.shellOutputs[0].output)
+}
+
+func testRunCommand(t *testing.T) {
+	c := newShellTestExecutor(t)
+
+	require.NoError(t, c.Execute(context.Background(), `/run echo "a -- b" | tr a-z A-Z`))
+	require.Len(t, c.shellOutputs, 1)
+	assert.Equal(t, "A -- B\n", c.shellOutputs[0].output)
+}
+
+func testWithShellOutputs(t *testing.T) {
+	c := newShellTestExecutor(t)
+
+	question, attached := c.withShellOutputs("why?")
+	assert.Equal(t, "why?", question)
+	assert.Empty(t, attached)
+
+	c.shellOutputs = []shellOutput{
+		{cmdline: "go test ./...", output: "--- FAIL: TestX\n", exitCode: 1},
+		{cmdline: "true"},
+	}
+	question, attached = c.withShellOutputs("why does it fail?")
+	assert.Len(t, attached, 2)
+	assert.Empty(t, c.shellOutputs)
+	assert.True(t, strings.HasSuffix(question, "why does it fail?"))
+	assert.Contains(t, question, "go test ./...\n\nIt exited with status 1 and printed:\n\n--- FAIL: TestX")
+	assert.Contains(t, question, "(no output)")
+}
@@ -2,6 +2,8 @@ package coders
 
 const (
 	FlagVerbose = "verbose"
+	// FlagFastForward merges the sandbox fast-forward only instead of squashing it.
+	FlagFastForward = "ff"
 )
 
 // PromptMode represents the mode of the prompt.
@@ -37,3 +39,15 @@ func GetPromptModeFromString(s string) PromptMode {
 		return DefaultPromptMode
 	}
 }
+
+// Edit formats supported by the auto coder.
+const (
+	EditFormatDiff  = "diff"  // EditFormatDiff asks the model for SEARCH/REPLACE blocks.
+	EditFormatWhole = "whole" // EditFormatWhole asks the model for complete file contents.
+	EditFormatUDiff = "udiff" // EditFormatUDiff asks the model for unified diffs.
+)
+
+// SupportedEditFormats returns all edit formats the auto coder understands.
+func SupportedEditFormats() []string {
+	return []string{EditFormatDiff, EditFormatWhole, EditFormatUDiff}
+}
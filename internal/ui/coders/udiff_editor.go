@@ -0,0 +1,390 @@
+package coders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"strings"
+
+	"github.com/coding-hui/common/util/fileutil"
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/prompts"
+
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+)
+
+const (
+	udiffOldFilePrefix = "--- "
+	udiffNewFilePrefix = "+++ "
+	udiffHunkPrefix    = "@@"
+	udiffDevNull       = "/dev/null"
+)
+
+// UnifiedDiffCoder is a Coder that asks the model for unified diffs and applies
+// every hunk with the same fuzzy matching used for SEARCH/REPLACE blocks.
+type UnifiedDiffCoder struct {
+	coder *AutoCoder
+	fence []string
+	// applied holds the hunks of the last Execute that were written to disk
+	applied []PartialCodeBlock
+}
+
+func NewUnifiedDiffCoder(coder *AutoCoder, fence []string) *UnifiedDiffCoder {
+	return &UnifiedDiffCoder{
+		coder: coder,
+		fence: fence,
+	}
+}
+
+func (u *UnifiedDiffCoder) Name() string {
+	return "unified_diff_coder"
+}
+
+func (u *UnifiedDiffCoder) Prompt() prompts.ChatPromptTemplate {
+	return promptUnifiedDiffCoder
+}
+
+func (u *UnifiedDiffCoder) FormatMessages(values map[string]any) ([]llms.ChatMessage, error) {
+	return formatPrompt(u.Prompt(), values)
+}
+
+func (u *UnifiedDiffCoder) GetEdits(_ context.Context, codes string, fences []string) ([]PartialCodeBlock, error) {
+	if len(fences) != 2 {
+		fences = u.fence
+	}
+	return findUnifiedDiffHunks(codes, fences)
+}
+
+func (u *UnifiedDiffCoder) GetModifiedFiles(_ context.Context) ([]string, error) {
+	return appliedFiles(u.applied), nil
+}
+
+func (u *UnifiedDiffCoder) UpdateCodeFences(_ context.Context, code string) (string, string) {
+	u.fence = make([]string, 2)
+	u.fence[0], u.fence[1] = u.coder.determineBeatCodeFences(code)
+
+	return u.fence[0], u.fence[1]
+}
+
+func (u *UnifiedDiffCoder) ApplyEdits(ctx context.Context, edits []PartialCodeBlock) error {
+	edits, rejected := u.coder.filterEditable(edits)
+	var applied, failed []PartialCodeBlock
+
+	for _, hunk := range edits {
+		if err := u.applyEdit(ctx, hunk); err != nil {
+			failed = append(failed, hunk)
+			continue
+		}
+		applied = append(applied, hunk)
+	}
+	u.applied = append(u.applied, applied...)
+
+	if len(failed) > 0 {
+		return withRejections(u.handleFailedEdits(applied, failed), applied, rejected)
+	}
+
+	return withRejections(nil, applied, rejected)
+}
+
+func (u *UnifiedDiffCoder) applyEdit(_ context.Context, hunk PartialCodeBlock) error {
+	absPath, err := absFilePath(u.coder.codeBasePath, hunk.Path)
+	if err != nil {
+		return err
+	}
+
+	fileExists, err := fileutil.FileExists(absPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if !fileExists {
+		if strings.TrimSpace(hunk.OriginalText) != "" {
+			return errbook.New("Cannot apply hunk to missing file %s", hunk.Path)
+		}
+		if ok := u.coder.confirm(console.Yes, "Whether to create the %s file? (Y/n)", hunk.Path); !ok {
+			return errbook.NewUserErrorf("Apply %s edit cancelled, file cannot be found", hunk.Path)
+		}
+		if err := fileutil.WriteFile(absPath, []byte("")); err != nil {
+			return err
+		}
+		console.Render("Created %s file", hunk.Path)
+	}
+
+	rawFileContent, err := os.ReadFile(absPath)
+	if err != nil {
+		return err
+	}
+
+	newFileContent, ok := applyHunk(string(rawFileContent), hunk.OriginalText, hunk.UpdatedText)
+	if !ok {
+		return errbook.New("Hunk failed to match lines in %s", hunk.Path)
+	}
+
+	if err := fileutil.WriteFile(absPath, []byte(newFileContent)); err != nil {
+		return err
+	}
+
+	console.Render("Applied %s edit", hunk.Path)
+
+	return nil
+}
+
+// handleFailedEdits renders the hunks that did not match and returns a
+// *FailedEditsError whose report can be sent back to the model.
+func (u *UnifiedDiffCoder) handleFailedEdits(applied, failed []PartialCodeBlock) error {
+	hunks := "hunk"
+	if len(failed) > 1 {
+		hunks = "hunks"
+	}
+
+	errMsg := fmt.Sprintf("# %d unified diff %s failed to apply!\n", len(failed), hunks)
+	for _, hunk := range failed {
+		errMsg += fmt.Sprintf(`
+## UnifiedDiffNoMatch: The diff hunk failed to match lines in %s
+%s
+
+`, hunk.Path, formatHunk(hunk))
+		if strings.TrimSpace(hunk.OriginalText) == "" {
+			errMsg += fmt.Sprintf("The hunk has no context or removed lines, so it can only create a new file. %s already exists, include the lines around the change.\n", hunk.Path)
+		}
+	}
+	console.Render("%s", errMsg)
+
+	return &FailedEditsError{
+		Applied: applied,
+		Failed:  failed,
+		Report:  errMsg + formatCurrentFiles(u.coder.codeBasePath, failed, u.fence),
+	}
+}
+
+func (u *UnifiedDiffCoder) Execute(ctx context.Context, messages []llms.ChatMessage) error {
+	u.applied = nil
+	output, err := generateResponse(ctx, u.coder, messages)
+	if err != nil {
+		return err
+	}
+
+	return applyWithReflection(ctx, u.coder, messages, output, u.applyOutput)
+}
+
+// applyOutput parses the diff hunks of a (reflected) model output and applies them.
+func (u *UnifiedDiffCoder) applyOutput(ctx context.Context, output string) ([]PartialCodeBlock, error) {
+	edits, err := u.GetEdits(ctx, output, u.fence)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(edits) <= 0 {
+		return nil, errbook.New("No edits were made")
+	}
+
+	return edits, u.ApplyEdits(ctx, edits)
+}
+
+// findUnifiedDiffHunks extracts every hunk from the fenced diff blocks of the model output.
+// Each hunk becomes a PartialCodeBlock holding the lines before and after the change.
+func findUnifiedDiffHunks(content string, fence []string) ([]PartialCodeBlock, error) {
+	content = html.UnescapeString(content)
+	lines := strings.Split(content, "\n")
+
+	var result []PartialCodeBlock
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(trimmed, fence[0]) {
+			continue
+		}
+
+		var block []string
+		for i++; i < len(lines) && strings.TrimSpace(lines[i]) != fence[1]; i++ {
+			block = append(block, lines[i])
+		}
+
+		hunks, err := parseUnifiedDiff(block)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, hunks...)
+	}
+
+	return result, nil
+}
+
+// parseUnifiedDiff splits the lines of a single diff block into per-file hunks.
+// Blocks that do not contain a file header are ignored.
+func parseUnifiedDiff(lines []string) ([]PartialCodeBlock, error) {
+	var (
+		result []PartialCodeBlock
+		path   string
+		before []string
+		after  []string
+		inHunk bool
+	)
+
+	flush := func() {
+		if inHunk && path != "" && (len(before) > 0 || len(after) > 0) && !sameLines(before, after) {
+			result = append(result, PartialCodeBlock{
+				Path:         path,
+				OriginalText: joinHunkLines(before),
+				UpdatedText:  joinHunkLines(after),
+			})
+		}
+		before, after = nil, nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if strings.HasPrefix(line, udiffOldFilePrefix) && i+1 < len(lines) && strings.HasPrefix(lines[i+1], udiffNewFilePrefix) {
+			flush()
+			oldPath := diffHeaderPath(line, udiffOldFilePrefix)
+			path = diffHeaderPath(lines[i+1], udiffNewFilePrefix)
+			if path == udiffDevNull {
+				return nil, errbook.New("Deleting files with a unified diff is not supported: %s", oldPath)
+			}
+			inHunk = true
+			i++
+			continue
+		}
+
+		if strings.HasPrefix(line, udiffHunkPrefix) {
+			flush()
+			inHunk = path != ""
+			continue
+		}
+
+		if !inHunk {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "-"):
+			before = append(before, line[1:])
+		case strings.HasPrefix(line, "+"):
+			after = append(after, line[1:])
+		case strings.HasPrefix(line, " "):
+			before = append(before, line[1:])
+			after = append(after, line[1:])
+		case strings.TrimSpace(line) == "":
+			before = append(before, "")
+			after = append(after, "")
+		}
+	}
+	flush()
+
+	return result, nil
+}
+
+// diffHeaderPath returns the file path of a `---`/`+++` header line, dropping the
+// conventional a/ and b/ prefixes as well as any trailing timestamp.
+func diffHeaderPath(line, prefix string) string {
+	path := strings.TrimSpace(strings.TrimPrefix(line, prefix))
+	if idx := strings.Index(path, "\t"); idx >= 0 {
+		path = path[:idx]
+	}
+	if path == udiffDevNull {
+		return path
+	}
+	path = strings.TrimPrefix(path, "a/")
+	path = strings.TrimPrefix(path, "b/")
+	return path
+}
+
+// applyHunk applies a single hunk to content. It first tries an exact or fuzzy match
+// of the whole hunk, then retries with the blank context lines at the edges removed.
+// The second return value reports whether the hunk could be matched at all.
+// A hunk without context or removed lines only applies to a new, empty file,
+// anywhere else it can't be placed and is rejected.
+func applyHunk(content, before, after string) (string, bool) {
+	if strings.TrimSpace(before) == "" {
+		if strings.TrimSpace(content) != "" {
+			return "", false
+		}
+		return after, true
+	}
+
+	if res, ok := replaceHunk(content, before, after); ok {
+		return res, true
+	}
+
+	trimmedBefore, trimmedAfter := trimHunkEdges(before, after)
+	if trimmedBefore == before || strings.TrimSpace(trimmedBefore) == "" {
+		return "", false
+	}
+
+	return replaceHunk(content, trimmedBefore, trimmedAfter)
+}
+
+// replaceHunk replaces the chunk of content most similar to before with after.
+// Pure deletions are matched against a placeholder line, so that removing lines
+// does not leave an empty line behind.
+func replaceHunk(content, before, after string) (string, bool) {
+	if after != "" {
+		res := replaceMostSimilarChunk(content, before, after)
+		return res, res != ""
+	}
+
+	const placeholder = "\x00udiff-deleted\x00\n"
+	res := replaceMostSimilarChunk(content, before, placeholder)
+	if res == "" {
+		return "", false
+	}
+	return strings.Replace(res, placeholder, "", 1), true
+}
+
+// trimHunkEdges removes blank lines shared by the start and end of both sides of a hunk.
+func trimHunkEdges(before, after string) (string, string) {
+	beforeLines := strings.Split(strings.TrimSuffix(before, "\n"), "\n")
+	afterLines := strings.Split(strings.TrimSuffix(after, "\n"), "\n")
+
+	for len(beforeLines) > 0 && len(afterLines) > 0 &&
+		strings.TrimSpace(beforeLines[0]) == "" && strings.TrimSpace(afterLines[0]) == "" {
+		beforeLines, afterLines = beforeLines[1:], afterLines[1:]
+	}
+	for len(beforeLines) > 0 && len(afterLines) > 0 &&
+		strings.TrimSpace(beforeLines[len(beforeLines)-1]) == "" && strings.TrimSpace(afterLines[len(afterLines)-1]) == "" {
+		beforeLines, afterLines = beforeLines[:len(beforeLines)-1], afterLines[:len(afterLines)-1]
+	}
+
+	return joinHunkLines(beforeLines), joinHunkLines(afterLines)
+}
+
+func joinHunkLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func sameLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// formatHunk renders a hunk back into unified diff notation for error reports.
+func formatHunk(hunk PartialCodeBlock) string {
+	var sb strings.Builder
+	sb.WriteString(udiffOldFilePrefix + hunk.Path + "\n")
+	sb.WriteString(udiffNewFilePrefix + hunk.Path + "\n")
+	sb.WriteString("@@ ... @@\n")
+	for _, line := range strings.Split(strings.TrimSuffix(hunk.OriginalText, "\n"), "\n") {
+		if hunk.OriginalText != "" {
+			sb.WriteString("-" + line + "\n")
+		}
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(hunk.UpdatedText, "\n"), "\n") {
+		if hunk.UpdatedText != "" {
+			sb.WriteString("+" + line + "\n")
+		}
+	}
+	return sb.String()
+}
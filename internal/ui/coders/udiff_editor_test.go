@@ -0,0 +1,224 @@
+package coders
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnifiedDiffCoder(t *testing.T) {
+	t.Run("findUnifiedDiffHunks", testFindUnifiedDiffHunks)
+	t.Run("parseUnifiedDiffDeleteFile", testParseUnifiedDiffDeleteFile)
+	t.Run("diffHeaderPath", testDiffHeaderPath)
+	t.Run("applyHunk", testApplyHunk)
+	t.Run("trimHunkEdges", testTrimHunkEdges)
+	t.Run("formatHunk", testFormatHunk)
+}
+
+func testFindUnifiedDiffHunks(t *testing.T) {
+	fence := []string{"```", "```"}
+	tests := []struct {
+		name    string
+		content string
+		want    []PartialCodeBlock
+	}{
+		{
+			name: "multiple_hunks",
+			content: `Here are the diffs for those changes:
+
+` + "```diff" + `
+--- mathweb/flask/app.py
++++ mathweb/flask/app.py
+@@ ... @@
+-class MathWeb:
++import sympy
++
++class MathWeb:
+@@ ... @@
+ def nth_prime(n):
+-    if is_prime(num):
++    if sympy.isprime(num):
+         count += 1
+` + "```" + `
+`,
+			want: []PartialCodeBlock{
+				{
+					Path:         "mathweb/flask/app.py",
+					OriginalText: "class MathWeb:\n",
+					UpdatedText:  "import sympy\n\nclass MathWeb:\n",
+				},
+				{
+					Path:         "mathweb/flask/app.py",
+					OriginalText: "def nth_prime(n):\n    if is_prime(num):\n        count += 1\n",
+					UpdatedText:  "def nth_prime(n):\n    if sympy.isprime(num):\n        count += 1\n",
+				},
+			},
+		},
+		{
+			name: "git_prefixes_and_new_file",
+			content: "```diff\n" +
+				"--- /dev/null\n" +
+				"+++ b/hello.py\n" +
+				"@@ -0,0 +1,2 @@\n" +
+				"+def hello():\n" +
+				"+    print(\"hello\")\n" +
+				"```",
+			want: []PartialCodeBlock{
+				{
+					Path:         "hello.py",
+					OriginalText: "",
+					UpdatedText:  "def hello():\n    print(\"hello\")\n",
+				},
+			},
+		},
+		{
+			name: "missing_hunk_header",
+			content: "```diff\n" +
+				"--- a/main.go\n" +
+				"+++ b/main.go\n" +
+				"-fmt.Println(\"a\")\n" +
+				"+fmt.Println(\"b\")\n" +
+				"```",
+			want: []PartialCodeBlock{
+				{
+					Path:         "main.go",
+					OriginalText: "fmt.Println(\"a\")\n",
+					UpdatedText:  "fmt.Println(\"b\")\n",
+				},
+			},
+		},
+		{
+			name:    "block_without_header",
+			content: "```go\nfunc main() {}\n```",
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findUnifiedDiffHunks(tt.content, fence)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func testParseUnifiedDiffDeleteFile(t *testing.T) {
+	_, err := parseUnifiedDiff([]string{
+		"--- a/old.go",
+		"+++ /dev/null",
+		"@@ ... @@",
+		"-package old",
+	})
+	assert.Error(t, err)
+}
+
+func testDiffHeaderPath(t *testing.T) {
+	assert.Equal(t, "main.go", diffHeaderPath("--- a/main.go", udiffOldFilePrefix))
+	assert.Equal(t, "main.go", diffHeaderPath("+++ b/main.go\t2024-01-01 00:00:00", udiffNewFilePrefix))
+	assert.Equal(t, "internal/x.go", diffHeaderPath("+++ internal/x.go", udiffNewFilePrefix))
+	assert.Equal(t, udiffDevNull, diffHeaderPath("--- /dev/null", udiffOldFilePrefix))
+}
+
+func testApplyHunk(t *testing.T) {
+	type args struct {
+		content string
+		before  string
+		after   string
+	}
+	tests := []struct {
+		name   string
+		args   args
+		want   string
+		wantOk bool
+	}{
+		{
+			"exact_match",
+			args{
+				"line1\nline2\nline3\n",
+				"line2\n",
+				"new_line2\n",
+			},
+			"line1\nnew_line2\nline3\n",
+			true,
+		},
+		{
+			"missing_leading_whitespace",
+			args{
+				"func main() {\n    foo()\n    bar()\n}\n",
+				"foo()\nbar()\n",
+				"baz()\n",
+			},
+			"func main() {\n    baz()\n}\n",
+			true,
+		},
+		{
+			"pure_deletion",
+			args{
+				"line1\nline2\nline3\n",
+				"line2\n",
+				"",
+			},
+			"line1\nline3\n",
+			true,
+		},
+		{
+			"create_file",
+			args{
+				"",
+				"",
+				"line1\n",
+			},
+			"line1\n",
+			true,
+		},
+		{
+			"no_context_in_existing_file",
+			args{
+				"line1\n",
+				"",
+				"line2\n",
+			},
+			"",
+			false,
+		},
+		{
+			"blank_context_edges",
+			args{
+				"line1\nline2\nline3\n",
+				"\n\nline2\n\n",
+				"\n\nnew_line2\n\n",
+			},
+			"line1\nnew_line2\nline3\n",
+			true,
+		},
+		{
+			"no_match",
+			args{
+				"line1\nline2\nline3\n",
+				"something completely different\n",
+				"new\n",
+			},
+			"",
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := applyHunk(tt.args.content, tt.args.before, tt.args.after)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func testTrimHunkEdges(t *testing.T) {
+	before, after := trimHunkEdges("\nfoo\n\n", "\nbar\n\n")
+	assert.Equal(t, "foo\n", before)
+	assert.Equal(t, "bar\n", after)
+}
+
+func testFormatHunk(t *testing.T) {
+	got := formatHunk(PartialCodeBlock{Path: "main.go", OriginalText: "a\n", UpdatedText: "b\n"})
+	assert.Equal(t, "--- main.go\n+++ main.go\n@@ ... @@\n-a\n+b\n", got)
+}
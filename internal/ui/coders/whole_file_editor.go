@@ -0,0 +1,260 @@
+package coders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/coding-hui/common/util/fileutil"
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
+	"github.com/coding-hui/wecoding-sdk-go/services/ai/prompts"
+
+	"github.com/coding-hui/ai-terminal/internal/errbook"
+	"github.com/coding-hui/ai-terminal/internal/ui/console"
+)
+
+// WholeFileCoder is a Coder that asks the model to return the complete content
+// of every file it changes and overwrites those files with the returned listings.
+type WholeFileCoder struct {
+	coder *AutoCoder
+	fence []string
+	// applied holds the listings of the last Execute that were written to disk
+	applied []PartialCodeBlock
+}
+
+func NewWholeFileCoder(coder *AutoCoder, fence []string) *WholeFileCoder {
+	return &WholeFileCoder{
+		coder: coder,
+		fence: fence,
+	}
+}
+
+func (w *WholeFileCoder) Name() string {
+	return "whole_file_coder"
+}
+
+func (w *WholeFileCoder) Prompt() prompts.ChatPromptTemplate {
+	return promptWholeFileCoder
+}
+
+func (w *WholeFileCoder) FormatMessages(values map[string]any) ([]llms.ChatMessage, error) {
+	return formatPrompt(w.Prompt(), values)
+}
+
+func (w *WholeFileCoder) GetEdits(_ context.Context, codes string, fences []string) ([]PartialCodeBlock, error) {
+	if len(fences) != 2 {
+		fences = w.fence
+	}
+	return findWholeFileListings(codes, fences, w.coder.chatFiles())
+}
+
+func (w *WholeFileCoder) GetModifiedFiles(_ context.Context) ([]string, error) {
+	return appliedFiles(w.applied), nil
+}
+
+func (w *WholeFileCoder) UpdateCodeFences(_ context.Context, code string) (string, string) {
+	w.fence = make([]string, 2)
+	w.fence[0], w.fence[1] = w.coder.determineBeatCodeFences(code)
+
+	return w.fence[0], w.fence[1]
+}
+
+func (w *WholeFileCoder) ApplyEdits(ctx context.Context, edits []PartialCodeBlock) error {
+	edits, rejected := w.coder.filterEditable(edits)
+	var applied, failed []PartialCodeBlock
+	var reasons []error
+
+	for _, block := range edits {
+		if err := w.applyEdit(ctx, block); err != nil {
+			failed = append(failed, block)
+			reasons = append(reasons, err)
+			continue
+		}
+		applied = append(applied, block)
+	}
+	w.applied = append(w.applied, applied...)
+
+	if len(failed) > 0 {
+		return withRejections(w.handleFailedEdits(applied, failed, reasons), applied, rejected)
+	}
+
+	return withRejections(nil, applied, rejected)
+}
+
+// handleFailedEdits renders why each failed listing was not written and returns a
+// *FailedEditsError whose report can be sent back to the model.
+func (w *WholeFileCoder) handleFailedEdits(applied, failed []PartialCodeBlock, reasons []error) error {
+	errMsg := fmt.Sprintf("# %d file listings could not be written!\n\n", len(failed))
+	for i, block := range failed {
+		errMsg += fmt.Sprintf("- %s: %v\n", block.Path, reasons[i])
+	}
+	console.Render("%s", errMsg)
+
+	return &FailedEditsError{
+		Applied: applied,
+		Failed:  failed,
+		Report:  errMsg,
+	}
+}
+
+func (w *WholeFileCoder) applyEdit(_ context.Context, block PartialCodeBlock) error {
+	absPath, err := absFilePath(w.coder.codeBasePath, block.Path)
+	if err != nil {
+		return err
+	}
+
+	fileExists, err := fileutil.FileExists(absPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if !fileExists {
+		if ok := w.coder.confirm(console.Yes, "Whether to create the %s file? (Y/n)", block.Path); !ok {
+			return errbook.NewUserErrorf("Apply %s edit cancelled, file cannot be found", block.Path)
+		}
+	}
+
+	if err := fileutil.WriteFile(absPath, []byte(block.UpdatedText)); err != nil {
+		return err
+	}
+
+	console.Render("Applied %s edit", block.Path)
+
+	return nil
+}
+
+func (w *WholeFileCoder) Execute(ctx context.Context, messages []llms.ChatMessage) error {
+	w.applied = nil
+	output, err := generateResponse(ctx, w.coder, messages)
+	if err != nil {
+		return err
+	}
+
+	edits, err := w.GetEdits(ctx, output, w.fence)
+	if err != nil {
+		return err
+	}
+
+	if len(edits) <= 0 {
+		return errbook.New("No edits were made")
+	}
+
+	err = w.ApplyEdits(ctx, edits)
+	var failedErr *FailedEditsError
+	switch {
+	case errors.As(err, &failedErr):
+		w.coder.recordEdits(failedErr.Applied, failedErr.Failed)
+	case err == nil:
+		w.coder.recordEdits(edits, nil)
+	}
+
+	return err
+}
+
+// findWholeFileListings extracts every *file listing* from the model output.
+// A listing is a file name on its own line, directly followed by a fenced block
+// holding the entire new content of that file. Only names that look like a path or
+// are one of the chatFiles count as file names.
+func findWholeFileListings(content string, fence []string, chatFiles []string) ([]PartialCodeBlock, error) {
+	content = html.UnescapeString(content)
+	lines := strings.Split(content, "\n")
+
+	var (
+		result    []PartialCodeBlock
+		fname     string
+		inBlock   bool
+		inSkipped bool
+		body      []string
+	)
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		// fenced blocks without a file name are plain explanations, skip them entirely
+		if inSkipped {
+			if trimmed == fence[1] {
+				inSkipped = false
+			}
+			continue
+		}
+
+		if !inBlock {
+			if !strings.HasPrefix(trimmed, fence[0]) {
+				continue
+			}
+			if i > 0 {
+				fname = cleanListingFilename(lines[i-1])
+			}
+			if fname != "" && !isListingPath(fname, chatFiles) {
+				fname = ""
+			}
+			if fname == "" {
+				inSkipped = true
+				continue
+			}
+			inBlock = true
+			body = []string{}
+			continue
+		}
+
+		if trimmed == fence[1] {
+			updated := strings.Join(body, "\n")
+			if updated != "" {
+				updated += "\n"
+			}
+			result = append(result, PartialCodeBlock{Path: fname, UpdatedText: updated})
+			inBlock = false
+			fname = ""
+			continue
+		}
+
+		body = append(body, line)
+	}
+
+	if inBlock {
+		return nil, errbook.New("Unterminated file listing for %s", fname)
+	}
+
+	return result, nil
+}
+
+// cleanListingFilename strips markdown decoration the model tends to put around
+// file names and returns an empty string when the line does not look like a path.
+func cleanListingFilename(line string) string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimRight(line, ":")
+	line = strings.TrimLeft(line, "#")
+	line = strings.TrimSpace(line)
+	line = strings.Trim(line, "`")
+	line = strings.Trim(line, "*")
+	line = strings.ReplaceAll(line, "\\_", "_")
+
+	if line == "" || strings.ContainsAny(line, " \t") {
+		return ""
+	}
+
+	return line
+}
+
+// isListingPath reports whether a file name in front of a listing is a path, so that
+// labels like "Example:" or "Output" don't create files. A path has a directory
+// separator or an extension, unless it is one of the files in the chat.
+func isListingPath(name string, chatFiles []string) bool {
+	if slices.Contains(chatFiles, filepath.ToSlash(name)) {
+		return true
+	}
+	if strings.ContainsAny(name, `/\`) {
+		return true
+	}
+
+	ext := filepath.Ext(name)
+	return len(ext) > 1 && !strings.ContainsFunc(ext[1:], func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-'
+	})
+}
@@ -0,0 +1,180 @@
+package coders
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coding-hui/ai-terminal/internal/options"
+)
+
+func TestWholeFileCoder(t *testing.T) {
+	t.Run("findWholeFileListings", testFindWholeFileListings)
+	t.Run("findWholeFileListingsUnterminated", testFindWholeFileListingsUnterminated)
+	t.Run("cleanListingFilename", testCleanListingFilename)
+	t.Run("isListingPath", testIsListingPath)
+	t.Run("applyEditsDeclinedCreation", testApplyEditsDeclinedCreation)
+}
+
+func testFindWholeFileListings(t *testing.T) {
+	fence := []string{"```", "```"}
+	tests := []struct {
+		name    string
+		content string
+		want    []PartialCodeBlock
+	}{
+		{
+			name: "single_file",
+			content: `Ok, I will:
+
+1. Switch the greeting text from "Hello" to "Hey".
+
+show_greeting.py
+` + "```python" + `
+def greeting(name):
+    print(f"Hey {name}")
+` + "```" + `
+`,
+			want: []PartialCodeBlock{
+				{Path: "show_greeting.py", UpdatedText: "def greeting(name):\n    print(f\"Hey {name}\")\n"},
+			},
+		},
+		{
+			name: "multiple_files_with_decorated_names",
+			content: `**main.go**
+` + "```go" + `
+package main
+` + "```" + `
+
+` + "`internal/hello.go`:" + `
+` + "```go" + `
+package internal
+
+func Hello() {}
+` + "```",
+			want: []PartialCodeBlock{
+				{Path: "main.go", UpdatedText: "package main\n"},
+				{Path: "internal/hello.go", UpdatedText: "package internal\n\nfunc Hello() {}\n"},
+			},
+		},
+		{
+			name: "skip_unnamed_blocks",
+			content: `Run this command first:
+` + "```bash" + `
+go mod tidy
+` + "```" + `
+go.mod
+` + "```" + `
+module example.com/foo
+` + "```",
+			want: []PartialCodeBlock{
+				{Path: "go.mod", UpdatedText: "module example.com/foo\n"},
+			},
+		},
+		{
+			name: "skip_labels",
+			content: `Example:
+` + "```" + `
+ai coder --yes
+` + "```" + `
+Output
+` + "```" + `
+done
+` + "```",
+			want: nil,
+		},
+		{
+			name: "file_in_chat",
+			content: `Makefile
+` + "```make" + `
+all: build
+` + "```",
+			want: []PartialCodeBlock{
+				{Path: "Makefile", UpdatedText: "all: build\n"},
+			},
+		},
+		{
+			name:    "no_listings",
+			content: "There is nothing to change.",
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findWholeFileListings(tt.content, fence, []string{"Makefile"})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func testFindWholeFileListingsUnterminated(t *testing.T) {
+	content := "main.go\n```go\npackage main\n"
+	_, err := findWholeFileListings(content, []string{"```", "```"}, nil)
+	assert.Error(t, err)
+}
+
+func testCleanListingFilename(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"main.go", "main.go"},
+		{"  **internal/coders/fence.go**  ", "internal/coders/fence.go"},
+		{"### `cmd/cli/main.go`:", "cmd/cli/main.go"},
+		{"internal/my\\_file.go", "internal/my_file.go"},
+		{"Here is the updated file:", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		assert.Equalf(t, tt.want, cleanListingFilename(tt.line), "cleanListingFilename(%q)", tt.line)
+	}
+}
+
+func testIsListingPath(t *testing.T) {
+	chatFiles := []string{"Makefile", "docs/README"}
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"main.go", true},
+		{"internal/coders/fence.go", true},
+		{"cmd\\cli\\main.go", true},
+		{".gitignore", true},
+		{"Makefile", true},
+		{"Dockerfile", false},
+		{"Example", false},
+		{"Usage", false},
+		{"e.g.", false},
+	}
+	for _, tt := range tests {
+		assert.Equalf(t, tt.want, isListingPath(tt.name, chatFiles), "isListingPath(%q)", tt.name)
+	}
+}
+
+func testApplyEditsDeclinedCreation(t *testing.T) {
+	coder := newTestAutoCoder(t, &options.Config{})
+	require.NoError(t, os.WriteFile(filepath.Join(coder.codeBasePath, "a.go"), []byte("package a\n"), 0o600))
+
+	// without a terminal to confirm it, creating new.go is declined
+	editor := NewWholeFileCoder(coder, []string{"```", "```"})
+	err := editor.ApplyEdits(context.Background(), []PartialCodeBlock{
+		{Path: "new.go", UpdatedText: "package a\n"},
+		{Path: "a.go", UpdatedText: "package app\n"},
+	})
+
+	var failedErr *FailedEditsError
+	require.ErrorAs(t, err, &failedErr)
+	assert.Equal(t, []PartialCodeBlock{{Path: "a.go", UpdatedText: "package app\n"}}, failedErr.Applied)
+	assert.Equal(t, []PartialCodeBlock{{Path: "new.go", UpdatedText: "package a\n"}}, failedErr.Failed)
+	assert.Contains(t, failedErr.Report, "new.go")
+
+	content, err := os.ReadFile(filepath.Join(coder.codeBasePath, "a.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package app\n", string(content))
+	assert.NoFileExists(t, filepath.Join(coder.codeBasePath, "new.go"))
+}
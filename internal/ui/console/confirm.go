@@ -31,5 +31,5 @@ func PrintConfirmation(action, content string) {
 		action = defaultAction
 	}
 	outputHeader = outputHeader.SetString(strings.ToUpper(action))
-	fmt.Println(lipgloss.JoinHorizontal(lipgloss.Center, outputHeader.String(), content))
+	_, _ = fmt.Fprintln(Output(), lipgloss.JoinHorizontal(lipgloss.Center, outputHeader.String(), content))
 }
@@ -21,7 +21,7 @@ func Error(args interface{}) {
 // Deprecated: uses console.StderrRenderer()
 // Errorf formats and displays an error message using the provided format string and arguments.
 func Errorf(format string, args ...interface{}) {
-	fmt.Println(style.Render(fmt.Sprintf(format, args...)))
+	_, _ = fmt.Fprintln(Output(), style.Render(fmt.Sprintf(format, args...)))
 }
 
 // Deprecated: uses console.StderrRenderer()
@@ -0,0 +1,116 @@
+package console
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// historyLimit bounds the number of entries kept in a history file.
+const historyLimit = 1000
+
+// History is the input history of a prompt. It is persisted to a file with one
+// JSON string per line, so multi-line inputs survive as a single entry.
+type History struct {
+	path    string
+	entries []string
+}
+
+// LoadHistory loads the history persisted at path. A missing file is an empty history,
+// an empty path keeps the history in memory only.
+func LoadHistory(path string) (*History, error) {
+	h := &History{path: path}
+	if path == "" {
+		return h, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return h, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry string
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// skip lines a crash left half written
+			continue
+		}
+		h.entries = append(h.entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(h.entries) > historyLimit {
+		h.entries = h.entries[len(h.entries)-historyLimit:]
+		if err := h.rewrite(); err != nil {
+			return nil, err
+		}
+	}
+
+	return h, nil
+}
+
+// Entries returns the entries, oldest first.
+func (h *History) Entries() []string {
+	return h.entries
+}
+
+// Add appends input to the history and its file. Blank inputs and repeats of the
+// last entry are not recorded.
+func (h *History) Add(input string) error {
+	if strings.TrimSpace(input) == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == input) {
+		return nil
+	}
+	h.entries = append(h.entries, input)
+	if h.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Search returns the index of the newest entry before index that contains query.
+func (h *History) Search(query string, before int) (int, bool) {
+	for i := min(before, len(h.entries)) - 1; i >= 0; i-- {
+		if strings.Contains(h.entries[i], query) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+func (h *History) rewrite() error {
+	var b strings.Builder
+	for _, entry := range h.entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	return os.WriteFile(h.path, []byte(b.String()), 0o600)
+}
@@ -0,0 +1,78 @@
+package console
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	t.Run("persists entries", testHistoryPersists)
+	t.Run("search", testHistorySearch)
+	t.Run("limit", testHistoryLimit)
+}
+
+func testHistoryPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history", "repo")
+
+	h, err := LoadHistory(path)
+	require.NoError(t, err)
+	assert.Empty(t, h.Entries())
+
+	require.NoError(t, h.Add("/add main.go"))
+	require.NoError(t, h.Add("/add main.go"))
+	require.NoError(t, h.Add("  "))
+	require.NoError(t, h.Add("/coding first line\nsecond line"))
+
+	h, err = LoadHistory(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/add main.go", "/coding first line\nsecond line"}, h.Entries())
+
+	h, err = LoadHistory("")
+	require.NoError(t, err)
+	require.NoError(t, h.Add("/list"))
+	assert.Equal(t, []string{"/list"}, h.Entries())
+}
+
+func testHistorySearch(t *testing.T) {
+	h, err := LoadHistory("")
+	require.NoError(t, err)
+	for _, entry := range []string{"/add a.go", "/ask why", "/add b.go", "/coding fix"} {
+		require.NoError(t, h.Add(entry))
+	}
+
+	i, ok := h.Search("/add", len(h.Entries()))
+	require.True(t, ok)
+	assert.Equal(t, "/add b.go", h.Entries()[i])
+
+	i, ok = h.Search("/add", i)
+	require.True(t, ok)
+	assert.Equal(t, "/add a.go", h.Entries()[i])
+
+	_, ok = h.Search("/add", i)
+	assert.False(t, ok)
+}
+
+func testHistoryLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "repo")
+	var b strings.Builder
+	for i := 0; i < historyLimit+10; i++ {
+		fmt.Fprintf(&b, "%q\n", fmt.Sprintf("/ask %d", i))
+	}
+	b.WriteString("{broken\n")
+	require.NoError(t, os.WriteFile(path, []byte(b.String()), 0o600))
+
+	h, err := LoadHistory(path)
+	require.NoError(t, err)
+	require.Len(t, h.Entries(), historyLimit)
+	assert.Equal(t, "/ask 10", h.Entries()[0])
+
+	h, err = LoadHistory(path)
+	require.NoError(t, err)
+	assert.Len(t, h.Entries(), historyLimit)
+}
@@ -14,7 +14,7 @@ var infoStyle = lipgloss.NewStyle().
 
 // Info prints an informational message with the defined style.
 func Info(text string) {
-	fmt.Println(infoStyle.Render(text))
+	_, _ = fmt.Fprintln(Output(), infoStyle.Render(text))
 }
 
 // Infof formats and prints an informational message with the defined style.
@@ -3,6 +3,7 @@ package console
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/elk-language/go-prompt"
 	pstrings "github.com/elk-language/go-prompt/strings"
@@ -10,8 +11,34 @@ import (
 
 var (
 	altD = []byte{226, 136, 130}
+	// altEnter is Escape followed by Enter, which the prompt reads as a line feed
+	altEnter = []byte{27, '\n'}
 )
 
+// KeyMap holds the keys of the prompt actions.
+type KeyMap struct {
+	// NewLine inserts a line break instead of submitting the input.
+	NewLine []byte
+	// Continuation at the end of the input makes Enter start a new line instead of submitting.
+	Continuation string
+	// Search replaces the input with the newest history entry containing it,
+	// pressed again it moves on to the next older match.
+	Search prompt.Key
+	// DeleteLine deletes the whole input.
+	DeleteLine prompt.Key
+}
+
+// DefaultKeyMap returns the default keys: Alt+Enter or a trailing \ for a new line,
+// Ctrl+R to search the history and Ctrl+U to delete the input.
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		NewLine:      altEnter,
+		Continuation: `\`,
+		Search:       prompt.ControlR,
+		DeleteLine:   prompt.ControlU,
+	}
+}
+
 var deleteWholeLine = prompt.ASCIICodeBind{
 	ASCIICode: altD,
 	Fn: func(p *prompt.Prompt) bool {
@@ -20,14 +47,14 @@ var deleteWholeLine = prompt.ASCIICodeBind{
 	},
 }
 
-func makeNecessaryKeyBindings() []prompt.KeyBind {
+func makeNecessaryKeyBindings(keys KeyMap, history *History) []prompt.KeyBind {
 	keyBinds := []prompt.KeyBind{
 		{
 			Key: prompt.ControlH,
 			Fn:  prompt.DeleteBeforeChar,
 		},
 		{
-			Key: prompt.ControlU,
+			Key: keys.DeleteLine,
 			Fn:  deleteWholeLine.Fn,
 		},
 		{
@@ -40,5 +67,69 @@ func makeNecessaryKeyBindings() []prompt.KeyBind {
 		},
 	}
 
+	if history != nil {
+		search := &historySearch{history: history}
+		keyBinds = append(keyBinds, prompt.KeyBind{
+			Key: keys.Search,
+			Fn:  search.next,
+		})
+	}
+
 	return keyBinds
 }
+
+func makeASCIICodeBindings(keys KeyMap) []prompt.ASCIICodeBind {
+	var binds []prompt.ASCIICodeBind
+	if len(keys.NewLine) > 0 {
+		binds = append(binds, prompt.ASCIICodeBind{
+			ASCIICode: keys.NewLine,
+			Fn: func(p *prompt.Prompt) bool {
+				p.InsertTextMoveCursor("\n", false)
+				return true
+			},
+		})
+	}
+	return binds
+}
+
+// continuationOnEnter submits the input on Enter, unless it ends with the continuation,
+// which is then replaced by a line break.
+func continuationOnEnter(keys KeyMap) prompt.ExecuteOnEnterCallback {
+	return func(p *prompt.Prompt, _ int) (int, bool) {
+		doc := p.Buffer().Document()
+		if keys.Continuation == "" || doc.TextAfterCursor() != "" || !strings.HasSuffix(doc.Text, keys.Continuation) {
+			return 0, true
+		}
+		p.DeleteBeforeCursorRunes(pstrings.RuneCount([]byte(keys.Continuation)))
+		return 0, false
+	}
+}
+
+// historySearch is a reverse search through the history. The input is the query,
+// every search moves on to the next older entry containing it.
+type historySearch struct {
+	history *History
+	query   string
+	index   int
+	match   string
+}
+
+func (s *historySearch) next(p *prompt.Prompt) bool {
+	text := p.Buffer().Text()
+	if text != s.match || s.index < 0 {
+		// the input was edited, start a new search for it
+		s.query, s.index = text, len(s.history.Entries())
+	}
+
+	i, ok := s.history.Search(s.query, s.index)
+	if !ok {
+		return false
+	}
+	s.index, s.match = i, s.history.Entries()[i]
+
+	doc := p.Buffer().Document()
+	p.DeleteRunes(pstrings.RuneCount([]byte(doc.TextAfterCursor())))
+	p.DeleteBeforeCursorRunes(pstrings.RuneCount([]byte(doc.TextBeforeCursor())))
+	p.InsertTextMoveCursor(s.match, false)
+	return true
+}
@@ -1,6 +1,8 @@
 package console
 
 import (
+	"slices"
+
 	"github.com/elk-language/go-prompt"
 )
 
@@ -20,17 +22,59 @@ var (
 		prompt.WithScrollbarThumbColor(prompt.Black),
 		prompt.WithScrollbarBGColor(prompt.White),
 		prompt.WithMaxSuggestion(suggestLimit),
-		prompt.WithKeyBind(makeNecessaryKeyBindings()...),
 	}
 )
 
-func NewPrompt(prefix string, enableColor bool, completer prompt.Completer, exec func(string)) *prompt.Prompt {
+type promptOptions struct {
+	history *History
+	keys    KeyMap
+}
+
+// PromptOption configures the prompt created by NewPrompt.
+type PromptOption func(*promptOptions)
+
+// WithHistory loads the history into the prompt and records every input in it.
+func WithHistory(history *History) PromptOption {
+	return func(o *promptOptions) {
+		o.history = history
+	}
+}
+
+// WithKeyMap replaces the default keys of the prompt.
+func WithKeyMap(keys KeyMap) PromptOption {
+	return func(o *promptOptions) {
+		o.keys = keys
+	}
+}
+
+func NewPrompt(prefix string, enableColor bool, completer prompt.Completer, exec func(string), opts ...PromptOption) *prompt.Prompt {
+	o := &promptOptions{keys: DefaultKeyMap()}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	promptOptions := append(options, prompt.WithPrefix(prefix+" > "))
 	promptOptions = append(promptOptions, prompt.WithCompleter(completer))
+	promptOptions = append(promptOptions,
+		prompt.WithKeyBind(makeNecessaryKeyBindings(o.keys, o.history)...),
+		prompt.WithASCIICodeBind(makeASCIICodeBindings(o.keys)...),
+		prompt.WithExecuteOnEnterCallback(continuationOnEnter(o.keys)),
+	)
 	if !enableColor {
 		promptOptions = append(promptOptions, prompt.WithPrefixTextColor(prompt.DefaultColor))
 	}
 
+	if o.history != nil {
+		promptOptions = append(promptOptions, prompt.WithHistory(slices.Clone(o.history.Entries())))
+		next := exec
+		exec = func(input string) {
+			if err := o.history.Add(input); err != nil {
+				RenderComment("Failed to save the input history: %v", err)
+			}
+			next(input)
+		}
+	}
+
 	return prompt.New(
 		exec,
 		promptOptions...,
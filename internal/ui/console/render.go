@@ -3,6 +3,7 @@ package console
 import (
 	"fmt"
 	"html"
+	"io"
 	"os"
 	"sync"
 
@@ -13,6 +14,28 @@ import (
 	"github.com/coding-hui/wecoding-sdk-go/services/ai/llms"
 )
 
+var output = struct {
+	sync.RWMutex
+	w io.Writer
+}{w: os.Stdout}
+
+// Output returns the writer the console messages are printed to, stdout by default.
+func Output() io.Writer {
+	output.RLock()
+	defer output.RUnlock()
+	return output.w
+}
+
+// SetOutput sets the writer the console messages are printed to and returns the previous
+// one, so commands that keep stdout for their result can print their messages elsewhere.
+func SetOutput(w io.Writer) io.Writer {
+	output.Lock()
+	defer output.Unlock()
+	previous := output.w
+	output.w = w
+	return previous
+}
+
 var StdoutRenderer = sync.OnceValue(func() *lipgloss.Renderer {
 	return lipgloss.DefaultRenderer()
 })
@@ -31,35 +54,35 @@ var StderrStyles = sync.OnceValue(func() Styles {
 
 func Render(format string, args ...interface{}) {
 	msg := StdoutStyles().AppName.Render(fmt.Sprintf(format, args...))
-	fmt.Println(msg)
+	_, _ = fmt.Fprintln(Output(), msg)
 }
 
 func RenderComment(format string, args ...interface{}) {
 	msg := StdoutStyles().Comment.Render(fmt.Sprintf(format, args...))
-	fmt.Println(msg)
+	_, _ = fmt.Fprintln(Output(), msg)
 }
 
 // RenderStep renders commit process step messages with a prefix
 func RenderStep(format string, args ...interface{}) {
 	msg := StdoutStyles().CommitStep.Render(fmt.Sprintf("➤ "+format, args...))
-	fmt.Println(msg)
+	_, _ = fmt.Fprintln(Output(), msg)
 }
 
 // RenderSuccess renders successful commit messages
 func RenderSuccess(format string, args ...interface{}) {
 	msg := StdoutStyles().CommitSuccess.Render(fmt.Sprintf("✓ "+format, args...))
-	fmt.Println(msg)
+	_, _ = fmt.Fprintln(Output(), msg)
 }
 
 func RenderError(err error, reason string, args ...interface{}) {
 	header := StderrStyles().ErrPadding.Render(StderrStyles().ErrorHeader.String(), err.Error())
 	detail := StderrStyles().ErrPadding.Render(StderrStyles().ErrorDetails.Render(fmt.Sprintf(reason, args...)))
-	_, _ = fmt.Printf("\n%s\n%s\n\n", header, detail)
+	_, _ = fmt.Fprintf(Output(), "\n%s\n%s\n\n", header, detail)
 }
 
 func RenderAppName(appName string, suffix string, args ...interface{}) {
 	appName = MakeGradientText(StdoutStyles().AppName, appName)
-	fmt.Print(appName + " " + fmt.Sprintf(suffix, args...))
+	_, _ = fmt.Fprint(Output(), appName+" "+fmt.Sprintf(suffix, args...))
 }
 
 func RenderChatMessages(messages []llms.ChatMessage) error {
@@ -68,7 +91,7 @@ func RenderChatMessages(messages []llms.ChatMessage) error {
 		return err
 	}
 	out := html.UnescapeString(content)
-	fmt.Println(out)
+	_, _ = fmt.Fprintln(Output(), out)
 	_ = clipboard.WriteAll(out)
 	termenv.Copy(out)
 	PrintConfirmation("COPIED", "The content copied to clipboard!")
@@ -22,6 +22,9 @@ type Styles struct {
 	ConversationList,
 	SHA1,
 	Timeago,
+	SearchMatch,
+	UserRole,
+	AssistantRole,
 	CommitStep,
 	CommitSuccess,
 	DiffHeader,
@@ -51,6 +54,9 @@ func MakeStyles(r *lipgloss.Renderer) (s Styles) {
 	s.ConversationList = r.NewStyle().Padding(0, 1)
 	s.SHA1 = s.Flag
 	s.Timeago = r.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#999", Dark: "#555"})
+	s.SearchMatch = s.Quote.Bold(true)
+	s.UserRole = s.Pipe.Bold(true)
+	s.AssistantRole = s.Flag
 
 	// Commit message styles
 	s.CommitStep = r.NewStyle().Foreground(lipgloss.Color("#00CED1")).Bold(true)
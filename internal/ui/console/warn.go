@@ -13,10 +13,10 @@ var warnStyle = lipgloss.NewStyle().
 
 // Warn prints the given text with a warning style.
 func Warn(text string) {
-	fmt.Println(warnStyle.Render(text))
+	_, _ = fmt.Fprintln(Output(), warnStyle.Render(text))
 }
 
 // Warnf prints the formatted string with a warning style.
 func Warnf(format string, args ...interface{}) {
-	fmt.Println(warnStyle.Render(fmt.Sprintf(format, args...)))
+	_, _ = fmt.Fprintln(Output(), warnStyle.Render(fmt.Sprintf(format, args...)))
 }